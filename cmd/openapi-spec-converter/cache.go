@@ -0,0 +1,159 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// jsonlCacheSize 是 --jsonl 模式下缓存的转换结果数量
+const jsonlCacheSize = 64
+
+// cachedConversion 是 CachingConverter 缓存的一次转换结果
+type cachedConversion struct {
+	key      string    // conversionCacheKey 生成的键
+	output   []byte    // 转换为目标格式后的文档
+	warnings []Warning // 转换过程中记录的警告
+	steps    []string  // 转换过程中执行的步骤
+}
+
+// CachingConverter 缓存最近的转换结果，用于反复转换相同文档的场景（例如 --jsonl 输入中重复的行和 serve 模式）。
+// 缓存按 LRU 淘汰，可以被多个 goroutine 同时使用。
+type CachingConverter struct {
+	size    int                      // 最多缓存的转换结果数量
+	entries *list.List               // 缓存的 *cachedConversion，最近使用的在前
+	index   map[string]*list.Element // 键 -> entries 中的元素
+	hits    int                      // 命中缓存的次数
+	misses  int                      // 没有命中缓存的次数
+	mutex   sync.Mutex
+}
+
+// NewCachingConverter 创建最多缓存 size 个转换结果的 CachingConverter，size 小于 1 时不缓存任何结果。
+func NewCachingConverter(size int) *CachingConverter {
+	return &CachingConverter{
+		size:    size,
+		entries: list.New(),
+		index:   map[string]*list.Element{},
+	}
+}
+
+// conversionCacheKey 根据输入数据、目标版本、是否输出 Postman Collection 和输出格式生成缓存的键（SHA-256 的十六进制字符串）。
+// 注意：Postman Collection 的目标版本同样是 OpenAPI30，所以 postman 必须是键的一部分
+func conversionCacheKey(data []byte, target SpecVersion, postman bool, format Format) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n%t\n%d\n", target, postman, format)
+	hash.Write(data)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Convert 与包级别的 Convert 相同，将 data 转换为 options 设置的目标版本和输出格式，相同的文档只转换一次。
+// 命中缓存时，缓存的警告同样被传递给 WithWarningsSink 设置的函数。
+//
+// 注意：键不包含目标版本和输出格式以外的参数，一个 CachingConverter 只能用于这些参数都相同的转换
+func (cache *CachingConverter) Convert(data []byte, options ...Option) ([]byte, error) {
+	opts, err := newOptions(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	output, _, err := opts.convert(data, cache)
+
+	return output, err
+}
+
+// convert 将 data 转换为 converter 的目标版本和输出格式，相同的输入、目标版本和输出格式只转换一次。
+// 操作：
+//   - 命中缓存时，缓存的警告和步骤被添加到 converter 中，不记录各阶段的耗时
+//   - 没有命中时使用 converter 转换，成功的结果被缓存，超出 size 时淘汰最久没有使用的结果
+//   - 转换失败的结果不会被缓存
+//
+// 注意：键不包含其它参数（--dedupe-schemas 等），一个 CachingConverter 只能用于目标版本和输出格式以外的参数都相同的转换
+//
+// 返回：转换后的文档，以及结果是否来自缓存
+func (cache *CachingConverter) convert(converter *Converter, data []byte) ([]byte, bool, error) {
+	key := conversionCacheKey(data, converter.arguments.outputTarget, converter.arguments.postman, converter.arguments.outputFormat)
+
+	if entry, ok := cache.lookup(key); ok {
+		converter.warnings = append(converter.warnings, entry.warnings...)
+		converter.steps = append(converter.steps, entry.steps...)
+
+		return slices.Clone(entry.output), true, nil
+	}
+
+	warningCount, stepCount := len(converter.warnings), len(converter.steps)
+	output, err := converter.convertDocument(data)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if output, err = convertToFormat(output, converter.arguments.outputFormat); err != nil {
+		return nil, false, err
+	}
+
+	cache.add(&cachedConversion{
+		key:      key,
+		output:   slices.Clone(output),
+		warnings: slices.Clone(converter.warnings[warningCount:]),
+		steps:    slices.Clone(converter.steps[stepCount:]),
+	})
+
+	return output, false, nil
+}
+
+// lookup 返回 key 对应的缓存结果，并将其标记为最近使用，同时记录是否命中缓存。
+func (cache *CachingConverter) lookup(key string) (*cachedConversion, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, ok := cache.index[key]
+
+	if !ok {
+		cache.misses++
+
+		return nil, false
+	}
+
+	cache.hits++
+	cache.entries.MoveToFront(element)
+
+	return element.Value.(*cachedConversion), true
+}
+
+// add 缓存一个转换结果，并淘汰超出 size 的最久没有使用的结果。
+func (cache *CachingConverter) add(entry *cachedConversion) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.size < 1 {
+		return
+	}
+
+	// Another goroutine may have converted the same document in the meantime.
+	if element, ok := cache.index[entry.key]; ok {
+		cache.entries.MoveToFront(element)
+
+		return
+	}
+
+	cache.index[entry.key] = cache.entries.PushFront(entry)
+
+	for cache.entries.Len() > cache.size {
+		oldest := cache.entries.Back()
+		cache.entries.Remove(oldest)
+		delete(cache.index, oldest.Value.(*cachedConversion).key)
+	}
+}
+
+// Stats 返回命中和没有命中缓存的次数。
+func (cache *CachingConverter) Stats() (hits int, misses int) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.hits, cache.misses
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCachingConverterHitsOnSecondRequest(t *testing.T) {
+	cache := NewCachingConverter(2)
+	first, err := cache.Convert([]byte(optionsTestDocument), WithTarget(Swagger))
+
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if hits, misses := cache.Stats(); hits != 0 || misses != 1 {
+		t.Errorf("Stats() after the first request = %d, %d, want 0, 1", hits, misses)
+	}
+
+	var warnings []Warning
+	second, err := cache.Convert([]byte(optionsTestDocument), WithTarget(Swagger), WithWarningsSink(func(warning Warning) {
+		warnings = append(warnings, warning)
+	}))
+
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if hits, misses := cache.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("Stats() after the second request = %d, %d, want 1, 1", hits, misses)
+	}
+
+	if !bytes.Equal(first, second) {
+		t.Errorf("Convert() cached output differs from the first output")
+	}
+
+	if len(warnings) == 0 {
+		t.Errorf("Convert() from the cache passed no warnings to the sink")
+	}
+}
+
+func TestCachingConverterKeysOnTargetAndFormat(t *testing.T) {
+	cache := NewCachingConverter(4)
+
+	for _, options := range [][]Option{
+		{WithTarget(OpenAPI31)},
+		{WithTarget(OpenAPI30)},
+		{WithTarget(OpenAPI30), WithFormat(YAML)},
+		{WithPostman()},
+	} {
+		if _, err := cache.Convert([]byte(optionsTestDocument), options...); err != nil {
+			t.Fatalf("Convert() error = %v", err)
+		}
+	}
+
+	if hits, misses := cache.Stats(); hits != 0 || misses != 4 {
+		t.Errorf("Stats() = %d, %d, want 0, 4", hits, misses)
+	}
+}
+
+func TestCachingConverterEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewCachingConverter(1)
+
+	for _, target := range []SpecVersion{OpenAPI31, OpenAPI30, OpenAPI31} {
+		if _, err := cache.Convert([]byte(optionsTestDocument), WithTarget(target)); err != nil {
+			t.Fatalf("Convert() error = %v", err)
+		}
+	}
+
+	if hits, misses := cache.Stats(); hits != 0 || misses != 3 {
+		t.Errorf("Stats() = %d, %d, want 0, 3", hits, misses)
+	}
+}
+
+func TestCachingConverterDoesNotCacheErrors(t *testing.T) {
+	cache := NewCachingConverter(2)
+
+	for range 2 {
+		if _, err := cache.Convert([]byte("openapi: [")); err == nil {
+			t.Fatalf("Convert() error = nil, want an error for invalid YAML")
+		}
+	}
+
+	if hits, misses := cache.Stats(); hits != 0 || misses != 2 {
+		t.Errorf("Stats() = %d, %d, want 0, 2", hits, misses)
+	}
+}
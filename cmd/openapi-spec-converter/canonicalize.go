@@ -0,0 +1,150 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// emptySectionKeys 是根对象中为空时与不存在含义相同的字段
+var emptySectionKeys = []string{
+	"servers",
+	"components",
+	"tags",
+	"definitions",
+	"parameters",
+	"responses",
+	"securityDefinitions",
+}
+
+// sortMappingKeys 递归地按字母顺序排列映射节点的字段，列表中元素的顺序保持不变。
+func sortMappingKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+		}
+
+		slices.SortStableFunc(pairs, func(a, b [2]*yaml.Node) int {
+			return cmp.Compare(a[0].Value, b[0].Value)
+		})
+
+		node.Content = node.Content[:0]
+
+		for _, pair := range pairs {
+			node.Content = append(node.Content, pair[0], pair[1])
+		}
+	}
+
+	for _, child := range node.Content {
+		sortMappingKeys(child)
+	}
+}
+
+// clearNodeStyle 递归地清除节点的所有风格，包括字面量（|）和折叠（>）风格，由渲染器选择表示方式。
+func clearNodeStyle(node *yaml.Node) {
+	node.Style = 0
+
+	for _, child := range node.Content {
+		clearNodeStyle(child)
+	}
+}
+
+// isEmptyNode 判断节点是否为空的映射或列表。
+func isEmptyNode(node *yaml.Node) bool {
+	return (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && len(node.Content) == 0
+}
+
+// removeEmptySections 删除根对象和 components 中为空的字段，例如 {tags: [], components: {schemas: {}}} -> {}（--clean、--canonicalize）。
+// 注意：paths 和 security 不会被删除，paths 是必需的字段，空的 security 表示不需要认证
+func removeEmptySections(root *yaml.Node) {
+	if components := mappingValue(root, "components"); components != nil && components.Kind == yaml.MappingNode {
+		for i := len(components.Content) - 2; i >= 0; i -= 2 {
+			if isEmptyNode(components.Content[i+1]) {
+				components.Content = slices.Delete(components.Content, i, i+2)
+			}
+		}
+	}
+
+	for _, key := range emptySectionKeys {
+		if value := mappingValue(root, key); value != nil && isEmptyNode(value) {
+			deleteMappingKey(root, key)
+		}
+	}
+}
+
+// sortRequiredProperties 删除每个 schema 的 required 中重复的属性名称，并按字母顺序排列。
+// 原因：required 是属性名称的集合，顺序和重复不影响含义
+func sortRequiredProperties(root *yaml.Node) {
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		required := mappingValue(schema, "required")
+
+		if required == nil || required.Kind != yaml.SequenceNode {
+			return true
+		}
+
+		slices.SortStableFunc(required.Content, func(a, b *yaml.Node) int {
+			return cmp.Compare(a.Value, b.Value)
+		})
+
+		required.Content = slices.CompactFunc(required.Content, func(a, b *yaml.Node) bool {
+			return a.Kind == yaml.ScalarNode && b.Kind == yaml.ScalarNode && a.Value == b.Value
+		})
+
+		return true
+	})
+}
+
+// canonicalizeDocument 在不改变版本的情况下将文档转换为规范形式（--canonicalize）。
+// 操作：
+//  1. 删除每个 schema 的 required 中重复的属性名称，并按字母顺序排列（见 sortRequiredProperties）
+//  2. 删除根对象和 components 中为空的字段（见 removeEmptySections）
+//  3. 按字母顺序排列所有映射的字段，包括 paths 中的路径，然后按规范的书写顺序排列根对象的字段
+//  4. 清除所有节点的风格（引号、流风格、字面量等），以两个空格缩进的块风格 YAML 重新渲染文档
+//
+// 原因：含义相同但字段顺序或书写风格不同的文档得到逐字节相同的结果，便于在代码审查中比较文档的差异
+//
+// 注意：
+//   - 列表中元素的顺序（例如 parameters、enum、allOf）可能有含义，保持不变
+//   - 对规范形式再次执行 --canonicalize 得到相同的结果
+//   - 与 --normalize 一样，YAML 输入中的注释会被保留
+func (converter *Converter) canonicalizeDocument(data []byte) ([]byte, error) {
+	const conversion = "canonicalize"
+
+	converter.steps = append(converter.steps, conversion)
+	stageStart := time.Now()
+	root, err := parseDocumentNode(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	stageStart = converter.recordStage(conversion, "load", stageStart)
+
+	// A comment at the top of the file belongs to the first key, keep it at the top after reordering.
+	if len(root.Content) > 0 {
+		root.HeadComment = joinComments(root.HeadComment, root.Content[0].HeadComment)
+		root.Content[0].HeadComment = ""
+	}
+
+	sortRequiredProperties(root)
+	removeEmptySections(root)
+	sortMappingKeys(root)
+
+	if isSwaggerDocumentNode(root) {
+		orderSwaggerRootKeys(root)
+	} else {
+		orderRootKeys(root, openAPIRootKeyOrder)
+	}
+
+	clearNodeStyle(root)
+	stageStart = converter.recordStage(conversion, "sort keys", stageStart)
+	data, err = renderDocumentNode(root)
+	converter.recordStage(conversion, "render", stageStart)
+
+	return data, err
+}
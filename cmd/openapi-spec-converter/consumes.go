@@ -0,0 +1,171 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// selectSingleMediaType 从 mediaTypes 中选择一个媒体类型。
+// 选择顺序：
+//  1. preferred
+//  2. "application/json"
+//  3. mediaTypes 中的第一个媒体类型
+//
+// 匹配时忽略媒体类型参数，返回 mediaTypes 中实际的值
+func selectSingleMediaType(mediaTypes []string, preferred string) string {
+	for _, wanted := range []string{preferred, "application/json"} {
+		for _, mediaType := range mediaTypes {
+			if mediaTypeEssence(mediaType) == mediaTypeEssence(wanted) {
+				return mediaType
+			}
+		}
+	}
+
+	return mediaTypes[0]
+}
+
+// singleSwaggerMediaTypes 将 Swagger 2.0 的 consumes 和 produces 减少为一个媒体类型，并为丢弃的媒体类型记录警告（--single-consumes）。
+// 映射关系：
+//   - {consumes: ["application/json", "application/xml", "text/plain"]} -> {consumes: ["application/json"]}
+//   - {produces: ["application/xml", "text/plain"]}（--response-media application/xml）-> {produces: ["application/xml"]}
+//
+// 操作：consumes 优先选择 "application/json"，produces 优先选择 --response-media 指定的媒体类型，
+// 文档级别和每个操作的 consumes/produces 都会被处理
+//
+// 原因：一些 Swagger 工具不支持多个 consumes 或 produces
+func (converter *Converter) singleSwaggerMediaTypes(kinSwaggerDoc *openapi2.T) {
+	selectSingle := func(mediaTypes *[]string, preferred string, pointer string) {
+		if len(*mediaTypes) < 2 {
+			return
+		}
+
+		selected := selectSingleMediaType(*mediaTypes, preferred)
+		dropped := slices.DeleteFunc(slices.Clone(*mediaTypes), func(mediaType string) bool {
+			return mediaType == selected
+		})
+
+		converter.warn(pointer, "dropped media types %s, using only %s", strings.Join(dropped, ", "), selected)
+		*mediaTypes = []string{selected}
+	}
+
+	selectSingle(&kinSwaggerDoc.Consumes, "application/json", jsonPointer("consumes"))
+	selectSingle(&kinSwaggerDoc.Produces, converter.arguments.responseMedia, jsonPointer("produces"))
+
+	for _, path := range slices.Sorted(maps.Keys(kinSwaggerDoc.Paths)) {
+		if kinSwaggerDoc.Paths[path] == nil {
+			continue
+		}
+
+		operations := kinSwaggerDoc.Paths[path].Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			operation := operations[method]
+			pointer := jsonPointer("paths", path, strings.ToLower(method))
+
+			selectSingle(&operation.Consumes, "application/json", pointer+"/consumes")
+			selectSingle(&operation.Produces, converter.arguments.responseMedia, pointer+"/produces")
+		}
+	}
+}
+
+// setSwaggerDefaultProduces 为有响应但没有 produces 的 Swagger 2.0 操作设置 produces（--produces-default）。
+// 映射关系：
+//   - {responses: {"204": {...}}}（--produces-default application/json）-> {produces: ["application/json"], responses: {"204": {...}}}
+//
+// 原因：响应没有 content 的操作转换后没有 produces，一些 Swagger 代码生成工具要求每个操作都有 produces
+//
+// 注意：文档级别已经有 produces 时操作会继承它，因此不会被修改
+func setSwaggerDefaultProduces(kinSwaggerDoc *openapi2.T, mediaType string) {
+	if len(kinSwaggerDoc.Produces) > 0 {
+		return
+	}
+
+	for _, pathItem := range kinSwaggerDoc.Paths {
+		if pathItem == nil {
+			continue
+		}
+
+		for _, operation := range pathItem.Operations() {
+			if len(operation.Responses) > 0 && len(operation.Produces) == 0 {
+				operation.Produces = []string{mediaType}
+			}
+		}
+	}
+}
+
+// setSwaggerRequestBodySchemas 在 OpenAPI 3.0 到 Swagger 2.0 转换时，确定性地选择 body 参数的 schema。
+// 映射关系：
+//   - OpenAPI 3.0: {requestBody: {content: {"application/xml": {schema: A}, "application/json": {schema: B}}}}
+//     -> Swagger 2.0: {parameters: [{in: "body", schema: B}]}
+//
+// 操作：按 selectSingleMediaType 的顺序（优先 "application/json"）从非表单的媒体类型中选择一个，用它的 schema 替换 body 参数的 schema
+// 原因：kin-openapi 的 FromV3 遍历 content 这个 map，使用第一个遇到的媒体类型的 schema，每次转换的结果可能不同
+//
+// 注意：
+//   - 表单媒体类型（忽略媒体类型参数，例如 "multipart/form-data; boundary=x"）被转换为 formData 参数，不会被处理
+//   - 选择的 schema 是二进制数据（{type: "string", format: "binary"}）时，kin-openapi 会把它转换为 file 类型的 formData 参数，
+//     这里不使用这个参数，body 参数和 fixSwaggerOperationUploadFormat 一样使用 {type: "string", format: "binary"}
+//   - 无论是否使用 --single-consumes 都需要调用，否则 Swagger 的输出不确定
+func setSwaggerRequestBodySchemas(kinOpenAPIDoc *openapi3.T, kinSwaggerDoc *openapi2.T) {
+	if kinOpenAPIDoc.Paths == nil {
+		return
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(kinSwaggerDoc.Paths)) {
+		pathItem := kinOpenAPIDoc.Paths.Value(path)
+
+		if pathItem == nil || kinSwaggerDoc.Paths[path] == nil {
+			continue
+		}
+
+		operations := kinSwaggerDoc.Paths[path].Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			operation := pathItem.GetOperation(method)
+
+			if operation == nil || operation.RequestBody == nil || operation.RequestBody.Ref != "" || operation.RequestBody.Value == nil {
+				continue
+			}
+
+			content := operation.RequestBody.Value.Content
+			mediaTypes := slices.DeleteFunc(slices.Sorted(maps.Keys(content)), func(mediaType string) bool {
+				essence := mediaTypeEssence(mediaType)
+
+				return essence == "application/x-www-form-urlencoded" || essence == "multipart/form-data"
+			})
+
+			if len(mediaTypes) < 2 {
+				continue
+			}
+
+			selected := content[selectSingleMediaType(mediaTypes, "application/json")]
+
+			if selected == nil || selected.Schema == nil {
+				continue
+			}
+
+			// The second value is a file formData parameter for binary schemas, which have no body schema.
+			schema, fileParameter := openapi2conv.FromV3SchemaRef(selected.Schema, kinOpenAPIDoc.Components)
+
+			if fileParameter != nil {
+				schema = &openapi2.SchemaRef{
+					Value: &openapi2.Schema{
+						Type:   &openapi3.Types{"string"},
+						Format: "binary",
+					},
+				}
+			}
+
+			for _, parameter := range operations[method].Parameters {
+				if parameter != nil && parameter.In == "body" {
+					parameter.Schema = schema
+				}
+			}
+		}
+	}
+}
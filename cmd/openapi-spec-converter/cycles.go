@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaRefCycles 查找组件 schema（components.schemas 或 definitions）之间的 $ref 循环。
+// 映射关系：
+//   - {TreeNode: {properties: {children: {items: {$ref: "#/components/schemas/TreeNode"}}}}} -> [["TreeNode", "TreeNode"]]
+//   - {A: {properties: {b: {$ref: ".../B"}}}, B: {properties: {a: {$ref: ".../A"}}}} -> [["A", "B", "A"]]
+//
+// 返回：每个循环经过的组件名称，首尾是同一个组件；循环从名称最小的组件开始，每个循环只返回一次，按名称排序
+//
+// 注意：只检查组件之间的引用，inline schema 不能被引用，不会形成循环
+func schemaRefCycles(root *yaml.Node) [][]string {
+	components, refPrefix := schemaComponentsNode(root, false)
+
+	if components == nil || components.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var names []string
+	refs := make(map[string][]string)
+
+	for i := 0; i+1 < len(components.Content); i += 2 {
+		name := components.Content[i].Value
+		names = append(names, name)
+
+		walkSchemaNode(components.Content[i+1], func(schema *yaml.Node, component bool) bool {
+			ref := mappingValue(schema, "$ref")
+
+			if ref == nil || resolveLocalSchemaRef(root, ref.Value) == nil {
+				return true
+			}
+
+			target := strings.ReplaceAll(strings.ReplaceAll(strings.TrimPrefix(ref.Value, refPrefix), "~1", "/"), "~0", "~")
+
+			if !slices.Contains(refs[name], target) {
+				refs[name] = append(refs[name], target)
+			}
+
+			return true
+		}, true)
+	}
+
+	var cycles [][]string
+	seen := make(map[string]bool)
+	done := make(map[string]bool)
+	var stack []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		if index := slices.Index(stack, name); index >= 0 {
+			cycle := slices.Clone(stack[index:])
+			// Start each cycle at its smallest name, so it's only reported once.
+			first := slices.Index(cycle, slices.Min(cycle))
+			cycle = append(cycle[first:], cycle[:first]...)
+			cycle = append(cycle, cycle[0])
+
+			if key := strings.Join(cycle, "\n"); !seen[key] {
+				seen[key] = true
+				cycles = append(cycles, cycle)
+			}
+
+			return
+		}
+
+		if done[name] {
+			return
+		}
+
+		stack = append(stack, name)
+
+		for _, target := range refs[name] {
+			visit(target)
+		}
+
+		stack = stack[:len(stack)-1]
+		done[name] = true
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+
+	slices.SortFunc(cycles, func(a, b []string) int {
+		return slices.Compare(a, b)
+	})
+
+	return cycles
+}
+
+// checkSchemaRefCycles 检查转换后的文档中组件 schema 之间的 $ref 循环。
+// 操作：
+//   - 默认为每个循环记录警告，警告的位置是循环中的第一个组件
+//   - --fail-on-ref-cycle: 列出所有循环并返回错误
+//
+// 原因：递归的 schema 是有效的，但一些下游工具无法处理，内联 $ref 时也无法展开
+func (converter *Converter) checkSchemaRefCycles(data []byte) error {
+	root, err := parseDocumentNode(data)
+
+	if err != nil {
+		return fmt.Errorf("Error loading converted document: %w", err)
+	}
+
+	cycles := schemaRefCycles(root)
+	paths := make([]string, 0, len(cycles))
+
+	for _, cycle := range cycles {
+		paths = append(paths, strings.Join(cycle, " -> "))
+	}
+
+	if converter.arguments.failRefCycles && len(paths) > 0 {
+		return fmt.Errorf("Schema reference cycles: %s", strings.Join(paths, ", "))
+	}
+
+	swagger := isSwaggerDocumentNode(root)
+
+	for i, cycle := range cycles {
+		pointer := jsonPointer("components", "schemas", cycle[0])
+
+		if swagger {
+			pointer = jsonPointer("definitions", cycle[0])
+		}
+
+		converter.warn(pointer, "schema reference cycle %s", paths[i])
+	}
+
+	return nil
+}
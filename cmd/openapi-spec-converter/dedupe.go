@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// componentNameCharacters 是 OpenAPI 3.x 组件名称中允许使用的字符
+const componentNameCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.-_"
+
+// structuralSchemaKeywords 是判断一个 inline schema 是否值得被提升为组件的关键字。
+// 只有 {type: string} 这类简单 schema 的重复是正常的，提升它们只会让文档更难读。
+var structuralSchemaKeywords = []string{
+	"properties",
+	"allOf",
+	"oneOf",
+	"anyOf",
+	"items",
+	"additionalProperties",
+	"not",
+}
+
+// isDedupeCandidate 判断 schema 是否为可以被去重的 inline schema：不是 $ref，并且包含结构性关键字。
+func isDedupeCandidate(schema *yaml.Node) bool {
+	if mappingValue(schema, "$ref") != nil {
+		return false
+	}
+
+	for i := 0; i+1 < len(schema.Content); i += 2 {
+		if slices.Contains(structuralSchemaKeywords, schema.Content[i].Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generateComponentName 生成一个在 components 中尚未使用的 schema 名称，例如 "Generated1"。
+// prefix 不为空时名称带有该前缀，例如 prefix 为 "Billing" 时生成 "Billing_Generated1"。
+// nameCase 不为 KeepCase 时名称使用该风格，例如 SnakeCase 时生成 "billing_generated1"（见 applyNameCase）。
+func generateComponentName(components *yaml.Node, prefix string, nameCase NameCase, counter *int) string {
+	for {
+		*counter++
+		name := fmt.Sprintf("Generated%d", *counter)
+
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		name = applyNameCase(name, nameCase)
+
+		if mappingValue(components, name) == nil {
+			return name
+		}
+	}
+}
+
+// dedupeSchemas 查找结构相同的 inline schema，将它们提升到 components 中并替换为 $ref。
+// 映射关系：
+//   - 出现两次及以上的相同 inline schema -> components.schemas["GeneratedN"]（Swagger 为 definitions）+ {$ref}
+//     设置了 --components-prefix 时为 components.schemas["<prefix>_GeneratedN"]，设置了 --schema-name-case 时使用该风格
+//   - 与已有组件 schema 结构相同的 inline schema -> 指向该组件的 {$ref}
+//
+// 操作：
+//  1. 遍历所有 schema，按规范化 JSON 统计每个候选 inline schema 的出现次数
+//  2. 选择最大的（规范化 JSON 最长的）重复 schema，使外层 schema 先于其子 schema 被提升
+//  3. 将所有出现的位置替换为 $ref，然后重复以上步骤，直到没有重复的 schema
+//
+// 注意：
+//   - 只有包含 properties、allOf、oneOf、anyOf、items 等结构性关键字的 schema 才会被去重
+//   - 已有的组件 schema 不会被重命名或替换，新组件的名称也不会与已有的名称冲突，
+//     所以已有的 $ref 和 discriminator.mapping 中的引用仍然有效
+//   - 新 $ref 中的组件名称按 JSON Pointer 转义，例如 "pets.v1/Pet" -> "#/components/schemas/pets.v1~1Pet"
+func dedupeSchemas(root *yaml.Node, prefix string, nameCase NameCase) error {
+	nameCounter := 0
+
+	for {
+		components, refPrefix := schemaComponentsNode(root, false)
+
+		componentNames := map[string]string{}
+
+		if components != nil {
+			for i := 0; i+1 < len(components.Content); i += 2 {
+				key, err := canonicalNodeKey(components.Content[i+1])
+
+				if err != nil {
+					return err
+				}
+
+				if _, exists := componentNames[key]; !exists {
+					componentNames[key] = components.Content[i].Value
+				}
+			}
+		}
+
+		counts := map[string]int{}
+		var firstSchema *yaml.Node
+		var selectedKey string
+		var walkErr error
+
+		walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+			if component || !isDedupeCandidate(schema) || walkErr != nil {
+				return walkErr == nil
+			}
+
+			key, err := canonicalNodeKey(schema)
+
+			if err != nil {
+				walkErr = err
+				return false
+			}
+
+			counts[key]++
+			_, isComponent := componentNames[key]
+
+			if (counts[key] >= 2 || isComponent) && len(key) > len(selectedKey) {
+				selectedKey = key
+				firstSchema = schema
+			}
+
+			return true
+		})
+
+		if walkErr != nil {
+			return walkErr
+		}
+
+		if selectedKey == "" {
+			return nil
+		}
+
+		name, exists := componentNames[selectedKey]
+
+		if !exists {
+			components, refPrefix = schemaComponentsNode(root, true)
+			name = generateComponentName(components, prefix, nameCase, &nameCounter)
+			setMappingValue(components, name, copyNode(firstSchema))
+		}
+
+		ref := refPrefix + escapeJSONPointerToken(name)
+
+		walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+			if component || !isDedupeCandidate(schema) {
+				return true
+			}
+
+			if key, err := canonicalNodeKey(schema); err == nil && key == selectedKey {
+				*schema = *newRefNode(ref)
+
+				return false
+			}
+
+			return true
+		})
+	}
+}
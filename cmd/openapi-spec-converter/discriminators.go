@@ -0,0 +1,128 @@
+package main
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// componentRefName 返回 ref 指向的组件名称，ref 不是直接指向 prefix 下的组件时返回 false，
+// 例如 "#/components/schemas/pets.v1~1Pet" -> "pets.v1/Pet"。
+func componentRefName(ref string, prefix string) (string, bool) {
+	token, found := strings.CutPrefix(ref, prefix)
+
+	if !found || token == "" || strings.Contains(token, "/") {
+		return "", false
+	}
+
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~"), true
+}
+
+// materializeDiscriminatorMappings 为 OpenAPI 3.x 中没有 mapping 的 discriminator 添加显式的 mapping。
+// 映射关系：
+//   - {oneOf: [{$ref: "#/components/schemas/Cat"}], discriminator: {propertyName: "petType"}}
+//     -> {oneOf: [...], discriminator: {propertyName: "petType", mapping: {Cat: "#/components/schemas/Cat"}}}
+//   - components.schemas.Pet: {discriminator: {propertyName: "petType"}}，components.schemas.Cat: {allOf: [{$ref: "#/components/schemas/Pet"}, ...]}
+//     -> components.schemas.Pet: {discriminator: {propertyName: "petType", mapping: {Cat: "#/components/schemas/Cat"}}}
+//
+// 操作：
+//   - 有 oneOf 或 anyOf 时，使用其中每个指向组件的 $ref，键为组件名称
+//   - 否则如果 discriminator 在组件中，使用在 allOf 中引用该组件的其他组件
+//
+// 原因：没有 mapping 时 discriminator 的值按组件名称隐式匹配，--dedupe-schemas 提升的组件（例如 allOf 引用父 schema 的 "Generated1"）
+// 会改变隐式匹配的结果，所以在去重之前将隐式匹配固定为显式的 mapping
+//
+// 注意：
+//   - Swagger 2.0 的 discriminator 只是属性名称，没有 mapping，不会被处理
+//   - 已有的 mapping 不会被修改，不指向文档内组件的 $ref（例如远程 $ref）会被跳过
+//
+// 返回：添加了 mapping 的 discriminator 数量
+func materializeDiscriminatorMappings(root *yaml.Node) int {
+	if isSwaggerDocumentNode(root) {
+		return 0
+	}
+
+	components, refPrefix := schemaComponentsNode(root, false)
+	count := 0
+
+	// The schemas which reference each component in allOf, e.g. Pet -> [Cat, Dog].
+	subSchemas := map[string][]string{}
+
+	if components != nil {
+		for i := 0; i+1 < len(components.Content); i += 2 {
+			allOf := mappingValue(components.Content[i+1], "allOf")
+
+			if allOf == nil || allOf.Kind != yaml.SequenceNode {
+				continue
+			}
+
+			for _, member := range allOf.Content {
+				if ref := mappingValue(member, "$ref"); ref != nil {
+					if parent, ok := componentRefName(ref.Value, refPrefix); ok {
+						subSchemas[parent] = append(subSchemas[parent], components.Content[i].Value)
+					}
+				}
+			}
+		}
+	}
+
+	materialize := func(schema *yaml.Node, names []string) {
+		discriminator := mappingValue(schema, "discriminator")
+
+		if discriminator == nil || discriminator.Kind != yaml.MappingNode || mappingValue(discriminator, "mapping") != nil {
+			return
+		}
+
+		if len(names) == 0 {
+			for _, keyword := range []string{"oneOf", "anyOf"} {
+				members := mappingValue(schema, keyword)
+
+				if members == nil || members.Kind != yaml.SequenceNode {
+					continue
+				}
+
+				for _, member := range members.Content {
+					if ref := mappingValue(member, "$ref"); ref != nil {
+						if name, ok := componentRefName(ref.Value, refPrefix); ok {
+							names = append(names, name)
+						}
+					}
+				}
+			}
+		}
+
+		if len(names) == 0 {
+			return
+		}
+
+		mapping := newMappingNode()
+
+		for _, name := range names {
+			if mappingValue(mapping, name) == nil {
+				setMappingValue(mapping, name, newStringNode(refPrefix+escapeJSONPointerToken(name)))
+			}
+		}
+
+		setMappingValue(discriminator, "mapping", mapping)
+		count++
+	}
+
+	if components != nil {
+		for i := 0; i+1 < len(components.Content); i += 2 {
+			schema := components.Content[i+1]
+
+			// oneOf and anyOf list the choices explicitly, and take priority.
+			if mappingValue(schema, "oneOf") == nil && mappingValue(schema, "anyOf") == nil {
+				materialize(schema, subSchemas[components.Content[i].Value])
+			}
+		}
+	}
+
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		materialize(schema, nil)
+
+		return true
+	})
+
+	return count
+}
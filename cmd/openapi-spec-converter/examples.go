@@ -0,0 +1,117 @@
+package main
+
+import (
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exampleKeys 是值为示例数据的键
+var exampleKeys = []string{
+	"example",
+	"examples",
+	"x-example",
+}
+
+// walkExampleScalars 对 node 下所有示例数据（exampleKeys 的值）中的标量节点调用 visit。
+// 注意：示例数据中嵌套的 example 键也属于示例数据，不会被重复访问
+func walkExampleScalars(node *yaml.Node, inExample bool, visit func(scalar *yaml.Node)) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if inExample {
+			visit(node)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkExampleScalars(node.Content[i+1], inExample || slices.Contains(exampleKeys, node.Content[i].Value), visit)
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			walkExampleScalars(child, inExample, visit)
+		}
+	}
+}
+
+// hasPreservedExampleStyle 判断示例中的标量节点是否有 JSON 中无法表示的 YAML 书写风格。
+//   - 字面量（|）和折叠（>）风格的多行字符串
+//   - 未加引号的日期和时间（!!timestamp），例如 2024-01-02
+func hasPreservedExampleStyle(scalar *yaml.Node) bool {
+	return scalar.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || scalar.Tag == "!!timestamp"
+}
+
+// preserveExampleStyles 将 YAML 输入中示例数据的书写风格恢复到转换后的 YAML 输出中（--preserve-examples-format）。
+// 映射关系：
+//   - 折叠风格（>）的字符串：输出中被渲染为 "|" 或带引号的字符串 -> 恢复为 ">"
+//   - 未加引号的日期：输出中被渲染为带引号的字符串 "2024-01-02" -> 恢复为 2024-01-02
+//
+// 原因：Swagger 相关的转换经过 kin-openapi 的 JSON 模型，示例的值不变，但 YAML 书写风格会丢失；
+// libopenapi 的转换保留 YAML 节点，通常不受影响
+//
+// 注意：输入和输出的节点按示例中字符串的值对应，只有值与输入中的某个示例完全相同的标量会被修改
+func preserveExampleStyles(input []byte, output []byte) ([]byte, error) {
+	inputRoot, err := parseDocumentNode(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	styles := map[string]*yaml.Node{}
+
+	walkExampleScalars(inputRoot, false, func(scalar *yaml.Node) {
+		if _, ok := styles[scalar.Value]; !ok && hasPreservedExampleStyle(scalar) {
+			styles[scalar.Value] = scalar
+		}
+	})
+
+	if len(styles) == 0 {
+		return output, nil
+	}
+
+	outputRoot, err := parseDocumentNode(output)
+
+	if err != nil {
+		return nil, err
+	}
+
+	walkExampleScalars(outputRoot, false, func(scalar *yaml.Node) {
+		// Only strings are restored, a number equal to a string example isn't the same value.
+		if source, ok := styles[scalar.Value]; ok && (scalar.Tag == "!!str" || scalar.Tag == source.Tag) {
+			scalar.Style = source.Style & (yaml.LiteralStyle | yaml.FoldedStyle)
+			scalar.Tag = source.Tag
+		}
+	})
+
+	return renderDocumentNode(outputRoot)
+}
+
+// add31ExamplesFromDefaults 将 schema 的 default 复制到没有示例的 schema 的 examples 中（--examples-from-default）。
+// 映射关系：
+//   - {type: integer, default: 20} -> {type: integer, default: 20, examples: [20]}
+//   - {type: string, default: "cat", examples: []} -> {type: string, default: "cat", examples: ["cat"]}
+//   - {type: [string, "null"], default: null} -> 不修改
+//
+// 原因：文档工具通常只显示 examples，default 就是最有代表性的值
+//
+// 注意：已经有 examples 或 example 的 schema 不会被修改
+func add31ExamplesFromDefaults(root *yaml.Node) {
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		value := mappingValue(schema, "default")
+
+		if value == nil || value.Tag == "!!null" || mappingValue(schema, "example") != nil {
+			return true
+		}
+
+		examples := mappingValue(schema, "examples")
+
+		if examples == nil {
+			examples = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			setMappingValue(schema, "examples", examples)
+		} else if examples.Kind != yaml.SequenceNode || len(examples.Content) > 0 {
+			return true
+		}
+
+		examples.Content = []*yaml.Node{copyNode(value)}
+
+		return true
+	})
+}
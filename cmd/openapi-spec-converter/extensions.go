@@ -0,0 +1,114 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// extensionNameMapKeys 是值为 {名称: 对象} 映射的键，映射中的键是名称（例如属性名或 header 名）而不是扩展
+var extensionNameMapKeys = []string{
+	"properties",
+	"patternProperties",
+	"dependentSchemas",
+	"$defs",
+	"headers",
+	"encoding",
+	"variables",
+	"scopes",
+	"mapping",
+}
+
+// swaggerNameMapKeys 是 Swagger 2.0 根对象中值为 {名称: 对象} 映射的键
+var swaggerNameMapKeys = []string{
+	"definitions",
+	"parameters",
+	"responses",
+	"securityDefinitions",
+}
+
+// stripExtensions 删除文档中名称以任意 prefixes 开头的扩展（x- 字段），例如 prefixes 为 ["x-internal-"] 时：
+//   - {x-internal-owner: "team", x-public-name: "pets"} -> {x-public-name: "pets"}
+//
+// 注意：
+//   - 属性名、header 名、组件名等名称不是扩展，即使以相同的前缀开头也不会被删除
+//   - example、default、enum 等任意数据以及扩展本身的值不会被遍历
+func stripExtensions(root *yaml.Node, prefixes []string) {
+	swagger := isSwaggerDocumentNode(root)
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+
+		switch {
+		case swagger && slices.Contains(swaggerNameMapKeys, key):
+			stripNameMapExtensions(value, prefixes)
+		case !swagger && key == "components" && value.Kind == yaml.MappingNode:
+			stripObjectExtensions(value, prefixes)
+
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				if !strings.HasPrefix(value.Content[j].Value, "x-") {
+					stripNameMapExtensions(value.Content[j+1], prefixes)
+				}
+			}
+		default:
+			stripNodeExtensions(value, prefixes)
+		}
+	}
+
+	stripObjectExtensions(root, prefixes)
+}
+
+// stripNameMapExtensions 遍历 {名称: 对象} 映射中的每个对象，映射本身的键不会被删除。
+func stripNameMapExtensions(node *yaml.Node, prefixes []string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		stripNodeExtensions(node.Content[i+1], prefixes)
+	}
+}
+
+// stripNodeExtensions 删除节点及其子节点中匹配的扩展。
+func stripNodeExtensions(node *yaml.Node, prefixes []string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		stripObjectExtensions(node, prefixes)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+
+			switch {
+			case strings.HasPrefix(key, "x-"):
+			case slices.Contains(skippedDocumentKeys, key):
+			case slices.Contains(extensionNameMapKeys, key):
+				stripNameMapExtensions(value, prefixes)
+			default:
+				stripNodeExtensions(value, prefixes)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			stripNodeExtensions(child, prefixes)
+		}
+	}
+}
+
+// stripObjectExtensions 只删除映射节点自身中匹配的扩展。
+func stripObjectExtensions(node *yaml.Node, prefixes []string) {
+	content := node.Content[:0]
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		matches := slices.ContainsFunc(prefixes, func(prefix string) bool {
+			return strings.HasPrefix(key, prefix)
+		})
+
+		if !strings.HasPrefix(key, "x-") || !matches {
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	node.Content = content
+}
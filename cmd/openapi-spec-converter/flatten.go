@@ -0,0 +1,173 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolveLocalSchemaRef 返回 $ref 指向的组件 schema 节点（definitions 或 components.schemas 中），无法解析时返回 nil。
+func resolveLocalSchemaRef(root *yaml.Node, ref string) *yaml.Node {
+	components, refPrefix := schemaComponentsNode(root, false)
+	name, ok := strings.CutPrefix(ref, refPrefix)
+
+	if !ok || strings.Contains(name, "/") {
+		return nil
+	}
+
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+
+	return mappingValue(components, name)
+}
+
+// mergeAllOfMember 将 allOf 的一个成员合并到 merged 中。
+// 合并规则：
+//   - properties: 合并属性，同名属性必须结构相同
+//   - required: 取并集，保留第一次出现的顺序
+//   - 其他关键字: 不存在时添加，已存在时必须结构相同
+//
+// 返回：发生冲突的关键字（例如 "type" 或 "properties.name"），没有冲突时返回空字符串
+func mergeAllOfMember(merged *yaml.Node, member *yaml.Node) (string, error) {
+	for i := 0; i+1 < len(member.Content); i += 2 {
+		key, value := member.Content[i].Value, member.Content[i+1]
+		existing := mappingValue(merged, key)
+
+		switch {
+		case existing == nil:
+			setMappingValue(merged, key, copyNode(value))
+		case key == "properties" && existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				name := value.Content[j].Value
+
+				if conflict, err := mergeAllOfValue(existing, name, value.Content[j+1]); conflict || err != nil {
+					return "properties." + name, err
+				}
+			}
+		case key == "required" && existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			for _, name := range value.Content {
+				if !slices.ContainsFunc(existing.Content, func(node *yaml.Node) bool { return node.Value == name.Value }) {
+					existing.Content = append(existing.Content, copyNode(name))
+				}
+			}
+		default:
+			if conflict, err := mergeAllOfValue(merged, key, value); conflict || err != nil {
+				return key, err
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// mergeAllOfValue 将 key 对应的 value 添加到映射节点 node 中，返回已有的值是否与 value 冲突。
+func mergeAllOfValue(node *yaml.Node, key string, value *yaml.Node) (bool, error) {
+	existing := mappingValue(node, key)
+
+	if existing == nil {
+		setMappingValue(node, key, copyNode(value))
+
+		return false, nil
+	}
+
+	existingKey, err := canonicalNodeKey(existing)
+
+	if err != nil {
+		return false, err
+	}
+
+	valueKey, err := canonicalNodeKey(value)
+
+	if err != nil {
+		return false, err
+	}
+
+	return existingKey != valueKey, nil
+}
+
+// flattenSchemaAllOf 尝试将 schema 的 allOf 合并为一个扁平的 schema。
+// 返回：无法安全合并的原因，合并成功或没有 allOf 时返回空字符串
+func flattenSchemaAllOf(root *yaml.Node, schema *yaml.Node) (string, error) {
+	allOf := mappingValue(schema, "allOf")
+
+	if allOf == nil || allOf.Kind != yaml.SequenceNode {
+		return "", nil
+	}
+
+	// Merge into a copy, so the schema is left untouched when merging fails.
+	merged := newMappingNode()
+
+	for i := 0; i+1 < len(schema.Content); i += 2 {
+		if schema.Content[i].Value != "allOf" {
+			merged.Content = append(merged.Content, schema.Content[i], copyNode(schema.Content[i+1]))
+		}
+	}
+
+	for _, member := range allOf.Content {
+		if ref := mappingValue(member, "$ref"); ref != nil {
+			if member = resolveLocalSchemaRef(root, ref.Value); member == nil {
+				return "cannot resolve " + ref.Value, nil
+			}
+
+			if mappingValue(member, "discriminator") != nil {
+				return ref.Value + " has a discriminator", nil
+			}
+		}
+
+		if member.Kind != yaml.MappingNode {
+			return "a member is not a schema object", nil
+		}
+
+		if mappingValue(member, "allOf") != nil || mappingValue(member, "$ref") != nil {
+			return "a member is itself a composition", nil
+		}
+
+		if conflict, err := mergeAllOfMember(merged, member); err != nil {
+			return "", err
+		} else if conflict != "" {
+			return "members have conflicting " + conflict, nil
+		}
+	}
+
+	schema.Content = merged.Content
+
+	return "", nil
+}
+
+// flattenSwaggerAllOf 将 Swagger 2.0 文档中单层的 allOf 组合合并为扁平的 schema，因为很多 Swagger 代码生成器无法处理 allOf。
+// 映射关系：
+//   - {allOf: [{$ref: "#/definitions/Base"}, {properties: {...}, required: [...]}]}
+//     -> {type: "object", properties: {Base 的属性..., ...}, required: [Base 的 required..., ...]}
+//
+// 操作：
+//   - 合并 schema 本身和 allOf 中每个成员（inline schema 或引用 definitions 的 $ref）的关键字
+//   - properties 和 required 被合并，其他关键字必须相同
+//
+// 注意：成员之间存在冲突（例如不同的 type）、成员本身包含 allOf、或引用的 schema 带有 discriminator 时，
+// 保留原来的 allOf 并记录警告
+func (converter *Converter) flattenSwaggerAllOf(root *yaml.Node) error {
+	pointers := nodeJSONPointers(root)
+	var walkErr error
+
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		if walkErr != nil {
+			return false
+		}
+
+		allOf := mappingValue(schema, "allOf")
+		reason, err := flattenSchemaAllOf(root, schema)
+
+		if err != nil {
+			walkErr = err
+			return false
+		}
+
+		if reason != "" {
+			converter.warn(pointers[allOf], "allOf was not flattened, %s", reason)
+		}
+
+		return true
+	})
+
+	return walkErr
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseFormatMappings 解析 --map-format 的值，例如 ["int64=long", "date-time=datetime"] -> {"int64": "long", "date-time": "datetime"}。
+// 返回：旧 format 到新 format 的映射，值不是 old=new 形式或同一个旧值出现多次时返回错误
+func parseFormatMappings(values []string) (map[string]string, error) {
+	mappings := map[string]string{}
+
+	for _, value := range values {
+		from, to, ok := strings.Cut(value, "=")
+
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("Invalid format mapping: %s, use old=new", value)
+		}
+
+		if _, exists := mappings[from]; exists {
+			return nil, fmt.Errorf("Duplicate format mapping for %s", from)
+		}
+
+		mappings[from] = to
+	}
+
+	return mappings, nil
+}
+
+// mapSchemaFormats 按 mappings 重写文档中所有 schema 的 format（--map-format）。
+// 映射关系：
+//   - --map-format int64=long: {type: integer, format: int64} -> {type: integer, format: long}
+//
+// 原因：不同的工具期望不同的 format 名称，例如 "long" 而不是 "int64"，"datetime" 而不是 "date-time"
+//
+// 注意：
+//   - 每个 format 只被重写一次，--map-format a=b --map-format b=c 不会将 a 重写为 c
+//   - Swagger 2.0 中非 body 参数、header 和它们的 items 不是 schema，但同样有 format，它们也会被重写
+func mapSchemaFormats(root *yaml.Node, mappings map[string]string) {
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		mapFormat(mappingValue(schema, "format"), mappings)
+
+		return true
+	})
+
+	if isSwaggerDocumentNode(root) {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value != "definitions" {
+				mapSwaggerParameterFormats(root.Content[i+1], mappings)
+			}
+		}
+	}
+}
+
+// mapSwaggerParameterFormats 重写 Swagger 2.0 文档中 schema 以外的 format，即非 body 参数、header 和它们的 items 上的 format。
+// 注意：schema 已经被 walkDocumentSchemaNodes 处理，不会被遍历
+func mapSwaggerParameterFormats(node *yaml.Node, mappings map[string]string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+
+			switch {
+			case strings.HasPrefix(key, "x-"):
+			case slices.Contains(skippedDocumentKeys, key):
+			case key == "schema":
+			case key == "format" && value.Kind == yaml.ScalarNode:
+				mapFormat(value, mappings)
+			default:
+				mapSwaggerParameterFormats(value, mappings)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			mapSwaggerParameterFormats(child, mappings)
+		}
+	}
+}
+
+// mapFormat 按 mappings 重写一个 format 值节点，format 为 nil 或没有对应的映射时不做任何修改。
+func mapFormat(format *yaml.Node, mappings map[string]string) {
+	if format != nil && format.Kind == yaml.ScalarNode {
+		if to, ok := mappings[format.Value]; ok {
+			format.Value = to
+		}
+	}
+}
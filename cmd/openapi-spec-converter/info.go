@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultInfoTitle   = "Converted API" // --ensure-info 添加的 info.title
+	defaultInfoVersion = "0.0.0"         // --ensure-info 添加的 info.version
+)
+
+// ensureDocumentInfo 为没有 info 的文档添加最小的 info，并为 info 补充缺少的 title 和 version（--ensure-info），每次修改记录一条警告。
+// 映射关系：
+//   - {openapi: "3.0.3", paths: {...}} -> {openapi: "3.0.3", info: {title: "Converted API", version: "0.0.0"}, paths: {...}}
+//   - {info: {title: "Pets"}} -> {info: {title: "Pets", version: "0.0.0"}}
+//
+// 原因：Swagger 2.0 和 OpenAPI 3.x 都要求 info 有 title 和 version，没有它们的文档转换后无法通过验证
+//
+// 注意：新的 info 被添加到 swagger 或 openapi 字段之后，文档没有版本字段时添加到开头
+func (converter *Converter) ensureDocumentInfo(root *yaml.Node) {
+	info := mappingValue(root, "info")
+
+	if info == nil || info.Kind != yaml.MappingNode {
+		info = newMappingNode()
+		setMappingValue(info, "title", newStringNode(defaultInfoTitle))
+		setMappingValue(info, "version", newStringNode(defaultInfoVersion))
+
+		if !deleteMappingKey(root, "info") {
+			converter.warn("#", "the document has no info, added info with the title %q and version %q", defaultInfoTitle, defaultInfoVersion)
+		} else {
+			converter.warn(jsonPointer("info"), "info is not an object, replaced it with the title %q and version %q", defaultInfoTitle, defaultInfoVersion)
+		}
+
+		position := 0
+
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if key := root.Content[i].Value; key == "swagger" || key == "openapi" {
+				position = i + 2
+			}
+		}
+
+		keyNode := newStringNode("info")
+
+		// A comment at the top of the file belongs to the first key, keep it at the top.
+		if position == 0 && len(root.Content) > 0 {
+			keyNode.HeadComment = root.Content[0].HeadComment
+			root.Content[0].HeadComment = ""
+		}
+
+		root.Content = slices.Insert(root.Content, position, keyNode, info)
+
+		return
+	}
+
+	for _, field := range [][2]string{{"title", defaultInfoTitle}, {"version", defaultInfoVersion}} {
+		if key, value := field[0], field[1]; mappingValue(info, key) == nil {
+			setMappingValue(info, key, newStringNode(value))
+			converter.warn(jsonPointer("info", key), "info has no %s, using %q", key, value)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+)
+
+// TestReadInputFileReadsPipes reads the /dev/fd path of a pipe, as the shell passes for <(...).
+func TestReadInputFileReadsPipes(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("/dev/fd is not available")
+	}
+
+	reader, writer, err := os.Pipe()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer reader.Close()
+
+	// Write in a goroutine, so the test fails rather than blocks if the
+	// input is larger than the pipe buffer and isn't read to the end.
+	go func() {
+		defer writer.Close()
+
+		for range 1000 {
+			fmt.Fprintln(writer, "# padding past the pipe buffer size")
+		}
+
+		writer.WriteString(optionsTestDocument)
+	}()
+
+	data, err := readInputFile(Arguments{inputFilename: fmt.Sprintf("/dev/fd/%d", reader.Fd())})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := 1000*len("# padding past the pipe buffer size\n") + len(optionsTestDocument)
+
+	if len(data) != want {
+		t.Fatalf("read %d bytes, want %d", len(data), want)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// integerFormatBounds 是 int32 和 int64 format 可以表示的最小值和最大值
+var integerFormatBounds = map[string][2]int64{
+	"int32": {math.MinInt32, math.MaxInt32},
+	"int64": {math.MinInt64, math.MaxInt64},
+}
+
+// isIntegerTypeNode 判断 schema 的 type 是否为 "integer"，或者是包含 "integer" 的 type 数组。
+func isIntegerTypeNode(schemaType *yaml.Node) bool {
+	if schemaType == nil {
+		return false
+	}
+
+	if schemaType.Kind == yaml.SequenceNode {
+		return slices.ContainsFunc(schemaType.Content, func(value *yaml.Node) bool { return value.Value == "integer" })
+	}
+
+	return schemaType.Value == "integer"
+}
+
+// add31IntegerFormatBounds 为 format 为 int32 或 int64 的 integer schema 添加 format 可以表示的 minimum 和 maximum（--upgrade-int-formats）。
+// 映射关系：
+//   - {type: integer, format: int32} -> {type: integer, format: int32, minimum: -2147483648, maximum: 2147483647}
+//   - {type: [integer, "null"], format: int64, minimum: 0} -> {..., minimum: 0, maximum: 9223372036854775807}
+//
+// 原因：OpenAPI 3.1 的 schema 是 JSON Schema，format 只是注解，验证器不会检查值的范围，显式的边界才会被检查
+//
+// 注意：
+//   - 已经有 minimum 或 exclusiveMinimum 的 schema 不会添加 minimum，maximum 同理
+//   - 边界直接写为整数节点，不经过 float64，所以 int64 的边界不会损失精度
+func add31IntegerFormatBounds(root *yaml.Node) {
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		format := mappingValue(schema, "format")
+
+		if format == nil || !isIntegerTypeNode(mappingValue(schema, "type")) {
+			return true
+		}
+
+		bounds, ok := integerFormatBounds[format.Value]
+
+		if !ok {
+			return true
+		}
+
+		if mappingValue(schema, "minimum") == nil && mappingValue(schema, "exclusiveMinimum") == nil {
+			setMappingValue(schema, "minimum", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(bounds[0], 10)})
+		}
+
+		if mappingValue(schema, "maximum") == nil && mappingValue(schema, "exclusiveMaximum") == nil {
+			setMappingValue(schema, "maximum", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(bounds[1], 10)})
+		}
+
+		return true
+	})
+}
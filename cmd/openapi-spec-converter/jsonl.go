@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// convertJSONLine 转换 --jsonl 输入中的一行文档，返回单行的 JSON 数据，以及结果是否来自 cache（与之前的行相同的文档）。
+func (converter *Converter) convertJSONLine(cache *CachingConverter, line []byte) ([]byte, bool, error) {
+	if err := checkInputFormat(line, converter.arguments); err != nil {
+		return nil, false, err
+	}
+
+	data, cached, err := cache.convert(converter, line)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var compact bytes.Buffer
+
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, false, err
+	}
+
+	return compact.Bytes(), cached, nil
+}
+
+// convertJSONLines 将 --jsonl 输入的每一行作为独立的文档转换，返回 NDJSON 格式的转换结果（每行一个 JSON 文档）。
+// 操作：
+//   - 空行被忽略
+//   - 每一行使用单独的 Converter 转换，警告记录所在的行号，耗时按顺序合并到 converter 中
+//   - 相同的行只转换一次（最多缓存 jsonlCacheSize 个结果），重复的行使用缓存的结果和警告，不记录耗时
+//   - 转换失败的行不会输出，错误被收集并返回，其余的行继续转换
+//   - 每一行转换完成后向 progress 写入一行进度，例如 "[2/5] line 3 converted"，使用缓存的结果时为 "[2/5] line 3 converted (cached)"
+//     （progress 为 nil 时不写入）
+//
+// 返回：NDJSON 数据（末尾没有换行）和每个失败行的错误
+func (converter *Converter) convertJSONLines(data []byte, progress io.Writer) ([]byte, []error) {
+	var output [][]byte
+	var errs []error
+	cache := NewCachingConverter(jsonlCacheSize)
+	lines := bytes.Split(data, []byte("\n"))
+	total, completed := 0, 0
+
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	for i := range lines {
+		lines[i] = bytes.TrimSpace(lines[i])
+
+		if len(lines[i]) > 0 {
+			total++
+		}
+	}
+
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+
+		completed++
+		lineConverter := Converter{arguments: converter.arguments}
+		converted, cached, err := lineConverter.convertJSONLine(cache, line)
+
+		for _, warning := range lineConverter.warnings {
+			warning.Line = i + 1
+			converter.warnings = append(converter.warnings, warning)
+		}
+
+		converter.timings = append(converter.timings, lineConverter.timings...)
+
+		if err != nil {
+			fmt.Fprintf(progress, "[%d/%d] line %d failed\n", completed, total, i+1)
+			errs = append(errs, fmt.Errorf("line %d: %w", i+1, err))
+			continue
+		}
+
+		if cached {
+			fmt.Fprintf(progress, "[%d/%d] line %d converted (cached)\n", completed, total, i+1)
+		} else {
+			fmt.Fprintf(progress, "[%d/%d] line %d converted\n", completed, total, i+1)
+		}
+
+		output = append(output, converted)
+	}
+
+	return bytes.Join(output, []byte("\n")), errs
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// swaggerSchemaKeywords 是 Swagger 2.0 Schema Object 支持的关键字
+var swaggerSchemaKeywords = []string{
+	"$ref",
+	"format",
+	"title",
+	"description",
+	"default",
+	"multipleOf",
+	"maximum",
+	"exclusiveMaximum",
+	"minimum",
+	"exclusiveMinimum",
+	"maxLength",
+	"minLength",
+	"pattern",
+	"maxItems",
+	"minItems",
+	"uniqueItems",
+	"maxProperties",
+	"minProperties",
+	"required",
+	"enum",
+	"type",
+	"items",
+	"allOf",
+	"properties",
+	"additionalProperties",
+	"discriminator",
+	"readOnly",
+	"xml",
+	"externalDocs",
+	"example",
+}
+
+// openAPI30SchemaKeywords 是 OpenAPI 3.0 Schema Object 支持的关键字
+var openAPI30SchemaKeywords = []string{
+	"$ref",
+	"title",
+	"multipleOf",
+	"maximum",
+	"exclusiveMaximum",
+	"minimum",
+	"exclusiveMinimum",
+	"maxLength",
+	"minLength",
+	"pattern",
+	"maxItems",
+	"minItems",
+	"uniqueItems",
+	"maxProperties",
+	"minProperties",
+	"required",
+	"enum",
+	"type",
+	"allOf",
+	"oneOf",
+	"anyOf",
+	"not",
+	"items",
+	"properties",
+	"additionalProperties",
+	"description",
+	"format",
+	"default",
+	"nullable",
+	"discriminator",
+	"readOnly",
+	"writeOnly",
+	"xml",
+	"externalDocs",
+	"example",
+	"deprecated",
+}
+
+// openAPI31SchemaKeywords 是 OpenAPI 3.1 Schema Object 支持的关键字（JSON Schema 2020-12 的关键字和 OpenAPI 的扩展关键字）
+var openAPI31SchemaKeywords = []string{
+	"$schema",
+	"$id",
+	"$ref",
+	"$anchor",
+	"$dynamicRef",
+	"$dynamicAnchor",
+	"$vocabulary",
+	"$comment",
+	"$defs",
+	"allOf",
+	"anyOf",
+	"oneOf",
+	"not",
+	"if",
+	"then",
+	"else",
+	"dependentSchemas",
+	"prefixItems",
+	"items",
+	"contains",
+	"properties",
+	"patternProperties",
+	"additionalProperties",
+	"propertyNames",
+	"unevaluatedItems",
+	"unevaluatedProperties",
+	"type",
+	"enum",
+	"const",
+	"multipleOf",
+	"maximum",
+	"exclusiveMaximum",
+	"minimum",
+	"exclusiveMinimum",
+	"maxLength",
+	"minLength",
+	"pattern",
+	"maxItems",
+	"minItems",
+	"uniqueItems",
+	"maxContains",
+	"minContains",
+	"maxProperties",
+	"minProperties",
+	"required",
+	"dependentRequired",
+	"format",
+	"contentEncoding",
+	"contentMediaType",
+	"contentSchema",
+	"title",
+	"description",
+	"default",
+	"deprecated",
+	"readOnly",
+	"writeOnly",
+	"examples",
+	"discriminator",
+	"xml",
+	"externalDocs",
+	"example",
+}
+
+// schemaKeywordsForVersion 返回指定版本的 Schema Object 支持的关键字。
+func schemaKeywordsForVersion(version SpecVersion) []string {
+	switch version {
+	case Swagger:
+		return swaggerSchemaKeywords
+	case OpenAPI30:
+		return openAPI30SchemaKeywords
+	default:
+		return openAPI31SchemaKeywords
+	}
+}
+
+// documentSpecVersion 根据文档根节点的 swagger 或 openapi 字段确定文档的版本。
+func documentSpecVersion(root *yaml.Node) (SpecVersion, bool) {
+	if version := mappingValue(root, "swagger"); version != nil {
+		return inputSpecVersion(version.Value)
+	}
+
+	if version := mappingValue(root, "openapi"); version != nil {
+		return inputSpecVersion(version.Value)
+	}
+
+	return 0, false
+}
+
+// unknownSchemaKeywords 返回文档的 schema 中不属于文档版本的关键字的位置，例如 ["#/components/schemas/Pet/patternProperties"]。
+// 注意：x- 扩展总是允许的
+func unknownSchemaKeywords(root *yaml.Node) []string {
+	version, ok := documentSpecVersion(root)
+
+	if !ok {
+		return nil
+	}
+
+	keywords := schemaKeywordsForVersion(version)
+	pointers := nodeJSONPointers(root)
+	var unknown []string
+
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		for i := 0; i+1 < len(schema.Content); i += 2 {
+			key := schema.Content[i].Value
+
+			if !strings.HasPrefix(key, "x-") && !slices.Contains(keywords, key) {
+				unknown = append(unknown, pointers[schema]+"/"+escapeJSONPointerToken(key))
+			}
+		}
+
+		return true
+	})
+
+	return unknown
+}
+
+// failUnknownKeywords 检查转换后的文档的 schema 中没有目标版本不支持的关键字（例如 OpenAPI 3.0 中的 patternProperties）。
+// 原因：转换函数遗漏的关键字会被原样保留，生成目标版本中无效的文档，这里作为最后的检查
+//
+// 返回：列出所有不支持的关键字位置的错误，没有不支持的关键字时返回 nil
+func failUnknownKeywords(root *yaml.Node) error {
+	if unknown := unknownSchemaKeywords(root); len(unknown) > 0 {
+		return fmt.Errorf("Schema keywords not supported by the target version: %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// dropUnsupportedFeatures 删除转换后的文档中目标版本不支持的关键字和功能，并为每次删除记录警告（--drop-unsupported）。
+// 映射关系：
+//   - OpenAPI 3.1 -> 3.0: {const: "cat"} -> {enum: ["cat"]}（schema 已经有 enum 时删除 const）
+//   - OpenAPI 3.1 -> 3.0: {patternProperties: {...}, dependentRequired: {...}} -> {}（删除 3.0 不支持的 schema 关键字）
+//   - OpenAPI 3.1 -> 3.0: components.securitySchemes 中 type 为 mutualTLS 的方案 -> 删除，并从 security 中移除对它的引用
+//   - OpenAPI 3.1 -> 3.0: info.license.identifier -> 删除
+//
+// 原因：转换函数只转换有 3.0 对应形式的功能，其余的功能被原样保留（见 failUnknownKeywords），
+// 这个选项作为一个总开关，使输出一定是有效的 3.0 文档
+func (converter *Converter) dropUnsupportedFeatures(root *yaml.Node) {
+	version, ok := documentSpecVersion(root)
+
+	if !ok || version != OpenAPI30 {
+		return
+	}
+
+	info, _ := lookupSpecVersion(version)
+	keywords := schemaKeywordsForVersion(version)
+	pointers := nodeJSONPointers(root)
+
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		for i := 0; i+1 < len(schema.Content); {
+			key, value := schema.Content[i].Value, schema.Content[i+1]
+
+			if strings.HasPrefix(key, "x-") || slices.Contains(keywords, key) {
+				i += 2
+				continue
+			}
+
+			pointer := pointers[schema] + "/" + escapeJSONPointerToken(key)
+			schema.Content = slices.Delete(schema.Content, i, i+2)
+
+			if key == "const" && mappingValue(schema, "enum") == nil {
+				setMappingValue(schema, "enum", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{value}})
+				converter.warn(pointer, "replaced const with an enum of one value, %s has no const", info.Name)
+			} else {
+				converter.warn(pointer, "dropped %s, %s schemas don't support it", key, info.Name)
+			}
+		}
+
+		return true
+	})
+
+	if license := mappingValue(mappingValue(root, "info"), "license"); deleteMappingKey(license, "identifier") {
+		converter.warn(jsonPointer("info", "license", "identifier"), "dropped the license identifier, %s has no license identifiers", info.Name)
+	}
+
+	schemes := mappingValue(mappingValue(root, "components"), "securitySchemes")
+
+	if schemes == nil || schemes.Kind != yaml.MappingNode {
+		return
+	}
+
+	var dropped []string
+
+	for i := 0; i+1 < len(schemes.Content); {
+		name, scheme := schemes.Content[i].Value, schemes.Content[i+1]
+
+		if schemeType := mappingValue(scheme, "type"); schemeType == nil || schemeType.Value != "mutualTLS" {
+			i += 2
+			continue
+		}
+
+		schemes.Content = slices.Delete(schemes.Content, i, i+2)
+		dropped = append(dropped, name)
+		converter.warn(jsonPointer("components", "securitySchemes", name), "dropped the mutualTLS security scheme, %s has no mutualTLS", info.Name)
+	}
+
+	if len(dropped) > 0 {
+		converter.removeSecurityRequirements(root, dropped)
+	}
+}
+
+// removeSecurityRequirements 从根对象和每个操作的 security 中移除使用 names 中的安全方案的条目。
+// 映射关系：
+//   - names = ["mtls"]: {security: [{mtls: []}, {apiKey: []}]} -> {security: [{apiKey: []}]}
+//   - names = ["mtls"]: {paths: {"/pets": {get: {security: [{mtls: []}]}}}} -> {paths: {"/pets": {get: {}}}}
+//
+// 注意：见 filterSecurityRequirements，所有条目都被移除时 security 被删除
+func (converter *Converter) removeSecurityRequirements(root *yaml.Node, names []string) {
+	removeFrom := func(parent *yaml.Node, pointer string) {
+		security := mappingValue(parent, "security")
+
+		if security == nil || security.Kind != yaml.SequenceNode {
+			return
+		}
+
+		requirements, emptied := filterSecurityRequirements(converter, security.Content, names, func(requirement *yaml.Node) []string {
+			var schemes []string
+
+			for k := 0; k+1 < len(requirement.Content); k += 2 {
+				schemes = append(schemes, requirement.Content[k].Value)
+			}
+
+			return schemes
+		}, pointer)
+
+		if emptied {
+			deleteMappingKey(parent, "security")
+		} else {
+			security.Content = requirements
+		}
+	}
+
+	removeFrom(root, jsonPointer("security"))
+
+	paths := mappingValue(root, "paths")
+
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		pathItem := paths.Content[i+1]
+
+		if pathItem.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for j := 0; j+1 < len(pathItem.Content); j += 2 {
+			if pathItem.Content[j+1].Kind == yaml.MappingNode {
+				removeFrom(pathItem.Content[j+1], jsonPointer("paths", paths.Content[i].Value, pathItem.Content[j].Value, "security"))
+			}
+		}
+	}
+}
+
+// filterSecurityRequirements 从 security 中移除使用 names 中的安全方案的条目，schemes 返回一个条目使用的安全方案的名称。
+// 映射关系：
+//   - names = ["mtls"]: [{mtls: []}, {apiKey: []}] -> [{apiKey: []}]
+//   - names = ["mtls"]: [{mtls: []}] -> 删除 security，并记录警告
+//
+// 注意：
+//   - 一个条目中的方案需要同时满足，所以整个条目被移除，而不是只移除条目中的方案
+//   - 操作的 security: [] 表示不需要认证，所以所有条目都被移除时 security 需要被删除，操作会继承根对象的 security；
+//     原本就是 [] 的 security 不会被修改
+//
+// 返回：保留的条目，以及调用者是否需要删除 security
+func filterSecurityRequirements[Requirement any](converter *Converter, security []Requirement, names []string, schemes func(Requirement) []string, pointer string) ([]Requirement, bool) {
+	if len(security) == 0 {
+		return security, false
+	}
+
+	security = slices.DeleteFunc(security, func(requirement Requirement) bool {
+		return slices.ContainsFunc(schemes(requirement), func(name string) bool { return slices.Contains(names, name) })
+	})
+
+	if len(security) > 0 {
+		return security, false
+	}
+
+	if pointer == jsonPointer("security") {
+		converter.warn(pointer, "dropped every security requirement, the document has no security requirements")
+	} else {
+		converter.warn(pointer, "dropped every security requirement, the operation uses the root security requirements")
+	}
+
+	return nil, true
+}
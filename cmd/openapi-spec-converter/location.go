@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// swaggerSchemes 是 Swagger 2.0 schemes 字段允许的值
+var swaggerSchemes = []string{"http", "https", "ws", "wss"}
+
+// parseSchemes 解析 --schemes 的值，例如 "https,http" -> ["https", "http"]。
+// 返回：按给出顺序排列的协议，重复的协议只保留一次；有 Swagger 2.0 不支持的协议时返回错误
+func parseSchemes(value string) ([]string, error) {
+	var schemes []string
+
+	for _, scheme := range strings.Split(value, ",") {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+
+		if !slices.Contains(swaggerSchemes, scheme) {
+			return nil, fmt.Errorf("Invalid scheme: %s, expected one of %s", scheme, strings.Join(swaggerSchemes, ", "))
+		}
+
+		if !slices.Contains(schemes, scheme) {
+			schemes = append(schemes, scheme)
+		}
+	}
+
+	return schemes, nil
+}
+
+// setSwaggerLocation 设置 Swagger 2.0 文档的 host、basePath 和 schemes（--host、--base-path、--schemes）。
+// 映射关系：
+//   - {swagger: "2.0", paths: {...}} + --host api.example.com --base-path /v1 --schemes https
+//     -> {swagger: "2.0", host: "api.example.com", basePath: "/v1", schemes: ["https"], paths: {...}}
+//   - 文档中已有的值被替换，空的参数不修改对应的字段
+//
+// 原因：没有 servers 的 OpenAPI 3.x 文档（使用相对地址）转换后没有 host 和 basePath，一些 API 网关要求设置 basePath
+//
+// 注意：设置后根对象的字段按 Swagger 2.0 规范的书写顺序重新排列（见 orderSwaggerRootKeys）
+func setSwaggerLocation(root *yaml.Node, host string, basePath string, schemes []string) {
+	if len(host) > 0 {
+		setMappingValue(root, "host", newStringNode(host))
+	}
+
+	if len(basePath) > 0 {
+		setMappingValue(root, "basePath", newStringNode(basePath))
+	}
+
+	if len(schemes) > 0 {
+		sequence := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+		for _, scheme := range schemes {
+			sequence.Content = append(sequence.Content, newStringNode(scheme))
+		}
+
+		setMappingValue(root, "schemes", sequence)
+	}
+
+	orderSwaggerRootKeys(root)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// logEntry 是 --log-format json 时在标准错误输出中打印的一行日志
+type logEntry struct {
+	Level      string        `json:"level"`                // 日志级别："info"、"warning" 或 "error"
+	Message    string        `json:"message"`              // 日志内容，与 text 格式中打印的文本相同
+	Line       int           `json:"line,omitempty"`       // --jsonl 模式下警告所在的行号（从 1 开始）
+	Path       string        `json:"path,omitempty"`       // 警告发生的位置（JSON Pointer）
+	Conversion string        `json:"conversion,omitempty"` // 耗时所属的转换步骤，例如 "3.1 -> 3.0"
+	Stage      string        `json:"stage,omitempty"`      // 耗时所属的阶段名称，例如 "load"
+	Duration   time.Duration `json:"duration,omitempty"`   // 阶段耗时（纳秒）
+}
+
+// writeLogEntry 将 entry 编码为一行 JSON 写入 w。
+func writeLogEntry(w io.Writer, entry logEntry) error {
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+
+	return err
+}
+
+// jsonLogWriter 将每次写入的文本作为一行 level 级别的 JSON 日志写入 w。
+// 用于 --log-format json 时 log 包输出的错误和 --jsonl 的进度，它们每条消息只调用一次 Write
+type jsonLogWriter struct {
+	w     io.Writer // 写入 JSON 日志的目标，通常为标准错误输出
+	level string    // 写入的日志级别，例如 "error"
+}
+
+func (writer jsonLogWriter) Write(p []byte) (int, error) {
+	message := strings.TrimRight(string(p), "\n")
+
+	if err := writeLogEntry(writer.w, logEntry{Level: writer.level, Message: message}); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
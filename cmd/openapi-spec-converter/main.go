@@ -1,14 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	openapispecconverter "github.com/dense-analysis/openapi-spec-converter"
 	"github.com/getkin/kin-openapi/openapi2"
@@ -19,6 +25,7 @@ import (
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"github.com/pb33f/libopenapi/datamodel/low"
+	"github.com/pb33f/libopenapi/orderedmap"
 	"github.com/pb33f/libopenapi/utils"
 	"github.com/pborman/getopt/v2"
 	"gopkg.in/yaml.v3"
@@ -43,18 +50,150 @@ const (
 
 // Arguments 存储命令行参数解析后的结果
 type Arguments struct {
-	inputFilename  string      // 输入文件名（"-" 表示从标准输入读取）
-	outputFilename string      // 输出文件名（空字符串表示输出到标准输出）
-	outputTarget   SpecVersion // 目标版本（Swagger/OpenAPI30/OpenAPI31）
-	outputFormat   Format      // 输出格式（JSON/YAML）
+	inputFilename   string            // 输入文件名（"-" 表示从标准输入读取）
+	inputEnv        string            // 读取输入的环境变量名称（空字符串表示从输入文件或标准输入读取）
+	outputFilename  string            // 输出文件名（空字符串表示输出到标准输出）
+	outputTarget    SpecVersion       // 目标版本（Swagger/OpenAPI30/OpenAPI31）
+	outputFormat    Format            // 输出格式（JSON/YAML）
+	grpcSummary     bool              // 转换为 Swagger 时是否将 description 复制到空的 summary
+	grpcAnnotation  bool              // 转换为 Swagger 时是否在 description 中追加 gRPC 信息
+	verbose         bool              // 是否在标准错误输出中打印各转换阶段的耗时
+	timingsJSON     bool              // 是否以 JSON 格式打印各转换阶段的耗时
+	logJSON         bool              // 是否以每行一个 JSON 对象的格式在标准错误输出中打印警告、耗时、进度和错误
+	dedupeSchemas   bool              // 转换后是否将结构相同的 inline schema 提升到 components 中
+	responseMedia   string            // 转换为 Swagger 时响应优先使用的媒体类型
+	singleConsumes  bool              // 转换为 Swagger 时是否只保留一个 consumes 和 produces 媒体类型
+	defaultProduces string            // 转换为 Swagger 时为有响应但没有 produces 的操作设置的媒体类型（空字符串表示不设置）
+	inlineResponses bool              // 转换为 Swagger 时是否将操作中引用 components.responses 的响应替换为引用的内容
+	serverDesc      bool              // 转换为 Swagger 时是否将第一个 server 的 description 保存在根对象的 x-server-description 中
+	swaggerHost     string            // 转换为 Swagger 时设置的 host（空字符串表示使用 servers 转换的值）
+	basePath        string            // 转换为 Swagger 时设置的 basePath（空字符串表示使用 servers 转换的值）
+	schemes         []string          // 转换为 Swagger 时设置的 schemes，例如 ["https"]（nil 表示使用 servers 转换的值）
+	outputMode      *os.FileMode      // 写入输出文件时使用的权限（nil 表示新文件使用 0644，并且不修改已经存在的文件的权限）
+	noClobber       bool              // 输出文件已经存在时是否以错误退出，而不是覆盖它
+	listVersions    bool              // 是否只打印支持的版本，不进行转换
+	flattenAllOf    bool              // 转换为 Swagger 时是否将单层的 allOf 合并为扁平的 schema
+	inputFormat     *Format           // 强制使用的输入格式（nil 表示自动检测）
+	assumedVersion  *SpecVersion      // 文档没有 swagger 或 openapi 字段时假定的输入版本（nil 表示不假定）
+	infoSummary     bool              // 从 OpenAPI 3.1 降级时是否将 info.summary 添加到 info.description 的开头，而不是丢弃
+	stripReadOnly   bool              // 转换后是否删除 readOnly 的属性（用于只生成请求的代码）
+	stripWriteOnly  bool              // 转换后是否删除 writeOnly 的属性（用于只生成响应的代码）
+	declareTags     bool              // 转换后是否为操作使用但没有声明的标签在根对象的 tags 中添加条目
+	clean           bool              // 转换后是否删除空的 components.schemas、definitions 等字段
+	warningsFile    string            // 写入有损转换警告的 JSON 文件（空字符串表示打印到标准错误输出）
+	jsonl           bool              // 是否将输入的每一行作为独立的文档转换，并输出 NDJSON
+	quiet           bool              // --jsonl 模式下是否不在标准错误输出中打印每个文档的进度
+	stripExtensions []string          // 转换后删除名称以这些前缀开头的扩展，例如 "x-internal-"
+	fixPaths        bool              // 转换前是否为缺少前导 "/" 的路径添加 "/"
+	mergeSlashPaths bool              // 转换前是否将以 "/" 结尾的路径合并到没有结尾 "/" 的相同路径中
+	requireOpIDs    bool              // 转换后是否要求每个操作都有 operationId
+	normalize       bool              // 是否保持输入版本，只规范化文档（YAML 输入的注释会被保留）
+	canonicalize    bool              // 是否保持输入版本，将文档转换为字段排序后的规范形式
+	componentPrefix string            // --dedupe-schemas 生成的组件名称使用的前缀（空字符串表示没有前缀）
+	schemaNameCase  NameCase          // --dedupe-schemas 生成的和 --sanitize-names 重命名的组件名称使用的大小写风格（KeepCase 表示不修改）
+	failUnknownKeys bool              // 转换后如果 schema 中有目标版本不支持的关键字，是否以错误退出
+	failRefCycles   bool              // 转换后如果组件 schema 之间有 $ref 循环，是否以错误退出（否则只记录警告）
+	validateStrict  bool              // 转换后是否使用 libopenapi 和 kin-openapi 验证文档，文档无效时以错误退出
+	reportFile      string            // 写入转换报告的 JSON 文件（空字符串表示不写入）
+	postman         bool              // 是否输出 Postman Collection v2.1（先转换为 OpenAPI 3.0，outputTarget 为 OpenAPI30）
+	nullableEnums   bool              // 转换为 OpenAPI 3.0 时是否为 nullable 的 schema 的 enum 添加 null
+	flattenOneOf    bool              // 转换为 OpenAPI 3.0 时是否将只包含基本类型的 oneOf 合并为一个 nullable 的类型
+	dropUnsupported bool              // 转换为 OpenAPI 3.0 时是否删除 3.0 不支持的 schema 关键字和功能（例如 patternProperties、mutualTLS），而不是原样保留
+	nullableAnyOf   bool              // 转换为 OpenAPI 3.1 时是否将 nullable 的 schema 转换为 anyOf: [schema, {type: "null"}]，而不是 type 数组
+	intBounds       bool              // 转换为 OpenAPI 3.1 时是否为 format 为 int32 或 int64 的 integer schema 添加 minimum 和 maximum
+	defaultExamples bool              // 转换为 OpenAPI 3.1 时是否将 schema 的 default 复制到没有示例的 schema 的 examples 中
+	keepExamples    bool              // 输出 YAML 时是否恢复输入中示例数据的 YAML 书写风格（多行字符串、日期）
+	yamlExplicit    bool              // 输出 YAML 时是否在开头添加 "---" 文档标记
+	sanitizeNames   bool              // 转换为 Swagger 时是否将 definitions 名称中的特殊字符替换为 "_"
+	outputVersion   string            // 输出文档的 swagger 或 openapi 字段值（空字符串表示使用 specVersions 中的输出版本）
+	remoteRefs      bool              // 转换前是否下载并内联指向 http(s) URL 的 $ref
+	mergeBase       string            // 转换前合并到输入中的基础文档的文件名（空字符串表示不合并）
+	ensureInfo      bool              // 转换前是否为没有 info 的文档添加 {title: "Converted API", version: "0.0.0"}，并补充缺少的 title 和 version
+	formatMappings  map[string]string // 转换后重写的 schema format，旧值 -> 新值，例如 "int64" -> "long"
+}
+
+// StageTiming 记录一次转换中单个阶段的耗时
+type StageTiming struct {
+	Conversion string        `json:"conversion"` // 转换步骤，例如 "3.1 -> 3.0"
+	Stage      string        `json:"stage"`      // 阶段名称，例如 "load"、"build"、"render and reload"
+	Duration   time.Duration `json:"duration"`   // 阶段耗时（JSON 中以纳秒表示）
+}
+
+// Warning 记录一次有损转换，例如被丢弃的字段或内容
+type Warning struct {
+	Line    int    `json:"line,omitempty"` // --jsonl 模式下文档所在的行号（从 1 开始）
+	Path    string `json:"path"`           // 发生有损转换的位置（JSON Pointer），例如 "#/paths/~1pets/get"
+	Message string `json:"message"`        // 说明丢弃或修改了什么
+}
+
+// Converter 存储一次转换使用的参数，以及转换过程中收集到的信息
+type Converter struct {
+	arguments Arguments         // 命令行参数
+	timings   []StageTiming     // 按执行顺序记录的各阶段耗时
+	warnings  []Warning         // 按发现顺序记录的有损转换警告
+	steps     []string          // 按执行顺序记录的转换和处理步骤，例如 "3.1 -> 3.0"、"dedupe schemas"
+	renames   map[string]string // --sanitize-names 重命名的 definitions，旧名称 -> 新名称
 }
 
 // parseArgs 解析命令行参数并返回 Arguments 结构体。
 // 支持的参数：
 //   - --help, -h: 显示帮助信息
 //   - --output, -o: 指定输出文件（默认为标准输出）
-//   - --target, -t: 指定目标版本，可选值：swagger, 3.0, 3.1（默认为 3.1）
+//   - --target, -t: 指定目标版本，可选值：swagger, 3.0, 3.1, postman（默认为 3.1）；postman 输出 Postman Collection v2.1 JSON
+//   - --output-openapi-version: 输出文档的 openapi 字段值，例如 --target 3.1 时输出 "3.1.0" 而不是 "3.1.1"（必须是目标版本可以读取的版本）
 //   - --format, -f: 指定输出格式，可选值：json, yaml（默认为 json）
+//   - --input-format: 强制使用的输入格式，可选值：json, yaml（默认自动检测）
+//   - --assume-version: 文档没有 swagger 或 openapi 字段时假定的输入版本，可选值：swagger, 3.0, 3.1（文档有版本字段时不起作用）
+//   - --no-grpc-summary: 转换为 Swagger 时不将 description 复制到空的 summary
+//   - --no-grpc-annotation: 转换为 Swagger 时不在 description 中追加 gRPC 信息
+//   - --verbose, -v: 在标准错误输出中打印各转换阶段的耗时
+//   - --timings-json: 以 JSON 格式打印各转换阶段的耗时（隐含 --verbose）
+//   - --log-format: 标准错误输出中日志的格式，可选值：text, json（默认为 text）；json 时警告、耗时、进度和错误每条一行 JSON
+//   - --dedupe-schemas: 转换后将结构相同的 inline schema 提升到 components 中并替换为 $ref
+//   - --components-prefix: --dedupe-schemas 生成的组件名称的前缀，例如 "Billing" -> "Billing_Generated1"（只能与 --dedupe-schemas 一起使用）
+//   - --response-media: 转换为 Swagger 时，响应有多个媒体类型时优先使用的媒体类型（默认为 application/json）
+//   - --chmod: 输出文件的权限，八进制（没有设置时新文件使用 0644 并受 umask 影响，已经存在的文件保持原来的权限）
+//   - --no-clobber, -n: 如果 --output 指定的文件已经存在，则以非零状态退出，而不是覆盖它（输入和输出是同一个文件时同样会拒绝）
+//   - --list-versions: 打印支持的输入和输出版本，然后退出（不需要输入文件）
+//   - --preserve-info-summary: 从 OpenAPI 3.1 降级时将 info.summary 添加到 info.description 的开头（不能与 --target 3.1 一起使用）
+//   - --polyfill-nullable-enum: 转换为 OpenAPI 3.0 时为 nullable 的 schema 的 enum 添加 null（只能与 --target 3.0 一起使用）
+//   - --flatten-nullable-oneof: 转换为 OpenAPI 3.0 时将只包含基本类型的 oneOf 合并为一个类型，例如 string 和 integer 合并为 string（只能与 --target 3.0 一起使用）
+//   - --drop-unsupported: 转换为 OpenAPI 3.0 时删除 3.0 不支持的 schema 关键字、mutualTLS 安全方案和 license.identifier 并记录警告，const 被替换为只有一个值的 enum（只能与 --target 3.0 一起使用，不能与 --fail-unknown-keywords 一起使用）
+//   - --flatten-allof: 转换为 Swagger 时将单层的 allOf 合并为扁平的 schema（只能与 --target swagger 一起使用）
+//   - --modern-nullable-anyof: 转换为 OpenAPI 3.1 时将 nullable 的 schema 转换为 anyOf: [schema, {type: "null"}]，而不是 type 数组（只能与 --target 3.1 一起使用）
+//   - --upgrade-int-formats: 转换为 OpenAPI 3.1 时为 format 为 int32 或 int64 的 integer schema 添加 format 可以表示的 minimum 和 maximum（只能与 --target 3.1 一起使用）
+//   - --examples-from-default: 转换为 OpenAPI 3.1 时将 schema 的 default 复制到没有 examples 的 schema 的 examples 中，default 为 null 时跳过（只能与 --target 3.1 一起使用）
+//   - --host、--base-path、--schemes: 转换为 Swagger 时替换 host、basePath 和 schemes（只能与 --target swagger 一起使用）
+//   - --keep-server-description: 转换为 Swagger 时将第一个 server 的 description 保存在根对象的 x-server-description 扩展中（只能与 --target swagger 一起使用）
+//   - --inline-response-refs: 转换为 Swagger 时将操作中引用 components.responses 的 $ref 替换为响应的内容（只能与 --target swagger 一起使用）
+//   - --single-consumes: 转换为 Swagger 时只保留一个 consumes（优先 application/json）和 produces（优先 --response-media）媒体类型（只能与 --target swagger 一起使用）
+//   - --produces-default: 转换为 Swagger 时为有响应但没有 produces 的操作设置 produces，例如 application/json（只能与 --target swagger 一起使用）
+//   - --schema-name-case: --dedupe-schemas 生成的和 --sanitize-names 重命名的组件名称使用的风格：PascalCase、camelCase 或 snake_case（只能与 --dedupe-schemas 或 --sanitize-names 一起使用）
+//   - --sanitize-names: 转换为 Swagger 时将 definitions 名称中的特殊字符替换为 "_" 并更新 $ref（只能与 --target swagger 一起使用）
+//   - --warnings-file: 将有损转换警告以 JSON 数组写入指定文件，而不是打印到标准错误输出
+//   - --report: 将转换报告（输入和输出版本、执行的步骤、警告、耗时、被删除的关键字、重命名的 definitions）以 JSON 写入指定文件（不能与 --jsonl 一起使用）
+//   - --jsonl: 将输入的每一行作为独立的文档转换，输出 NDJSON（只能输出 JSON 格式）
+//   - --quiet, -q: --jsonl 模式下不在标准错误输出中打印每个文档的进度
+//   - --strip-ext: 转换后删除名称以指定前缀开头的扩展，可以重复指定，前缀必须以 "x-" 开头
+//   - --strip-readonly: 转换后删除所有 schema 中 readOnly 的属性，并从 required 中移除
+//   - --strip-writeonly: 转换后删除所有 schema 中 writeOnly 的属性，并从 required 中移除
+//   - --declare-tags: 转换后为操作使用但没有声明的标签在根对象的 tags 中添加条目
+//   - --clean: 转换后删除根对象和 components 中为空的字段，例如 components.schemas: {} 和 tags: []
+//   - --map-format: 转换后将 schema 的 format 从 old 重写为 new，例如 "int64=long"，可以重复指定
+//   - --fix-paths: 转换前为缺少前导 "/" 的路径添加 "/"，例如 "pets" -> "/pets"
+//   - --merge-trailing-slash: 转换前将 "/pets/" 的操作合并到 "/pets" 中，冲突的操作保留 "/pets" 中的操作并记录警告
+//   - --allow-remote-refs: 转换前下载并内联指向 http(s) URL 的 $ref（默认关闭，只支持 OpenAPI 3.x 输入）
+//   - --merge-base: 转换前将基础文档中的 info、servers、security、tags 和 components 合并到输入中，冲突时使用输入中的值（只支持 OpenAPI 3.x）
+//   - --ensure-info: 转换前为没有 info 的文档添加 info: {title: "Converted API", version: "0.0.0"}，并为 info 补充缺少的 title 和 version
+//   - --require-operation-id: 转换后如果有操作缺少 operationId，则列出这些操作并以非零状态退出
+//   - --fail-unknown-keywords: 转换后如果 schema 中有目标版本不支持的关键字，则列出这些关键字并以非零状态退出
+//   - --fail-on-ref-cycle: 转换后如果组件 schema 之间有 $ref 循环（例如引用自身的树节点），则列出循环的路径并以非零状态退出，否则只记录警告
+//   - --validate-strict: 转换后使用 libopenapi 和 kin-openapi 验证文档，文档无效时列出两者发现的错误并以非零状态退出
+//   - --normalize: 不转换版本，只规范化文档并保留 YAML 注释（不能与 --target 一起使用）
+//   - --canonicalize: 不转换版本，排列所有字段、删除重复的 required 和空的字段，使含义相同的文档得到相同的结果（不能与 --target 一起使用）
+//   - --preserve-examples-format: 输出 YAML 时恢复输入中示例的多行字符串和日期的书写风格（只能与 --format yaml 一起使用）
+//   - --yaml-explicit: 输出 YAML 时在开头添加 "---" 文档标记，一些 YAML 工具要求文档以它开始（只能与 --format yaml 一起使用）
+//   - --input-env: 从指定的环境变量读取输入，而不是输入文件或标准输入（不能与 <input> 一起使用）
 //   - <input>: 输入文件名（可选，如果不提供则从标准输入读取）
 //
 // 返回：解析后的 Arguments 结构体
@@ -65,8 +204,63 @@ func parseArgs() Arguments {
 
 	showHelp := getopt.BoolLong("help", 'h', "Print this help message")
 	outputFilename := getopt.StringLong("output", 'o', "", "Output file (default stdout)")
-	outputVersion := getopt.StringLong("target", 't', "3.1", "Target version: swagger, 3.0, or 3.1")
+	outputVersion := getopt.StringLong("target", 't', "3.1", "Target version: swagger, 3.0, 3.1, or postman")
+	emittedVersion := getopt.StringLong("output-openapi-version", 0, "", "Version string to write for the target, e.g. 3.1.0 (default latest)")
 	outputFormat := getopt.StringLong("format", 'f', "json", "Output format: yaml or json")
+	inputFormat := getopt.StringLong("input-format", 0, "", "Input format: yaml or json (default auto-detect)")
+	inputEnv := getopt.StringLong("input-env", 0, "", "Read the input from this environment variable instead of a file or stdin")
+	assumedVersion := getopt.StringLong("assume-version", 0, "", "Input version for documents without a swagger or openapi field: swagger, 3.0, or 3.1")
+	noGRPCSummary := getopt.BoolLong("no-grpc-summary", 0, "Don't copy descriptions into empty summaries for Swagger")
+	noGRPCAnnotation := getopt.BoolLong("no-grpc-annotation", 0, "Don't append gRPC info to descriptions for Swagger")
+	verbose := getopt.BoolLong("verbose", 'v', "Print the duration of each conversion stage to stderr")
+	timingsJSON := getopt.BoolLong("timings-json", 0, "Print conversion stage durations as JSON (implies --verbose)")
+	logFormat := getopt.StringLong("log-format", 0, "text", "Log format for warnings, timings and errors on stderr: text or json")
+	dedupeSchemas := getopt.BoolLong("dedupe-schemas", 0, "Hoist structurally identical inline schemas into components")
+	componentPrefix := getopt.StringLong("components-prefix", 0, "", "Prefix for component names created by --dedupe-schemas, e.g. Billing")
+	schemaNameCase := getopt.StringLong("schema-name-case", 0, "", "Case for generated and sanitized component names: PascalCase, camelCase, or snake_case")
+	responseMedia := getopt.StringLong("response-media", 0, "application/json", "Preferred response media type for Swagger")
+	singleConsumes := getopt.BoolLong("single-consumes", 0, "Keep only one consumes and produces media type for Swagger, preferring JSON")
+	defaultProduces := getopt.StringLong("produces-default", 0, "", "Set produces on Swagger operations with responses but no produces, e.g. application/json")
+	inlineResponses := getopt.BoolLong("inline-response-refs", 0, "Replace response $refs in operations with the responses for Swagger")
+	serverDesc := getopt.BoolLong("keep-server-description", 0, "Keep the server description as x-server-description for Swagger")
+	swaggerHost := getopt.StringLong("host", 0, "", "Set the Swagger host, e.g. api.example.com")
+	basePath := getopt.StringLong("base-path", 0, "", "Set the Swagger basePath, e.g. /v1")
+	schemes := getopt.StringLong("schemes", 0, "", "Set the Swagger schemes, e.g. https,http")
+	outputMode := getopt.StringLong("chmod", 0, "", "Output file permissions in octal, e.g. 0600")
+	noClobber := getopt.BoolLong("no-clobber", 'n', "Fail instead of overwriting an existing output file")
+	listVersions := getopt.BoolLong("list-versions", 0, "Print the supported input and output versions and exit")
+	infoSummary := getopt.BoolLong("preserve-info-summary", 0, "Prepend info.summary to info.description when downgrading 3.1")
+	nullableEnums := getopt.BoolLong("polyfill-nullable-enum", 0, "Add null to the enum of nullable schemas for 3.0")
+	flattenOneOf := getopt.BoolLong("flatten-nullable-oneof", 0, "Merge oneOf of primitive types like string and integer into one type for 3.0")
+	dropUnsupported := getopt.BoolLong("drop-unsupported", 0, "Drop 3.1 keywords and features with no 3.0 equivalent, with warnings")
+	sanitizeNames := getopt.BoolLong("sanitize-names", 0, "Replace characters other than letters, digits, '.', '-' and '_' in Swagger definition names")
+	flattenAllOf := getopt.BoolLong("flatten-allof", 0, "Merge single level allOf schemas into flat schemas for Swagger")
+	nullableAnyOf := getopt.BoolLong("modern-nullable-anyof", 0, "Convert nullable schemas to anyOf with {type: null} instead of type arrays for 3.1")
+	intBounds := getopt.BoolLong("upgrade-int-formats", 0, "Add the minimum and maximum of int32 and int64 formats to integer schemas for 3.1")
+	defaultExamples := getopt.BoolLong("examples-from-default", 0, "Add schema defaults as examples to schemas without examples for 3.1")
+	warningsFile := getopt.StringLong("warnings-file", 0, "", "Write lossy conversion warnings to a JSON file instead of stderr")
+	reportFile := getopt.StringLong("report", 0, "", "Write a JSON report of versions, steps, warnings and timings to a file")
+	jsonl := getopt.BoolLong("jsonl", 0, "Convert each input line as a separate JSON document and output NDJSON")
+	quiet := getopt.BoolLong("quiet", 'q', "Don't print progress for each --jsonl document")
+	remoteRefs := getopt.BoolLong("allow-remote-refs", 0, "Download and inline $refs to http(s) URLs before converting 3.x documents")
+	mergeBase := getopt.StringLong("merge-base", 0, "", "Merge info, servers, security, tags and components from a base 3.x document into the input")
+	fixPaths := getopt.BoolLong("fix-paths", 0, "Add a leading slash to path keys that lack one before converting")
+	mergeSlashPaths := getopt.BoolLong("merge-trailing-slash", 0, "Merge operations from paths like /pets/ into /pets before converting")
+	ensureInfo := getopt.BoolLong("ensure-info", 0, "Add a minimal info with a title and version to documents missing them")
+	failUnknownKeys := getopt.BoolLong("fail-unknown-keywords", 0, "Fail if any converted schema has keywords the target version doesn't support")
+	failRefCycles := getopt.BoolLong("fail-on-ref-cycle", 0, "Fail if converted schemas reference each other in a cycle, instead of warning")
+	validateStrict := getopt.BoolLong("validate-strict", 0, "Validate the converted document with both libopenapi and kin-openapi")
+	normalize := getopt.BoolLong("normalize", 0, "Normalize the document without changing its version, keeping YAML comments")
+	canonicalize := getopt.BoolLong("canonicalize", 0, "Sort and clean up the document without changing its version, for diffing")
+	keepExamples := getopt.BoolLong("preserve-examples-format", 0, "Keep the YAML style of multi-line strings and dates in examples")
+	yamlExplicit := getopt.BoolLong("yaml-explicit", 0, "Start YAML output with a --- document marker")
+	requireOpIDs := getopt.BoolLong("require-operation-id", 0, "Fail if any converted operation has no operationId")
+	stripReadOnly := getopt.BoolLong("strip-readonly", 0, "Remove readOnly properties after converting, e.g. for request-only specs")
+	stripWriteOnly := getopt.BoolLong("strip-writeonly", 0, "Remove writeOnly properties after converting, e.g. for response-only specs")
+	declareTags := getopt.BoolLong("declare-tags", 0, "Add root tags entries for operation tags which aren't declared")
+	clean := getopt.BoolLong("clean", 0, "Remove empty sections such as components.schemas after converting")
+	formatMappings := getopt.ListLong("map-format", 0, "Rewrite schema formats after converting, e.g. int64=long (repeatable)", "old=new")
+	stripExtensions := getopt.ListLong("strip-ext", 0, "Remove extensions starting with this prefix, e.g. x-internal- (repeatable)", "prefix")
 	getopt.SetParameters("<input>")
 
 	getopt.Parse()
@@ -77,6 +271,8 @@ func parseArgs() Arguments {
 	}
 
 	args := getopt.Args()
+	arguments.listVersions = *listVersions
+	arguments.inputEnv = *inputEnv
 
 	if len(args) > 2 {
 		fmt.Fprintln(os.Stderr, "Invalid number of arguments")
@@ -84,7 +280,15 @@ func parseArgs() Arguments {
 		os.Exit(1)
 	}
 
-	if len(args) == 0 {
+	if arguments.listVersions {
+		// No input is read when only listing versions.
+	} else if len(arguments.inputEnv) > 0 {
+		if len(args) > 0 {
+			fmt.Fprintln(os.Stderr, "--input-env can't be used with an input filename")
+			getopt.PrintUsage(os.Stderr)
+			os.Exit(1)
+		}
+	} else if len(args) == 0 {
 		// If no arguments are supplied and there's no data being piped in,
 		// then complain and print usage.
 		if stat, err := os.Stdin.Stat(); err != nil || (stat.Mode()&os.ModeCharDevice) != 0 {
@@ -98,27 +302,290 @@ func parseArgs() Arguments {
 		arguments.inputFilename = args[0]
 	}
 
-	if len(arguments.inputFilename) == 0 {
+	if len(arguments.inputFilename) == 0 && len(arguments.inputEnv) == 0 && !arguments.listVersions {
 		fmt.Fprintln(os.Stderr, "Empty input filename")
 		getopt.PrintUsage(os.Stderr)
 		os.Exit(1)
 	}
 
 	arguments.outputFilename = *outputFilename
+	arguments.noClobber = *noClobber
+	arguments.grpcSummary = !*noGRPCSummary
+	arguments.grpcAnnotation = !*noGRPCAnnotation
+	arguments.verbose = *verbose || *timingsJSON
+	arguments.timingsJSON = *timingsJSON
+	arguments.dedupeSchemas = *dedupeSchemas
+	arguments.componentPrefix = *componentPrefix
+	arguments.responseMedia = *responseMedia
+	arguments.singleConsumes = *singleConsumes
+	arguments.defaultProduces = *defaultProduces
+	arguments.inlineResponses = *inlineResponses
+	arguments.serverDesc = *serverDesc
+	arguments.swaggerHost = *swaggerHost
+	arguments.basePath = *basePath
+	arguments.flattenAllOf = *flattenAllOf
+	arguments.nullableAnyOf = *nullableAnyOf
+	arguments.intBounds = *intBounds
+	arguments.defaultExamples = *defaultExamples
+	arguments.sanitizeNames = *sanitizeNames
+	arguments.outputVersion = *emittedVersion
+	arguments.nullableEnums = *nullableEnums
+	arguments.flattenOneOf = *flattenOneOf
+	arguments.dropUnsupported = *dropUnsupported
+	arguments.infoSummary = *infoSummary
+	arguments.stripReadOnly = *stripReadOnly
+	arguments.stripWriteOnly = *stripWriteOnly
+	arguments.declareTags = *declareTags
+	arguments.clean = *clean
+	arguments.warningsFile = *warningsFile
+	arguments.jsonl = *jsonl
+	arguments.quiet = *quiet
+	arguments.reportFile = *reportFile
+	arguments.stripExtensions = *stripExtensions
+	arguments.fixPaths = *fixPaths
+	arguments.mergeSlashPaths = *mergeSlashPaths
+	arguments.ensureInfo = *ensureInfo
+	arguments.remoteRefs = *remoteRefs
+	arguments.mergeBase = *mergeBase
+	arguments.requireOpIDs = *requireOpIDs
+	arguments.normalize = *normalize
+	arguments.canonicalize = *canonicalize
+	arguments.failUnknownKeys = *failUnknownKeys
+	arguments.failRefCycles = *failRefCycles
+	arguments.validateStrict = *validateStrict
+	arguments.keepExamples = *keepExamples
+	arguments.yamlExplicit = *yamlExplicit
+
+	if (arguments.normalize || arguments.canonicalize) && getopt.IsSet("target") {
+		fmt.Fprintln(os.Stderr, "--normalize and --canonicalize keep the input version and can't be used with --target")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if mappings, err := parseFormatMappings(*formatMappings); err == nil {
+		arguments.formatMappings = mappings
+	} else {
+		fmt.Fprintln(os.Stderr, err)
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	for _, prefix := range arguments.stripExtensions {
+		if !strings.HasPrefix(prefix, "x-") {
+			fmt.Fprintf(os.Stderr, "Invalid extension prefix: %s, extensions start with x-\n", prefix)
+			getopt.PrintUsage(os.Stderr)
+			os.Exit(1)
+		}
+	}
+
+	// Only change the permissions when asked to, so existing files and the umask are respected.
+	if getopt.IsSet("chmod") {
+		mode, err := strconv.ParseUint(*outputMode, 8, 32)
+
+		if err != nil || mode > uint64(os.ModePerm) {
+			fmt.Fprintf(os.Stderr, "Invalid file mode: %s\n", *outputMode)
+			getopt.PrintUsage(os.Stderr)
+			os.Exit(1)
+		}
+
+		fileMode := os.FileMode(mode)
+		arguments.outputMode = &fileMode
+	}
 
-	switch strings.ToLower(*outputVersion) {
-	case "swagger":
-		arguments.outputTarget = Swagger
-	case "3.0":
+	if strings.EqualFold(*outputVersion, "postman") {
+		// Postman collections are built from the 3.0 document.
 		arguments.outputTarget = OpenAPI30
-	case "3.1":
-		arguments.outputTarget = OpenAPI31
-	default:
+		arguments.postman = true
+	} else if target, ok := targetSpecVersion(*outputVersion); ok {
+		arguments.outputTarget = target
+	} else {
 		fmt.Fprintf(os.Stderr, "Invalid target version %s\n", *outputVersion)
 		getopt.PrintUsage(os.Stderr)
 		os.Exit(1)
 	}
 
+	if len(arguments.outputVersion) > 0 {
+		info, _ := lookupSpecVersion(arguments.outputTarget)
+
+		if arguments.postman || arguments.normalize || arguments.canonicalize {
+			fmt.Fprintln(os.Stderr, "--output-openapi-version can't be used with --target postman, --normalize or --canonicalize")
+			getopt.PrintUsage(os.Stderr)
+			os.Exit(1)
+		}
+
+		if !slices.Contains(info.InputVersions, arguments.outputVersion) {
+			fmt.Fprintf(
+				os.Stderr,
+				"Invalid output version %s for %s, use one of %s\n",
+				arguments.outputVersion,
+				info.Name,
+				strings.Join(info.InputVersions, ", "),
+			)
+			getopt.PrintUsage(os.Stderr)
+			os.Exit(1)
+		}
+	}
+
+	if arguments.infoSummary && arguments.outputTarget == OpenAPI31 {
+		fmt.Fprintln(os.Stderr, "--preserve-info-summary can only be used when downgrading to 3.0 or swagger")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.nullableEnums && (arguments.outputTarget != OpenAPI30 || arguments.postman) {
+		fmt.Fprintln(os.Stderr, "--polyfill-nullable-enum can only be used with --target 3.0")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.flattenOneOf && (arguments.outputTarget != OpenAPI30 || arguments.postman) {
+		fmt.Fprintln(os.Stderr, "--flatten-nullable-oneof can only be used with --target 3.0")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.dropUnsupported && arguments.outputTarget != OpenAPI30 {
+		fmt.Fprintln(os.Stderr, "--drop-unsupported can only be used with --target 3.0")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	// Dropping unsupported keywords means there's nothing left to fail on.
+	if arguments.dropUnsupported && arguments.failUnknownKeys {
+		fmt.Fprintln(os.Stderr, "--drop-unsupported can't be used with --fail-unknown-keywords")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if len(*schemaNameCase) > 0 {
+		if nameCase, ok := nameCaseNames[strings.ToLower(*schemaNameCase)]; ok {
+			arguments.schemaNameCase = nameCase
+		} else {
+			fmt.Fprintf(os.Stderr, "Invalid schema name case: %s\n", *schemaNameCase)
+			getopt.PrintUsage(os.Stderr)
+			os.Exit(1)
+		}
+
+		if !arguments.dedupeSchemas && !arguments.sanitizeNames {
+			fmt.Fprintln(os.Stderr, "--schema-name-case can only be used with --dedupe-schemas or --sanitize-names")
+			getopt.PrintUsage(os.Stderr)
+			os.Exit(1)
+		}
+	}
+
+	if len(arguments.componentPrefix) > 0 && !arguments.dedupeSchemas {
+		fmt.Fprintln(os.Stderr, "--components-prefix can only be used with --dedupe-schemas")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	// Component names must match ^[a-zA-Z0-9.\-_]+$ in OpenAPI 3.x.
+	if strings.ContainsFunc(arguments.componentPrefix, func(r rune) bool {
+		return !strings.ContainsRune(componentNameCharacters, r)
+	}) {
+		fmt.Fprintf(os.Stderr, "Invalid components prefix: %s, use only letters, digits, '.', '-' and '_'\n", arguments.componentPrefix)
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.flattenAllOf && arguments.outputTarget != Swagger {
+		fmt.Fprintln(os.Stderr, "--flatten-allof can only be used with --target swagger")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.sanitizeNames && arguments.outputTarget != Swagger {
+		fmt.Fprintln(os.Stderr, "--sanitize-names can only be used with --target swagger")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.singleConsumes && arguments.outputTarget != Swagger {
+		fmt.Fprintln(os.Stderr, "--single-consumes can only be used with --target swagger")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if len(arguments.defaultProduces) > 0 && arguments.outputTarget != Swagger {
+		fmt.Fprintln(os.Stderr, "--produces-default can only be used with --target swagger")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if len(arguments.defaultProduces) > 0 && !strings.Contains(arguments.defaultProduces, "/") {
+		fmt.Fprintf(os.Stderr, "Invalid media type: %s, e.g. application/json\n", arguments.defaultProduces)
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.inlineResponses && arguments.outputTarget != Swagger {
+		fmt.Fprintln(os.Stderr, "--inline-response-refs can only be used with --target swagger")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.serverDesc && arguments.outputTarget != Swagger {
+		fmt.Fprintln(os.Stderr, "--keep-server-description can only be used with --target swagger")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if (len(arguments.swaggerHost) > 0 || len(arguments.basePath) > 0 || len(*schemes) > 0) && arguments.outputTarget != Swagger {
+		fmt.Fprintln(os.Stderr, "--host, --base-path and --schemes can only be used with --target swagger")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if strings.Contains(arguments.swaggerHost, "/") {
+		fmt.Fprintf(os.Stderr, "Invalid host: %s, the host can't include a scheme or path\n", arguments.swaggerHost)
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if len(arguments.basePath) > 0 && !strings.HasPrefix(arguments.basePath, "/") {
+		fmt.Fprintf(os.Stderr, "Invalid base path: %s, the base path must start with /\n", arguments.basePath)
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if len(*schemes) > 0 {
+		if parsed, err := parseSchemes(*schemes); err == nil {
+			arguments.schemes = parsed
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+			getopt.PrintUsage(os.Stderr)
+			os.Exit(1)
+		}
+	}
+
+	if arguments.nullableAnyOf && arguments.outputTarget != OpenAPI31 {
+		fmt.Fprintln(os.Stderr, "--modern-nullable-anyof can only be used with --target 3.1")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.intBounds && arguments.outputTarget != OpenAPI31 {
+		fmt.Fprintln(os.Stderr, "--upgrade-int-formats can only be used with --target 3.1")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.defaultExamples && arguments.outputTarget != OpenAPI31 {
+		fmt.Fprintln(os.Stderr, "--examples-from-default can only be used with --target 3.1")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	switch strings.ToLower(*logFormat) {
+	case "text":
+	case "json":
+		arguments.logJSON = true
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid log format: %s\n", *logFormat)
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
 	switch strings.ToLower(*outputFormat) {
 	case "json":
 		arguments.outputFormat = JSON
@@ -130,17 +597,83 @@ func parseArgs() Arguments {
 		os.Exit(1)
 	}
 
+	if arguments.postman && arguments.outputFormat != JSON {
+		fmt.Fprintln(os.Stderr, "--target postman can only output JSON")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.keepExamples && arguments.outputFormat != YAML {
+		fmt.Fprintln(os.Stderr, "--preserve-examples-format can only be used with --format yaml")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.yamlExplicit && arguments.outputFormat != YAML {
+		fmt.Fprintln(os.Stderr, "--yaml-explicit can only be used with --format yaml")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.jsonl && len(arguments.reportFile) > 0 {
+		fmt.Fprintln(os.Stderr, "--report describes a single document and can't be used with --jsonl")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.jsonl && arguments.outputFormat != JSON {
+		fmt.Fprintln(os.Stderr, "--jsonl can only output JSON")
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if len(*assumedVersion) > 0 {
+		if version, ok := targetSpecVersion(*assumedVersion); ok {
+			arguments.assumedVersion = &version
+		} else {
+			fmt.Fprintf(os.Stderr, "Invalid assumed version %s\n", *assumedVersion)
+			getopt.PrintUsage(os.Stderr)
+			os.Exit(1)
+		}
+	}
+
+	switch strings.ToLower(*inputFormat) {
+	case "":
+	case "json":
+		format := JSON
+		arguments.inputFormat = &format
+	case "yaml":
+		format := YAML
+		arguments.inputFormat = &format
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid input format: %s\n", *inputFormat)
+		getopt.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
 	return arguments
 }
 
 // readInputFile 根据参数读取输入文件内容。
 // 输入源：
+//   - 如果设置了 --input-env，则读取该环境变量的值，环境变量没有设置时返回错误
 //   - 如果 arguments.inputFilename == "-"，则从标准输入（os.Stdin）读取
 //   - 否则从指定文件路径读取
 //
+// 注意：命名管道和 /dev/fd 路径（例如 shell 的进程替换 <(...)）同样由 os.ReadFile 读取，
+// os.ReadFile 会一直读到写入端关闭，不依赖文件大小，所以不会截断或提前结束
+//
 // 返回：文件内容的字节数组和可能的错误
 func readInputFile(arguments Arguments) (inputData []byte, err error) {
-	if arguments.inputFilename == "-" {
+	if len(arguments.inputEnv) > 0 {
+		value, ok := os.LookupEnv(arguments.inputEnv)
+
+		if !ok {
+			return nil, fmt.Errorf("the environment variable %s is not set", arguments.inputEnv)
+		}
+
+		inputData = []byte(value)
+	} else if arguments.inputFilename == "-" {
 		inputData, err = io.ReadAll(os.Stdin)
 	} else {
 		inputData, err = os.ReadFile(arguments.inputFilename)
@@ -149,11 +682,131 @@ func readInputFile(arguments Arguments) (inputData []byte, err error) {
 	return
 }
 
+// writeOutputFile 将 data 写入 arguments.outputFilename，设置了 --chmod 时使用指定的权限。
+// 注意：
+//   - 没有设置 --chmod 时，新文件使用 0644（受 umask 影响），已经存在的文件保持原来的权限
+//   - os.OpenFile 只在创建文件时使用权限，并且受 umask 影响，所以设置了 --chmod 时写入后再调用 os.Chmod
+//   - 设置了 --no-clobber 时使用 O_EXCL 创建文件，文件已经存在时返回错误，检查和创建之间不会有竞争
+func writeOutputFile(arguments Arguments, data []byte) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+
+	if arguments.noClobber {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+
+	mode := os.FileMode(0644)
+
+	if arguments.outputMode != nil {
+		mode = *arguments.outputMode
+	}
+
+	file, err := os.OpenFile(arguments.outputFilename, flags, mode)
+
+	if errors.Is(err, fs.ErrExist) && arguments.noClobber {
+		return fmt.Errorf("%s already exists, not overwriting it with --no-clobber", arguments.outputFilename)
+	} else if err != nil {
+		return err
+	}
+
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	if arguments.outputMode == nil {
+		return nil
+	}
+
+	return os.Chmod(arguments.outputFilename, *arguments.outputMode)
+}
+
+// checkInputFormat 检查输入数据能否按 --input-format 强制指定的格式解析，没有指定格式时不做检查。
+// 注意：JSON 是合法的 YAML，所以强制使用 yaml 时 JSON 输入同样可以解析
+func checkInputFormat(data []byte, arguments Arguments) error {
+	if arguments.inputFormat == nil {
+		return nil
+	}
+
+	var value any
+
+	if *arguments.inputFormat == JSON {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("Input is not valid JSON: %w", err)
+		}
+	} else if err := yaml.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("Input is not valid YAML: %w", err)
+	}
+
+	return nil
+}
+
+// dedupeRequiredProperties 移除 schema.Required 中重复的属性名称，保留第一次出现的顺序。
+// 映射关系：{required: ["id", "name", "id"]} -> {required: ["id", "name"]}
+// 原因：一些生成器会输出重复的 required 属性，这会导致校验警告
+func dedupeRequiredProperties(schema *base.Schema) {
+	if len(schema.Required) < 2 {
+		return
+	}
+
+	seen := make(map[string]bool, len(schema.Required))
+	required := make([]string, 0, len(schema.Required))
+
+	for _, propName := range schema.Required {
+		if !seen[propName] {
+			seen[propName] = true
+			required = append(required, propName)
+		}
+	}
+
+	if len(required) != len(schema.Required) {
+		schema.Required = required
+	}
+}
+
+// dedupeRequiredPropertyNodes 与 dedupeRequiredProperties 相同，移除文档中所有 schema 节点的 required 中重复的属性名称。
+// 原因：Swagger 2.0 到 OpenAPI 3.0 由 kin-openapi 转换，不经过 libopenapi 的 schema 遍历，所以在转换之前修改 Swagger 文档
+//
+// 返回：是否修改了文档
+func dedupeRequiredPropertyNodes(root *yaml.Node) bool {
+	changed := false
+
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		required := mappingValue(schema, "required")
+
+		if required == nil || required.Kind != yaml.SequenceNode {
+			return true
+		}
+
+		seen := map[string]bool{}
+		names := required.Content[:0]
+
+		for _, name := range required.Content {
+			if !seen[name.Value] {
+				seen[name.Value] = true
+				names = append(names, name)
+			}
+		}
+
+		changed = changed || len(names) != len(required.Content)
+		required.Content = names
+
+		return true
+	})
+
+	return changed
+}
+
 // make30RequiredAndReadonlyPropertiesOnlyReadonly 处理 OpenAPI 3.0 到 Swagger 2.0 转换时的特殊规则：
 // 如果一个属性既是 required（必需）又是 readonly（只读），则从 required 列表中移除，只保留 readonly 标记。
 // 这是因为 Swagger 2.0 规范不允许 required 属性同时是 readonly。
-// 映射关系：schema.Required[] -> 过滤后的 schema.Required[]（移除所有 readonly 属性）
+// 映射关系：schema.Required[] -> 过滤后的 schema.Required[]（移除所有 readonly 属性和重复的属性，保留原有顺序）
 func make30RequiredAndReadonlyPropertiesOnlyReadonly(schema *base.Schema) {
+	dedupeRequiredProperties(schema)
+
 	if schema.Properties != nil && len(schema.Required) > 0 {
 		newRequired := []string{}
 
@@ -177,16 +830,45 @@ func make30RequiredAndReadonlyPropertiesOnlyReadonly(schema *base.Schema) {
 	}
 }
 
+// resolve30ReadOnlyAndWriteOnlyForSwagger 处理 readOnly 和 writeOnly 同时为 true 的 schema，并记录警告。
+// 映射关系：{readOnly: true, writeOnly: true} -> {readOnly: true}
+//
+// 原因：同时为 true 的属性既不能出现在请求中，也不能出现在响应中，Swagger 2.0 只有 readOnly，因此保留 readOnly，
+// 并且属性会像其它 readOnly 属性一样从 required 中移除（见 make30RequiredAndReadonlyPropertiesOnlyReadonly）
+//
+// 参数 pointers 是 nodeJSONPointers 为文档生成的节点路径，用于生成警告路径
+func (converter *Converter) resolve30ReadOnlyAndWriteOnlyForSwagger(schema *base.Schema, pointers map[*yaml.Node]string) {
+	if schema.ReadOnly == nil || !*schema.ReadOnly || schema.WriteOnly == nil || !*schema.WriteOnly {
+		return
+	}
+
+	schema.WriteOnly = nil
+
+	if schema.GoLow() == nil {
+		return
+	}
+
+	if pointer, ok := pointers[schema.GoLow().RootNode]; ok {
+		converter.warn(pointer, "readOnly and writeOnly are both true, keeping readOnly and removing writeOnly")
+	}
+}
+
 // convert30NullablesTo31TypeArrays 将 OpenAPI 3.0 的 nullable 字段映射到 OpenAPI 3.1 的 type 数组。
 // 映射关系：
 //   - OpenAPI 3.0: {type: "string", nullable: true} -> OpenAPI 3.1: {type: ["string", "null"]}
 //   - OpenAPI 3.0: {type: "string", nullable: false} -> OpenAPI 3.1: {type: ["string"]}（nullable 字段被移除）
+//   - OpenAPI 3.0: {items: {...}, nullable: true}（没有 type）-> OpenAPI 3.1: {items: {...}}（nullable 字段被移除）
+//   - 错误的混合形式: {type: ["string", "null"], nullable: true} -> OpenAPI 3.1: {type: ["string", "null"]}（不会重复添加 "null"）
 //
 // 操作：将 schema.Nullable 的值转换为 schema.Type 数组中的 "null" 元素，然后清空 schema.Nullable
+//
+// 注意：
+//   - 没有 type 的 schema 本身就允许 null，如果添加 {type: ["null"]} 反而会只允许 null
+//   - 一些工具会输出 type 数组和 nullable 同时存在的 schema，转换对已经包含 "null" 的 type 数组不做修改
 func convert30NullablesTo31TypeArrays(schema *base.Schema) {
 	// Replace {type: T, nullable: true} with {type: [T, "null"]}, etc.
 	if schema.Nullable != nil {
-		if *schema.Nullable {
+		if *schema.Nullable && len(schema.Type) > 0 && !slices.Contains(schema.Type, "null") {
 			schema.Type = append(schema.Type, "null")
 		}
 
@@ -197,13 +879,45 @@ func convert30NullablesTo31TypeArrays(schema *base.Schema) {
 // convert31TypeArraysTo30 将 OpenAPI 3.1 的 type 数组映射回 OpenAPI 3.0 的 nullable 字段或 oneOf。
 // 映射关系：
 //   - OpenAPI 3.1: {type: ["string", "null"]} -> OpenAPI 3.0: {type: "string", nullable: true}
-//   - OpenAPI 3.1: {type: ["string", "integer", "null"]} -> OpenAPI 3.0: {oneOf: [{type: "string", nullable: true}, {type: "integer", nullable: true}]}
+//   - OpenAPI 3.1: {type: ["string", "integer", "null"]} -> OpenAPI 3.0: {oneOf: [{type: "string", nullable: true}, {type: "integer"}]}
 //   - OpenAPI 3.1: {type: ["string", "integer"]} -> OpenAPI 3.0: {oneOf: [{type: "string"}, {type: "integer"}]}
+//   - OpenAPI 3.1: {type: ["integer", "number"]} -> OpenAPI 3.0: {type: "number"}
+//   - OpenAPI 3.1: {type: ["string", "string", "null"]} -> OpenAPI 3.0: {type: "string", nullable: true}
 //
 // 操作：
+//   - 先删除 type 数组中重复的元素，保留第一次出现的顺序
 //   - 如果 type 数组包含 "null" 且只有两个元素，则转换为 {type: T, nullable: true}
-//   - 如果 type 数组有多个非 null 元素，则转换为 oneOf 结构
-func convert31TypeArraysTo30(schema *base.Schema) {
+//   - 如果 type 数组有多个非 null 元素，则转换为 oneOf 结构，包含 "null" 时只有第一个分支被标记为 nullable，
+//     因为 null 必须恰好匹配一个分支（与 collapse31NullBranchesTo30 相同）
+//   - 拆分为 oneOf 时，items 被移动到 {type: "array"} 分支中，因为 OpenAPI 3.0 要求 array 类型必须有 items
+//   - 拆分为 oneOf 时，format 被移动到它适用的类型的分支中（见 formatSchemaType），
+//     例如 {type: ["integer", "string"], format: "int64"} -> {oneOf: [{type: "integer", format: "int64"}, {type: "string"}]}
+//
+// 注意：number 已经包含 integer，所以同时包含两者时会先移除 "integer"，避免生成多余的 oneOf，并在 schema 的位置记录警告
+func (converter *Converter) convert31TypeArraysTo30(schema *base.Schema, pointers map[*yaml.Node]string) {
+	// Malformed documents can repeat types, which would create duplicate oneOf branches.
+	types := make([]string, 0, len(schema.Type))
+
+	for _, value := range schema.Type {
+		if !slices.Contains(types, value) {
+			types = append(types, value)
+		}
+	}
+
+	if len(schema.Type) > 0 {
+		schema.Type = types
+	}
+
+	if slices.Contains(schema.Type, "number") && slices.Contains(schema.Type, "integer") {
+		schema.Type = slices.DeleteFunc(schema.Type, func(value string) bool { return value == "integer" })
+
+		if schema.GoLow() != nil {
+			if pointer, ok := pointers[schema.GoLow().RootNode]; ok {
+				converter.warn(pointer, "folded integer into number, number already allows integers")
+			}
+		}
+	}
+
 	nullable := false
 	nonNullType := ""
 
@@ -222,26 +936,386 @@ func convert31TypeArraysTo30(schema *base.Schema) {
 		schema.Nullable = &nullable
 	} else if len(schema.Type) >= 2 {
 		// In case of 2 or more non-null values, set them in oneOf
-		// if "null" was one of the values then all values will be nullable.
+		// if "null" was one of the values then the first value will be nullable,
+		// as null may only match one branch of a oneOf.
 		schema.OneOf = make([]*base.SchemaProxy, 0, len(schema.Type))
+		formatType := formatSchemaType(schema.Format, schema.Type)
 
 		for _, value := range schema.Type {
 			if value != "null" {
 				newSchema := base.Schema{Type: []string{value}}
 
-				if nullable {
+				if nullable && len(schema.OneOf) == 0 {
 					newSchema.Nullable = &nullable
 				}
 
+				// Formats only apply to one of the types, e.g. int64 to integers.
+				if value == formatType {
+					newSchema.Format = schema.Format
+				}
+
+				// Array schemas need their items in 3.0, and items only apply to arrays.
+				if value == "array" && schema.Items != nil {
+					newSchema.Items = schema.Items
+				}
+
 				schema.OneOf = append(schema.OneOf, base.CreateSchemaProxy(&newSchema))
 			}
 		}
 
+		// The items were moved into the array branch above.
+		if slices.Contains(schema.Type, "array") {
+			schema.Items = nil
+		}
+
+		// So was the format, unless none of the types could use it.
+		if slices.Contains(schema.Type, formatType) {
+			schema.Format = ""
+		}
+
 		// Clear the type field.
 		schema.Type = nil
 	}
 }
 
+// formatSchemaType 返回 format 适用的类型，用于将 type 数组拆分为 oneOf 时选择保留 format 的分支。
+// 映射关系：
+//   - "int32"、"int64" -> "integer"（types 中没有 integer 时为 "number"）
+//   - "float"、"double" -> "number"
+//   - 其他 format（例如 "date-time"、"uuid"）-> "string"
+//   - "" -> ""
+func formatSchemaType(format string, types []string) string {
+	switch format {
+	case "":
+		return ""
+	case "int32", "int64":
+		if slices.Contains(types, "integer") {
+			return "integer"
+		}
+
+		return "number"
+	case "float", "double":
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// isNullTypeSchema 判断 schema 是否为 inline 的 {type: "null"} 分支。
+func isNullTypeSchema(proxy *base.SchemaProxy) bool {
+	if proxy == nil || proxy.IsReference() {
+		return false
+	}
+
+	schema := proxy.Schema()
+
+	return schema != nil && len(schema.Type) == 1 && schema.Type[0] == "null"
+}
+
+// makeNullableBranch 返回一个可以为 null 的 OpenAPI 3.0 分支。
+// 映射关系：
+//   - inline schema: {type: T} -> {type: T, nullable: true}
+//   - 引用: {$ref: R} -> {allOf: [{$ref: R}], nullable: true}（$ref 旁边的字段在 OpenAPI 3.0 中会被忽略）
+func makeNullableBranch(proxy *base.SchemaProxy) *base.SchemaProxy {
+	nullable := true
+
+	if proxy.IsReference() {
+		return base.CreateSchemaProxy(&base.Schema{
+			AllOf:    []*base.SchemaProxy{proxy},
+			Nullable: &nullable,
+		})
+	}
+
+	proxy.Schema().Nullable = &nullable
+
+	return proxy
+}
+
+// collapse31NullBranchesTo30 将 OpenAPI 3.1 中 anyOf/oneOf 的 {type: "null"} 分支映射为 OpenAPI 3.0 的 nullable 字段。
+// 映射关系：
+//   - OpenAPI 3.1: {anyOf: [{$ref: R}, {type: "null"}]} -> OpenAPI 3.0: {allOf: [{$ref: R}], nullable: true}
+//   - OpenAPI 3.1: {anyOf: [{type: T}, {type: "null"}]} -> OpenAPI 3.0: {allOf: [{type: T, nullable: true}]}
+//   - OpenAPI 3.1: {anyOf: [X, Y, {type: "null"}]} -> OpenAPI 3.0: {anyOf: [X + nullable, Y + nullable]}
+//   - OpenAPI 3.1: {oneOf: [X, Y, {type: "null"}]} -> OpenAPI 3.0: {oneOf: [X + nullable, Y]}
+//
+// 操作：
+//   - 移除 {type: "null"} 分支
+//   - 只剩一个分支时，将它移动到 allOf 中（schema 本身已经有 allOf 时保留 anyOf/oneOf）
+//   - anyOf 中其余的分支都被标记为 nullable
+//   - oneOf 中只有第一个分支被标记为 nullable，因为 null 必须恰好匹配一个分支，否则 oneOf 会拒绝 null
+//
+// 注意：子 schema 先于父 schema 被转换，所以分支中的 type 数组此时已经被 convert31TypeArraysTo30 转换
+func collapse31NullBranchesTo30(schema *base.Schema) {
+	collapse := func(branches []*base.SchemaProxy, exclusive bool) []*base.SchemaProxy {
+		if !slices.ContainsFunc(branches, isNullTypeSchema) {
+			return branches
+		}
+
+		remaining := make([]*base.SchemaProxy, 0, len(branches))
+
+		for _, branch := range branches {
+			if isNullTypeSchema(branch) {
+				continue
+			}
+
+			// null may only match one branch of a oneOf.
+			if !exclusive || len(remaining) == 0 {
+				branch = makeNullableBranch(branch)
+			}
+
+			remaining = append(remaining, branch)
+		}
+
+		return remaining
+	}
+
+	// A single remaining branch is the same as {allOf: [branch]}, which is
+	// how 3.0 documents usually write a nullable reference.
+	single := func(branches []*base.SchemaProxy) bool {
+		if len(branches) != 2 || len(schema.AllOf) > 0 || !slices.ContainsFunc(branches, isNullTypeSchema) {
+			return false
+		}
+
+		nullable := true
+
+		for _, branch := range branches {
+			if !isNullTypeSchema(branch) {
+				if branch.IsReference() {
+					schema.Nullable = &nullable
+				} else {
+					branch.Schema().Nullable = &nullable
+				}
+
+				schema.AllOf = []*base.SchemaProxy{branch}
+			}
+		}
+
+		return len(schema.AllOf) == 1
+	}
+
+	if single(schema.AnyOf) {
+		schema.AnyOf = nil
+	} else {
+		schema.AnyOf = collapse(schema.AnyOf, false)
+	}
+
+	if single(schema.OneOf) {
+		schema.OneOf = nil
+	} else {
+		schema.OneOf = collapse(schema.OneOf, true)
+	}
+}
+
+// convert30NullableRefsTo31AnyOf 将 OpenAPI 3.0 中与 $ref 同级的 nullable 映射为 OpenAPI 3.1 的 anyOf。
+// 映射关系：
+//   - OpenAPI 3.0: {$ref: "#/components/schemas/Foo", nullable: true}
+//     -> OpenAPI 3.1: {anyOf: [{$ref: "#/components/schemas/Foo"}, {type: "null"}]}
+//   - OpenAPI 3.0: {allOf: [{$ref: "#/components/schemas/Foo"}], nullable: true}（没有 type）
+//     -> OpenAPI 3.1: {anyOf: [{$ref: "#/components/schemas/Foo"}, {type: "null"}]}
+//   - OpenAPI 3.0: {$ref: "#/components/schemas/Foo", nullable: false} -> OpenAPI 3.1: {$ref: "#/components/schemas/Foo"}
+//
+// 操作：直接修改 YAML 节点树，$ref 的其他同级字段（例如 description）保留在外层 schema 中
+// 原因：3.0 规范中 $ref 的同级字段会被忽略，但很多工具仍然这样表示可以为 null 的引用；
+// libopenapi 会原样输出引用节点，convert30NullablesTo31TypeArrays 也无法为没有 type 的 schema 添加 "null"；
+// 单个 $ref 的 allOf 是 OpenAPI 3.0 中表示可以为 null 的引用的有效写法（见 makeNullableBranch）
+//
+// 返回：是否修改了文档
+func convert30NullableRefsTo31AnyOf(root *yaml.Node) bool {
+	changed := false
+
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		ref := mappingValue(schema, "$ref")
+		nullable := mappingValue(schema, "nullable")
+
+		// Unwrap {allOf: [{$ref: R}], nullable: true}, as written for 3.0 by makeNullableBranch.
+		if allOf := mappingValue(schema, "allOf"); ref == nil && nullable != nil && nullable.Value == "true" &&
+			mappingValue(schema, "type") == nil && allOf != nil && allOf.Kind == yaml.SequenceNode && len(allOf.Content) == 1 &&
+			len(allOf.Content[0].Content) == 2 && mappingValue(allOf.Content[0], "$ref") != nil {
+			ref = mappingValue(allOf.Content[0], "$ref")
+			deleteMappingKey(schema, "allOf")
+		}
+
+		if ref == nil || nullable == nil {
+			return true
+		}
+
+		deleteMappingKey(schema, "nullable")
+		changed = true
+
+		if nullable.Value == "true" {
+			deleteMappingKey(schema, "$ref")
+
+			nullSchema := newMappingNode()
+			setMappingValue(nullSchema, "type", newStringNode("null"))
+			anyOf := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{newRefNode(ref.Value), nullSchema}}
+			schema.Content = append([]*yaml.Node{newStringNode("anyOf"), anyOf}, schema.Content...)
+		}
+
+		return false
+	})
+
+	return changed
+}
+
+// convertSwaggerXNullableRefs 将 Swagger 2.0 中与 $ref 同级的 x-nullable 改写为 kin-openapi 可以转换的形式。
+// 映射关系：
+//   - Swagger 2.0: {$ref: "#/definitions/Foo", x-nullable: true} -> {allOf: [{$ref: "#/definitions/Foo"}], x-nullable: true}
+//     -> 由 kin-openapi 转换为 OpenAPI 3.0: {allOf: [{$ref: "#/components/schemas/Foo"}], nullable: true}
+//   - Swagger 2.0: {$ref: "#/definitions/Foo", x-nullable: false} -> {$ref: "#/definitions/Foo"}
+//
+// 原因：go-swagger 等生成器使用 x-nullable 表示可以为 null 的 schema，kin-openapi 会将 x-nullable 转换为 nullable，
+// 但是会丢弃 $ref 的所有同级字段
+//
+// 返回：是否修改了文档
+func convertSwaggerXNullableRefs(root *yaml.Node) bool {
+	changed := false
+
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		ref := mappingValue(schema, "$ref")
+		nullable := mappingValue(schema, "x-nullable")
+
+		if ref == nil || nullable == nil {
+			return true
+		}
+
+		deleteMappingKey(schema, "x-nullable")
+		changed = true
+
+		if nullable.Value == "true" {
+			deleteMappingKey(schema, "$ref")
+
+			allOf := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{newRefNode(ref.Value)}}
+			schema.Content = append([]*yaml.Node{newStringNode("allOf"), allOf, newStringNode("x-nullable"), nullable}, schema.Content...)
+		}
+
+		return false
+	})
+
+	return changed
+}
+
+// setSwaggerParameterNullablesFor30 将 Swagger 2.0 非 body 参数的 x-nullable 映射为 OpenAPI 3.0 参数 schema 的 nullable。
+// 映射关系：
+//   - Swagger 2.0: {in: "query", type: "string", x-nullable: true}
+//     -> OpenAPI 3.0: {in: "query", schema: {type: "string", nullable: true}}
+//
+// 原因：kin-openapi 将非 body 参数的 type 等字段移到 schema 中，但 x-nullable 作为扩展留在参数上
+func setSwaggerParameterNullablesFor30(kinOpenAPIDoc *openapi3.T) {
+	setNullable := func(parameterRef *openapi3.ParameterRef) {
+		if parameterRef == nil || parameterRef.Ref != "" || parameterRef.Value == nil {
+			return
+		}
+
+		nullable, ok := parameterRef.Value.Extensions["x-nullable"].(bool)
+
+		if !ok || parameterRef.Value.Schema == nil || parameterRef.Value.Schema.Value == nil {
+			return
+		}
+
+		parameterRef.Value.Schema.Value.Nullable = nullable
+		delete(parameterRef.Value.Extensions, "x-nullable")
+	}
+
+	if kinOpenAPIDoc.Components != nil {
+		for _, parameterRef := range kinOpenAPIDoc.Components.Parameters {
+			setNullable(parameterRef)
+		}
+	}
+
+	if kinOpenAPIDoc.Paths == nil {
+		return
+	}
+
+	for _, pathItem := range kinOpenAPIDoc.Paths.Map() {
+		for _, parameterRef := range pathItem.Parameters {
+			setNullable(parameterRef)
+		}
+
+		for _, operation := range pathItem.Operations() {
+			for _, parameterRef := range operation.Parameters {
+				setNullable(parameterRef)
+			}
+		}
+	}
+}
+
+// convert30NullablesTo31AnyOf 将 OpenAPI 3.0 中有 type 的 nullable schema 映射为 OpenAPI 3.1 的 anyOf（--modern-nullable-anyof）。
+// 映射关系：
+//   - OpenAPI 3.0: {type: "string", maxLength: 10, nullable: true}
+//     -> OpenAPI 3.1: {anyOf: [{type: "string", maxLength: 10}, {type: "null"}]}
+//   - OpenAPI 3.0: {type: "string", nullable: false} -> OpenAPI 3.1: {type: "string"}
+//
+// 操作：直接修改 YAML 节点树，schema 的其他字段（包括 description 等）都移到 anyOf 的第一个分支中
+// 原因：一些 OpenAPI 3.1 工具只支持 anyOf 形式的 nullable，不支持 type 数组（convert30NullablesTo31TypeArrays）
+//
+// 注意：
+//   - 没有 type 的 schema 本身就允许 null，只会删除 nullable 字段
+//   - type 已经是包含 "null" 的数组时（错误的混合形式），同样只会删除 nullable 字段
+//
+// 返回：是否修改了文档
+func convert30NullablesTo31AnyOf(root *yaml.Node) bool {
+	changed := false
+
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		nullable := mappingValue(schema, "nullable")
+
+		if nullable == nil || mappingValue(schema, "$ref") != nil {
+			return true
+		}
+
+		deleteMappingKey(schema, "nullable")
+		changed = true
+
+		schemaType := mappingValue(schema, "type")
+
+		if schemaType != nil && schemaType.Kind == yaml.SequenceNode &&
+			slices.ContainsFunc(schemaType.Content, func(value *yaml.Node) bool { return value.Value == "null" }) {
+			return true
+		}
+
+		if nullable.Value == "true" && schemaType != nil {
+			branch := newMappingNode()
+			branch.Content = schema.Content
+
+			nullSchema := newMappingNode()
+			setMappingValue(nullSchema, "type", newStringNode("null"))
+			anyOf := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{branch, nullSchema}}
+			schema.Content = []*yaml.Node{newStringNode("anyOf"), anyOf}
+		}
+
+		return true
+	})
+
+	return changed
+}
+
+// polyfill30NullableEnums 为 OpenAPI 3.0 中 nullable 的 schema 的 enum 添加 null 成员（--polyfill-nullable-enum）。
+// 映射关系：
+//   - {type: "string", nullable: true, enum: ["cat", "dog"]} -> {type: "string", nullable: true, enum: ["cat", "dog", null]}
+//
+// 原因：3.0 规范没有说明 nullable 和 enum 同时存在时 null 是否有效，很多校验器要求 null 必须出现在 enum 中，
+// 从 3.1 的 {type: ["string", "null"], enum: [...]} 降级时添加 null 可以让 schema 接受与之前相同的值
+func polyfill30NullableEnums(root *yaml.Node) {
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		nullable := mappingValue(schema, "nullable")
+		enum := mappingValue(schema, "enum")
+
+		if nullable == nil || nullable.Value != "true" || enum == nil || enum.Kind != yaml.SequenceNode {
+			return true
+		}
+
+		hasNull := slices.ContainsFunc(enum.Content, func(value *yaml.Node) bool {
+			return value.Kind == yaml.ScalarNode && value.Tag == "!!null"
+		})
+
+		if !hasNull {
+			enum.Content = append(enum.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"})
+		}
+
+		return true
+	})
+}
+
 // convert30MinMaxTo31 将 OpenAPI 3.0 的 minimum/exclusiveMinimum 和 maximum/exclusiveMaximum 字段映射到 OpenAPI 3.1。
 // 映射关系：
 //   - OpenAPI 3.0: {minimum: 10, exclusiveMinimum: true} -> OpenAPI 3.1: {exclusiveMinimum: 10}（DynamicValue 的 B 字段存储数值）
@@ -309,30 +1383,268 @@ func convert31MinMaxTo30(schema *base.Schema) {
 	convert31ExclusiveBoundTo30(&schema.Maximum, &schema.ExclusiveMaximum)
 }
 
+// explicitNullExample 返回显式写出 null 的示例节点，其他示例原样返回。
+// 原因：libopenapi 将带有 !!null 标签的节点渲染为空值（例如 "example:"），看起来像缺少示例；
+// 不带标签的 "null" 标量会被渲染为 "null"，并且重新加载后仍然是 null
+func explicitNullExample(example *yaml.Node) *yaml.Node {
+	if example.Kind == yaml.ScalarNode && example.Tag == "!!null" {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: "null"}
+	}
+
+	return example
+}
+
 // convert30ExampleTo31Examples 将 OpenAPI 3.0 的 example 字段映射到 OpenAPI 3.1 的 examples 数组。
 // 映射关系：
 //   - OpenAPI 3.0: {example: value} -> OpenAPI 3.1: {examples: [value]}
+//   - OpenAPI 3.0: {example: null} -> OpenAPI 3.1: {examples: [null]}（显式的 null 与没有示例不同，"example: ~" 和 "example:" 同样处理）
 //
 // 操作：将 schema.Example 的值放入 schema.Examples 数组的第一个位置，然后清空 schema.Example
 func convert30ExampleTo31Examples(schema *base.Schema) {
 	if schema.Example != nil {
-		schema.Examples = []*yaml.Node{schema.Example}
+		schema.Examples = []*yaml.Node{explicitNullExample(schema.Example)}
 		schema.Example = nil
 	}
 }
 
+// warn31WebhooksDroppedFor30 在 OpenAPI 3.1 到 3.0 转换时，为被丢弃的 webhooks 记录一条警告，列出每个 webhook 的名称和操作数量。
+// 映射关系：
+//   - OpenAPI 3.1: {webhooks: {newPet: {post: ...}, petSold: {post: ..., put: ...}}}
+//     -> 警告 "dropped 2 webhooks with 3 operations, ...: newPet (1 operation), petSold (2 operations)"
+//
+// 原因：OpenAPI 3.0 没有 webhooks，转换时会丢弃整个 webhooks 字段，需要在丢弃之前统计，警告才是准确的
+func (converter *Converter) warn31WebhooksDroppedFor30(
+	model *libopenapi.DocumentModel[v3.Document],
+) {
+	if model.Model.Webhooks == nil || model.Model.Webhooks.Len() == 0 {
+		return
+	}
+
+	plural := func(count int, noun string) string {
+		if count == 1 {
+			return fmt.Sprintf("%d %s", count, noun)
+		}
+
+		return fmt.Sprintf("%d %ss", count, noun)
+	}
+
+	var webhooks []string
+	total := 0
+
+	for name, pathItem := range model.Model.Webhooks.FromOldest() {
+		count := 0
+
+		if pathItem != nil {
+			count = pathItem.GetOperations().Len()
+		}
+
+		total += count
+		webhooks = append(webhooks, fmt.Sprintf("%s (%s)", name, plural(count, "operation")))
+	}
+
+	converter.warn(
+		jsonPointer("webhooks"),
+		"dropped %s with %s, OpenAPI 3.0 has no webhooks: %s",
+		plural(len(webhooks), "webhook"),
+		plural(total, "operation"),
+		strings.Join(webhooks, ", "),
+	)
+}
+
+// merge31InfoSummaryIntoDescriptionFor30 在 OpenAPI 3.1 到 3.0 转换时，将 info.summary 添加到 info.description 的开头（--preserve-info-summary）。
+// 映射关系：
+//   - OpenAPI 3.1: {summary: "Pet store", description: "Manage pets."} -> OpenAPI 3.0: {description: "Pet store\n\nManage pets."}
+//   - OpenAPI 3.1: {summary: "Pet store"} -> OpenAPI 3.0: {description: "Pet store"}
+//
+// 原因：OpenAPI 3.0 的 Info Object 没有 summary 字段，默认会被丢弃
+func merge31InfoSummaryIntoDescriptionFor30(info *base.Info) {
+	if info == nil || info.Summary == "" {
+		return
+	}
+
+	if info.Description == "" {
+		info.Description = info.Summary
+	} else {
+		info.Description = info.Summary + "\n\n" + info.Description
+	}
+}
+
 // convert31ExamplesTo30Example 将 OpenAPI 3.1 的 examples 数组映射回 OpenAPI 3.0 的 example 字段。
 // 映射关系：
 //   - OpenAPI 3.1: {examples: [value1, value2, ...]} -> OpenAPI 3.0: {example: value1}（只取第一个）
+//   - OpenAPI 3.1: {examples: [null]} -> OpenAPI 3.0: {example: null}
 //
 // 操作：将 schema.Examples 数组的第一个元素赋值给 schema.Example，然后清空 schema.Examples
+//
+// 注意：kin-openapi 无法区分 null 示例和没有示例，转换为 Swagger 2.0 时 null 示例被丢弃
 func convert31ExamplesTo30Example(schema *base.Schema) {
 	if len(schema.Examples) >= 1 {
-		schema.Example = schema.Examples[0]
+		schema.Example = explicitNullExample(schema.Examples[0])
 		schema.Examples = nil
 	}
 }
 
+// take31KeyedSchemaExamples 处理一些工具错误输出的 {名称: 示例} 形式的 schema examples。
+// 映射关系：
+//   - OpenAPI 3.1: {examples: {cat: value1, dog: value2}} -> {examples: [value1]}（只取第一个，并记录警告）
+//
+// 操作：libopenapi 会忽略不是数组的 examples，因此从底层 YAML 节点中读取第一个示例放入 schema.Examples，
+// 然后由 convert31ExamplesTo30Example 转换为 example
+//
+// 参数 pointers 是 nodeJSONPointers 为文档生成的节点路径，用于生成警告路径
+func (converter *Converter) take31KeyedSchemaExamples(schema *base.Schema, pointers map[*yaml.Node]string) {
+	if schema.Example != nil || len(schema.Examples) > 0 || schema.GoLow() == nil {
+		return
+	}
+
+	schemaNode := schema.GoLow().RootNode
+	examples := mappingValue(schemaNode, "examples")
+
+	if examples == nil || examples.Kind != yaml.MappingNode || len(examples.Content) < 2 {
+		return
+	}
+
+	schema.Examples = []*yaml.Node{examples.Content[1]}
+
+	if pointer, ok := pointers[examples]; ok {
+		converter.warn(pointer, "schema examples should be an array, using the %q example", examples.Content[0].Value)
+	}
+}
+
+// warn31AdditionalItemsDroppedFor30 为 schema 中的 additionalItems 记录警告。
+// additionalItems 是 2020-12 之前的 JSON Schema 关键字，在 OpenAPI 3.1 中没有作用，在 OpenAPI 3.0 中也没有对应的关键字。
+//
+// 注意：libopenapi 的 schema 模型不包含 additionalItems，重新渲染文档时它会被删除，因此这里只从底层 YAML 节点中读取并记录警告
+//
+// 参数 pointers 是 nodeJSONPointers 为文档生成的节点路径，用于生成警告路径
+func (converter *Converter) warn31AdditionalItemsDroppedFor30(schema *base.Schema, pointers map[*yaml.Node]string) {
+	if schema.GoLow() == nil || mappingValue(schema.GoLow().RootNode, "additionalItems") == nil {
+		return
+	}
+
+	if pointer, ok := pointers[schema.GoLow().RootNode]; ok {
+		converter.warn(pointer+"/additionalItems", "additionalItems has no OpenAPI 3.0 equivalent and was removed")
+	}
+}
+
+// convert31PrefixItemsTo30 将 OpenAPI 3.1 的 prefixItems（元组）映射为 OpenAPI 3.0 的 items，并记录警告。
+// 映射关系：
+//   - OpenAPI 3.1: {prefixItems: [A, B]} -> OpenAPI 3.0: {items: {anyOf: [A, B]}}
+//   - OpenAPI 3.1: {prefixItems: [A, B], items: C} -> OpenAPI 3.0: {items: {anyOf: [A, B, C]}}
+//   - OpenAPI 3.1: {prefixItems: [A]} -> OpenAPI 3.0: {items: A}
+//
+// 注意：元素的位置信息会丢失，每个元素都可以匹配任意一个 schema
+//
+// 参数 pointers 是 nodeJSONPointers 为文档生成的节点路径，用于生成警告路径
+func (converter *Converter) convert31PrefixItemsTo30(schema *base.Schema, pointers map[*yaml.Node]string) {
+	if len(schema.PrefixItems) == 0 {
+		return
+	}
+
+	itemSchemas := schema.PrefixItems
+
+	if schema.Items != nil && schema.Items.IsA() && schema.Items.A != nil {
+		itemSchemas = append(itemSchemas, schema.Items.A)
+	}
+
+	items := itemSchemas[0]
+
+	if len(itemSchemas) > 1 {
+		items = base.CreateSchemaProxy(&base.Schema{AnyOf: itemSchemas})
+	}
+
+	schema.Items = &base.DynamicValue[*base.SchemaProxy, bool]{A: items}
+	schema.PrefixItems = nil
+
+	if schema.GoLow() == nil {
+		return
+	}
+
+	if pointer, ok := pointers[schema.GoLow().RootNode]; ok {
+		converter.warn(pointer+"/prefixItems", "prefixItems has no OpenAPI 3.0 equivalent, items allows any of the tuple schemas")
+	}
+}
+
+// convert31ConditionalsTo30 将 OpenAPI 3.1 的 if/then/else 条件 schema 改写为 OpenAPI 3.0 支持的 oneOf 和 not，并记录警告。
+// 映射关系：
+//   - OpenAPI 3.1: {if: I, then: T, else: E} -> OpenAPI 3.0: {allOf: [{oneOf: [{allOf: [I, T]}, {allOf: [{not: I}, E]}]}]}
+//   - OpenAPI 3.1: {if: I, then: T} -> OpenAPI 3.0: {allOf: [{oneOf: [{allOf: [I, T]}, {not: I}]}]}
+//   - OpenAPI 3.1: {if: I, else: E} -> OpenAPI 3.0: {allOf: [{oneOf: [I, {allOf: [{not: I}, E]}]}]}
+//   - OpenAPI 3.1: {if: I} 或没有 if 的 then/else -> 没有作用，被删除
+//
+// 原因：OpenAPI 3.0 的 Schema Object 不支持 if/then/else，两个分支互斥，所以 oneOf 与条件 schema 的含义相同
+//
+// 注意：
+//   - 改写的 schema 被添加到 allOf 中，不会与 schema 中已有的 oneOf 冲突
+//   - 一些代码生成工具不支持 not，生成的类型可能不如原来的 schema 精确
+//
+// 参数 pointers 是 nodeJSONPointers 为文档生成的节点路径，用于生成警告路径
+func (converter *Converter) convert31ConditionalsTo30(schema *base.Schema, pointers map[*yaml.Node]string) {
+	if schema.If == nil && schema.Then == nil && schema.Else == nil {
+		return
+	}
+
+	pointer, hasPointer := "", false
+
+	if schema.GoLow() != nil {
+		pointer, hasPointer = pointers[schema.GoLow().RootNode]
+	}
+
+	if schema.If != nil && (schema.Then != nil || schema.Else != nil) {
+		var branches []*base.SchemaProxy
+
+		for _, branch := range [][]*base.SchemaProxy{
+			{schema.If, schema.Then},
+			{base.CreateSchemaProxy(&base.Schema{Not: schema.If}), schema.Else},
+		} {
+			if branch[1] == nil {
+				branches = append(branches, branch[0])
+			} else {
+				branches = append(branches, base.CreateSchemaProxy(&base.Schema{AllOf: branch}))
+			}
+		}
+
+		schema.AllOf = append(schema.AllOf, base.CreateSchemaProxy(&base.Schema{OneOf: branches}))
+
+		if hasPointer {
+			converter.warn(pointer+"/if", "if/then/else has no OpenAPI 3.0 equivalent, it was rewritten with oneOf and not")
+		}
+	} else if hasPointer {
+		converter.warn(pointer, "if/then/else without both a condition and a branch has no effect and was removed")
+	}
+
+	schema.If = nil
+	schema.Then = nil
+	schema.Else = nil
+}
+
+// remove31ContainsFor30 删除 OpenAPI 3.1 的 contains、minContains 和 maxContains，并为每个关键字记录警告。
+// 映射关系：
+//   - OpenAPI 3.1: {type: "array", contains: {...}, minContains: 1, maxContains: 2} -> OpenAPI 3.0: {type: "array"}
+//
+// 原因：OpenAPI 3.0 的 Schema Object 不支持这些关键字，也无法用 3.0 的关键字表达"至少一个元素匹配"
+//
+// 参数 pointers 是 nodeJSONPointers 为文档生成的节点路径，用于生成警告路径
+func (converter *Converter) remove31ContainsFor30(schema *base.Schema, pointers map[*yaml.Node]string) {
+	if schema.Contains == nil && schema.MinContains == nil && schema.MaxContains == nil {
+		return
+	}
+
+	if schema.GoLow() != nil {
+		if pointer, ok := pointers[schema.GoLow().RootNode]; ok {
+			for _, keyword := range []string{"contains", "minContains", "maxContains"} {
+				if mappingValue(schema.GoLow().RootNode, keyword) != nil {
+					converter.warn(pointer+"/"+keyword, "%s has no OpenAPI 3.0 equivalent and was removed", keyword)
+				}
+			}
+		}
+	}
+
+	schema.Contains = nil
+	schema.MinContains = nil
+	schema.MaxContains = nil
+}
+
 // convert30FormatsTo31ContentFields 将 OpenAPI 3.0 的 format 字段映射到 OpenAPI 3.1 的 contentMediaType 和 contentEncoding 字段。
 // 映射关系：
 //   - OpenAPI 3.0: {type: "string", format: "binary"} -> OpenAPI 3.1: {type: "string", contentMediaType: "base64"}
@@ -380,6 +1692,7 @@ func convert30FormatsTo31ContentFields(schema *base.Schema) {
 //   - 将 lowSchema.ContentMediaType = "application/octet-stream" 映射到 schema.Format = "binary"
 //   - 将 lowSchema.ContentEncoding = "base64" 映射到 schema.Format = "base64"
 //   - 清空 lowSchema.ContentMediaType 和 lowSchema.ContentEncoding 字段
+//   - 如果 schema 已经有 format（例如 {format: "date-time", contentEncoding: "base64"}），则保留原有的 format
 //
 // 注意：此函数需要访问底层 low schema 来读取 contentMediaType 和 contentEncoding
 func convert31ContentFieldsTo30Formats(schema *base.Schema) {
@@ -388,7 +1701,7 @@ func convert31ContentFieldsTo30Formats(schema *base.Schema) {
 
 		if lowSchema != nil {
 			if len(lowSchema.ContentMediaType.Value) > 0 {
-				if lowSchema.ContentMediaType.Value == "application/octet-stream" {
+				if lowSchema.ContentMediaType.Value == "application/octet-stream" && schema.Format == "" {
 					schema.Format = "binary"
 				}
 
@@ -396,7 +1709,8 @@ func convert31ContentFieldsTo30Formats(schema *base.Schema) {
 			}
 
 			if len(lowSchema.ContentEncoding.Value) > 0 {
-				if lowSchema.ContentEncoding.Value == "base64" {
+				// Don't clobber a meaningful format such as `date-time` with the encoding.
+				if lowSchema.ContentEncoding.Value == "base64" && schema.Format == "" {
 					schema.Format = "base64"
 				}
 
@@ -410,12 +1724,19 @@ func convert31ContentFieldsTo30Formats(schema *base.Schema) {
 // 遍历路径：
 //  1. schema.Properties -> 每个属性的 schema
 //  2. schema.Items -> 数组元素的 schema
-//  3. schema.AllOf -> 所有组合的 schema
-//  4. schema.OneOf -> 任一组合的 schema
-//  5. schema.AnyOf -> 任意组合的 schema
-//  6. 最后更新当前 schema 本身
+//  3. schema.PrefixItems -> 元组中每个元素的 schema（OpenAPI 3.1）
+//  4. schema.AdditionalProperties -> map 值的 schema（additionalProperties 为 true/false 时没有子 schema）
+//  5. schema.AllOf -> 所有组合的 schema
+//  6. schema.OneOf -> 任一组合的 schema
+//  7. schema.AnyOf -> 任意组合的 schema
+//  8. schema.If / schema.Then / schema.Else -> 条件 schema（OpenAPI 3.1）
+//  9. schema.Contains -> 数组中至少一个元素需要匹配的 schema（OpenAPI 3.1）
+//  10. 最后更新当前 schema 本身
+//
+// 操作：对每个找到的 schema 递归调用 callback 函数进行转换，子 schema 先于父 schema 被转换
 //
-// 操作：对每个找到的 schema 调用 callback 函数进行转换
+// 注意：$ref 引用的子 schema 不会被遍历，它们在定义的位置（例如 components.schemas）被转换，
+// 这样每个 schema 只会被转换一次，并且循环引用不会导致无限递归
 func updateSchemaAndReferencedSchema(
 	schema *base.Schema,
 	callback func(schema *base.Schema),
@@ -425,33 +1746,56 @@ func updateSchemaAndReferencedSchema(
 		return
 	}
 
+	// Referenced schemas are updated where they are defined, which also
+	// stops us from recursing forever through circular references.
+	updateSubSchema := func(proxy *base.SchemaProxy) {
+		if proxy != nil && !proxy.IsReference() {
+			updateSchemaAndReferencedSchema(proxy.Schema(), callback)
+		}
+	}
+
 	// Handle schemas in properties.
 	if schema.Properties != nil {
 		for property := range schema.Properties.ValuesFromOldest() {
-			callback(property.Schema())
+			updateSubSchema(property)
 		}
 	}
 
 	// Handle items if the schema is an array.
 	if schema.Items != nil {
 		if schema.Items.IsA() {
-			callback(schema.Items.A.Schema())
+			updateSubSchema(schema.Items.A)
 		}
 	}
 
+	for _, subSchema := range schema.PrefixItems {
+		updateSubSchema(subSchema)
+	}
+
+	// Handle map values, e.g. {additionalProperties: {type: string, format: binary}}.
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.IsA() {
+		updateSubSchema(schema.AdditionalProperties.A)
+	}
+
 	// Process composite schemas: allOf, oneOf, and anyOf.
 	for _, subSchema := range schema.AllOf {
-		callback(subSchema.Schema())
+		updateSubSchema(subSchema)
 	}
 
 	for _, subSchema := range schema.OneOf {
-		callback(subSchema.Schema())
+		updateSubSchema(subSchema)
 	}
 
 	for _, subSchema := range schema.AnyOf {
-		callback(subSchema.Schema())
+		updateSubSchema(subSchema)
 	}
 
+	// Handle 3.1 conditionals, which are converted with their parent.
+	updateSubSchema(schema.If)
+	updateSubSchema(schema.Then)
+	updateSubSchema(schema.Else)
+	updateSubSchema(schema.Contains)
+
 	// Modify this schema last, so our changes to schema are final.
 	callback(schema)
 }
@@ -459,61 +1803,238 @@ func updateSchemaAndReferencedSchema(
 // updateAllSchema 在整个 OpenAPI 文档中查找所有 schema 并使用 callback 更新它们。
 // 查找位置：
 //  1. model.Model.Components.Schemas -> 组件中定义的 schema（全局可复用的 schema）
-//  2. model.Model.Components.Parameters -> 参数中的 schema（参数定义中的 schema）
-//  3. model.Model.Paths -> 路径操作中的 schema：
-//     a. operation.RequestBody.Content -> 请求体的 content 中的 schema
-//     b. operation.Responses.Codes -> 响应中的 content 中的 schema
+//  2. model.Model.Components.Parameters -> 参数中的 schema，以及使用 content 形式的参数的 content 中的 schema
+//  3. model.Model.Components.Headers -> header 中的 schema（encoding.headers 引用的 header 定义中的 schema）
+//  4. model.Model.Components.PathItems 和 model.Model.Components.Callbacks -> 与路径相同，查找其中的操作的 schema
+//  5. model.Model.Paths -> 路径操作中的 schema：
+//     a. pathItem.Parameters 和 operation.Parameters -> 路径和操作参数中的 schema（包括 content 形式）
+//     b. operation.RequestBody.Content -> 请求体的 content 中的 schema，以及 multipart 等 encoding.headers 中的 schema
+//     c. operation.Responses.Codes -> 响应中的 content 中的 schema
+//     d. operation.Callbacks -> 回调的路径中的操作的 schema
 //
 // 操作：对每个找到的 schema 调用 updateSchemaAndReferencedSchema 进行递归更新
+//
+// 注意：
+//   - 引用组件的参数、header、回调、路径和 schema（$ref）只在组件中被更新，每个 schema 只会被转换一次
+//   - 本身是 $ref 的组件 schema（例如 {PetAlias: {$ref: "#/components/schemas/Pet"}}）不会被更新，引用的 schema 在它定义的地方被更新
+//   - 需要内联引用组件的路径时（例如 inline31ComponentPathItemsFor30），必须在调用之前清除引用，路径才会被更新
+//   - 同时有 schema 和 content 的参数只更新 schema，content 不会被转换
 func updateAllSchema(
 	model *libopenapi.DocumentModel[v3.Document],
 	callback func(schema *base.Schema),
 ) {
+	// Referenced schemas are updated where they are defined, so they
+	// aren't converted twice.
+	updateSchema := func(proxy *base.SchemaProxy) {
+		if proxy != nil && !proxy.IsReference() {
+			updateSchemaAndReferencedSchema(proxy.Schema(), callback)
+		}
+	}
+
+	updateContent := func(content *orderedmap.Map[string, *v3.MediaType]) {
+		for mediaType := range content.ValuesFromOldest() {
+			updateSchema(mediaType.Schema)
+
+			for encoding := range mediaType.Encoding.ValuesFromOldest() {
+				for header := range encoding.Headers.ValuesFromOldest() {
+					// Referenced headers are updated in the components.
+					if header != nil && (header.GoLow() == nil || !header.GoLow().IsReference()) {
+						updateSchema(header.Schema)
+					}
+				}
+			}
+		}
+	}
+
+	updateParameters := func(parameters []*v3.Parameter) {
+		for _, parameter := range parameters {
+			// Referenced parameters are updated in the components.
+			if parameter == nil || (parameter.GoLow() != nil && parameter.GoLow().IsReference()) {
+				continue
+			}
+
+			// Invalid parameters with both forms keep the schema, see drop30ParameterContentWithSchema.
+			if parameter.Schema != nil {
+				updateSchema(parameter.Schema)
+			} else {
+				updateContent(parameter.Content)
+			}
+		}
+	}
+
+	// Path items referencing components.pathItems are updated in the
+	// components, unless those have been removed.
+	isComponentPathItemRef := func(pathItem *v3.PathItem) bool {
+		return model.Model.Components != nil && model.Model.Components.PathItems != nil &&
+			pathItem.GoLow() != nil && strings.HasPrefix(pathItem.GoLow().GetReference(), "#/components/pathItems/")
+	}
+
+	var updatePathItem func(pathItem *v3.PathItem)
+
+	updateCallback := func(operationCallback *v3.Callback) {
+		// Referenced callbacks are updated in the components.
+		if operationCallback == nil || (operationCallback.GoLow() != nil && operationCallback.GoLow().IsReference()) {
+			return
+		}
+
+		for pathItem := range operationCallback.Expression.ValuesFromOldest() {
+			updatePathItem(pathItem)
+		}
+	}
+
+	updatePathItem = func(pathItem *v3.PathItem) {
+		if pathItem == nil || isComponentPathItemRef(pathItem) {
+			return
+		}
+
+		updateParameters(pathItem.Parameters)
+
+		for operation := range pathItem.GetOperations().ValuesFromOldest() {
+			updateParameters(operation.Parameters)
+
+			if operation.RequestBody != nil {
+				updateContent(operation.RequestBody.Content)
+			}
+
+			if operation.Responses != nil && operation.Responses.Codes != nil {
+				for code := range operation.Responses.Codes.ValuesFromOldest() {
+					updateContent(code.Content)
+				}
+			}
+
+			for operationCallback := range operation.Callbacks.ValuesFromOldest() {
+				updateCallback(operationCallback)
+			}
+		}
+	}
+
 	if model.Model.Components != nil && model.Model.Components.Schemas != nil {
+		// Alias components such as {PetAlias: {$ref: Pet}} are updated where they point to.
 		for value := range model.Model.Components.Schemas.ValuesFromOldest() {
-			updateSchemaAndReferencedSchema(value.Schema(), callback)
+			updateSchema(value)
 		}
 	}
 
 	if model.Model.Components != nil && model.Model.Components.Parameters != nil {
-		for value := range model.Model.Components.Parameters.ValuesFromOldest() {
-			updateSchemaAndReferencedSchema(value.Schema.Schema(), callback)
+		updateParameters(slices.Collect(model.Model.Components.Parameters.ValuesFromOldest()))
+	}
+
+	if model.Model.Components != nil && model.Model.Components.Headers != nil {
+		for value := range model.Model.Components.Headers.ValuesFromOldest() {
+			if value != nil && (value.GoLow() == nil || !value.GoLow().IsReference()) {
+				updateSchema(value.Schema)
+			}
+		}
+	}
+
+	if model.Model.Components != nil && model.Model.Components.PathItems != nil {
+		for pathItem := range model.Model.Components.PathItems.ValuesFromOldest() {
+			// Component path items can also reference each other.
+			if pathItem != nil && (pathItem.GoLow() == nil || !pathItem.GoLow().IsReference()) {
+				updatePathItem(pathItem)
+			}
+		}
+	}
+
+	if model.Model.Components != nil && model.Model.Components.Callbacks != nil {
+		for value := range model.Model.Components.Callbacks.ValuesFromOldest() {
+			updateCallback(value)
 		}
 	}
 
 	if model.Model.Paths != nil && model.Model.Paths.PathItems != nil {
 		for pathItem := range model.Model.Paths.PathItems.ValuesFromOldest() {
-			for operation := range pathItem.GetOperations().ValuesFromOldest() {
-				if operation.RequestBody != nil && operation.RequestBody.Content != nil {
-					for content := range operation.RequestBody.Content.ValuesFromOldest() {
-						if content.Schema != nil {
-							updateSchemaAndReferencedSchema(content.Schema.Schema(), callback)
-						}
-					}
-				}
+			updatePathItem(pathItem)
+		}
+	}
+}
 
-				if operation.Responses != nil && operation.Responses.Codes != nil {
-					for code := range operation.Responses.Codes.ValuesFromOldest() {
-						if code.Content != nil {
-							for mediaType := range code.Content.ValuesFromOldest() {
-								if mediaType.Schema != nil {
-									updateSchemaAndReferencedSchema(mediaType.Schema.Schema(), callback)
-								}
-							}
-						}
-					}
-				}
-			}
+// drop30ParameterContentWithSchema 删除同时有 schema 和 content 的参数的 content，并为每个参数记录警告。
+// 映射关系：
+//   - {name: "filter", in: "query", schema: {...}, content: {...}} -> {name: "filter", in: "query", schema: {...}}
+//
+// 原因：OpenAPI 3.x 要求参数只能有 schema 和 content 中的一个，同时有两者的文档是无效的；
+// schema 是更常见的形式，并且是 Swagger 2.0 唯一能表示的形式，所以保留 schema
+//
+// 注意：引用其他参数的 $ref 不会被处理，被引用的参数在 components.parameters 中处理
+func (converter *Converter) drop30ParameterContentWithSchema(
+	model *libopenapi.DocumentModel[v3.Document],
+) {
+	dropContent := func(parameter *v3.Parameter, pointer string) {
+		if parameter == nil || (parameter.GoLow() != nil && parameter.GoLow().IsReference()) {
+			return
+		}
+
+		if parameter.Schema != nil && parameter.Content != nil && parameter.Content.Len() > 0 {
+			converter.warn(pointer+"/content", "dropped the content, the parameter also has a schema and can only have one of them")
+			parameter.Content = nil
+		}
+	}
+
+	dropListContent := func(parameters []*v3.Parameter, pointer string) {
+		for i, parameter := range parameters {
+			dropContent(parameter, pointer+"/"+strconv.Itoa(i))
+		}
+	}
+
+	if model.Model.Components != nil && model.Model.Components.Parameters != nil {
+		for name, parameter := range model.Model.Components.Parameters.FromOldest() {
+			dropContent(parameter, jsonPointer("components", "parameters", name))
+		}
+	}
+
+	if model.Model.Paths == nil || model.Model.Paths.PathItems == nil {
+		return
+	}
+
+	for path, pathItem := range model.Model.Paths.PathItems.FromOldest() {
+		dropListContent(pathItem.Parameters, jsonPointer("paths", path, "parameters"))
+
+		for method, operation := range pathItem.GetOperations().FromOldest() {
+			dropListContent(operation.Parameters, jsonPointer("paths", path, method, "parameters"))
 		}
 	}
 }
 
+// mediaTypeEssence 返回去掉参数后的小写媒体类型，用于比较媒体类型。
+// 映射关系：
+//   - "application/json; charset=utf-8" -> "application/json"
+//   - "Application/Octet-Stream" -> "application/octet-stream"
+func mediaTypeEssence(mediaType string) string {
+	essence, _, _ := strings.Cut(mediaType, ";")
+
+	return strings.ToLower(strings.TrimSpace(essence))
+}
+
+// findContentMediaType 在 content 中查找与 mediaType 匹配的媒体类型，忽略媒体类型参数和大小写。
+// 返回 content 中实际使用的键，例如 "application/octet-stream; foo=bar"。
+func findContentMediaType(content *orderedmap.Map[string, *v3.MediaType], mediaType string) (string, *v3.MediaType, bool) {
+	if content == nil {
+		return "", nil, false
+	}
+
+	// Prefer an exact match before comparing media type essences.
+	if value, ok := content.Get(mediaType); ok {
+		return mediaType, value, true
+	}
+
+	essence := mediaTypeEssence(mediaType)
+
+	for name, value := range content.FromOldest() {
+		if mediaTypeEssence(name) == essence {
+			return name, value, true
+		}
+	}
+
+	return "", nil, false
+}
+
 // clear30RequestFileContentSchemaFor31 在 OpenAPI 3.0 到 3.1 转换时，清除文件上传请求体的 schema。
 // 映射关系：
 //   - OpenAPI 3.0: {content: {"application/octet-stream": {schema: {...}}}}
 //     -> OpenAPI 3.1: {content: {"application/octet-stream": {schema: null}}}
 //
-// 操作：将 content["application/octet-stream"].Schema 设置为 nil
+// 操作：将 content["application/octet-stream"].Schema 设置为 nil（带参数的媒体类型，例如 "application/octet-stream; foo=bar" 同样处理）
 // 原因：在 OpenAPI 3.1 中，application/octet-stream 的 schema 类型是隐式的，不需要显式定义
 func clear30RequestFileContentSchemaFor31(
 	model *libopenapi.DocumentModel[v3.Document],
@@ -523,7 +2044,7 @@ func clear30RequestFileContentSchemaFor31(
 			for operation := range pathItem.GetOperations().ValuesFromOldest() {
 				if operation.RequestBody != nil && operation.RequestBody.Content != nil {
 					// Clear the schema for application/octet-stream, as the type is implied.
-					if content, ok := operation.RequestBody.Content.Get("application/octet-stream"); ok {
+					if _, content, ok := findContentMediaType(operation.RequestBody.Content, "application/octet-stream"); ok {
 						content.Schema = nil
 					}
 				}
@@ -537,7 +2058,7 @@ func clear30RequestFileContentSchemaFor31(
 //   - OpenAPI 3.1: {content: {"application/octet-stream": {schema: null}}}
 //     -> OpenAPI 3.0: {content: {"application/octet-stream": {schema: {type: "string", format: "binary"}}}}
 //
-// 操作：将 content["application/octet-stream"].Schema 设置为 {type: ["string"], format: "binary"}
+// 操作：将 content["application/octet-stream"].Schema 设置为 {type: ["string"], format: "binary"}（带参数的媒体类型同样处理）
 // 原因：在 OpenAPI 3.0 中，需要显式定义二进制文件的 schema
 func set31RequestFileContentSchemaFor30(
 	model *libopenapi.DocumentModel[v3.Document],
@@ -547,7 +2068,7 @@ func set31RequestFileContentSchemaFor30(
 			for operation := range pathItem.GetOperations().ValuesFromOldest() {
 				if operation.RequestBody != nil && operation.RequestBody.Content != nil {
 					// Clear the schema for application/octet-stream, as the type is implied.
-					if content, ok := operation.RequestBody.Content.Get("application/octet-stream"); ok {
+					if _, content, ok := findContentMediaType(operation.RequestBody.Content, "application/octet-stream"); ok {
 						content.Schema = base.CreateSchemaProxy(&base.Schema{
 							Type:   []string{"string"},
 							Format: "binary",
@@ -559,28 +2080,598 @@ func set31RequestFileContentSchemaFor30(
 	}
 }
 
-// ensureRequestBodyContentSchemas 确保所有请求体 content 都有有效的 schema。
+// inline31ComponentPathItemsFor30 在 OpenAPI 3.1 到 3.0 转换时，将引用 components.pathItems 的路径和回调内联。
+// 映射关系：
+//   - OpenAPI 3.1: {paths: {"/pets": {$ref: "#/components/pathItems/Pets"}}, components: {pathItems: {Pets: {...}}}}
+//     -> OpenAPI 3.0: {paths: {"/pets": {...}}}（components.pathItems 被移除）
+//   - OpenAPI 3.1: {callbacks: {onEvent: {"{$request.body#/url}": {$ref: "#/components/pathItems/Event"}}}}
+//     -> OpenAPI 3.0: {callbacks: {onEvent: {"{$request.body#/url}": {...}}}}
+//
+// 操作：清除路径和回调（包括操作中和 components.callbacks 中的回调）上的引用标记，使 libopenapi 渲染已解析的路径内容，
+// 然后清空 Components.PathItems
+// 原因：components.pathItems 是 OpenAPI 3.1 新增的字段，OpenAPI 3.0 中不存在
+//
+// 注意：webhooks 在转换为 3.0 时被丢弃，所以不需要内联其中的引用
+func inline31ComponentPathItemsFor30(
+	model *libopenapi.DocumentModel[v3.Document],
+) {
+	var inlinePathItem func(pathItem *v3.PathItem)
+
+	inlineCallback := func(operationCallback *v3.Callback) {
+		// Referenced callbacks are inlined in the components.
+		if operationCallback == nil || (operationCallback.GoLow() != nil && operationCallback.GoLow().IsReference()) {
+			return
+		}
+
+		for pathItem := range operationCallback.Expression.ValuesFromOldest() {
+			inlinePathItem(pathItem)
+		}
+	}
+
+	inlinePathItem = func(pathItem *v3.PathItem) {
+		if pathItem == nil {
+			return
+		}
+
+		lowPathItem := pathItem.GoLow()
+
+		// The high level path item is already resolved, so clearing the
+		// reference makes libopenapi render the resolved content inline.
+		if lowPathItem != nil && strings.HasPrefix(lowPathItem.GetReference(), "#/components/pathItems/") {
+			lowPathItem.SetReference("", nil)
+		}
+
+		for operation := range pathItem.GetOperations().ValuesFromOldest() {
+			if operation.Callbacks != nil {
+				for operationCallback := range operation.Callbacks.ValuesFromOldest() {
+					inlineCallback(operationCallback)
+				}
+			}
+		}
+	}
+
+	if model.Model.Paths != nil && model.Model.Paths.PathItems != nil {
+		for pathItem := range model.Model.Paths.PathItems.ValuesFromOldest() {
+			inlinePathItem(pathItem)
+		}
+	}
+
+	if model.Model.Components != nil && model.Model.Components.Callbacks != nil {
+		for operationCallback := range model.Model.Components.Callbacks.ValuesFromOldest() {
+			inlineCallback(operationCallback)
+		}
+	}
+
+	if model.Model.Components != nil {
+		model.Model.Components.PathItems = nil
+	}
+}
+
+// inline30PathItemRefsForSwagger 在 OpenAPI 3.0 到 Swagger 2.0 转换时，将所有引用其他路径项的路径内联。
+// 映射关系：
+//   - OpenAPI 3.0: {paths: {"/animals": {$ref: "#/paths/~1pets"}}} -> {paths: {"/animals": {get: {...}}}}
+//   - OpenAPI 3.0: {paths: {"/pets": {$ref: "#/x-path-items/Pets"}}, x-path-items: {...}} -> {paths: {"/pets": {get: {...}}}}
+//
+// 操作：清除路径上的引用标记，使 libopenapi 渲染已解析的路径内容，然后删除保存被引用路径项的根对象扩展字段，
+// 并为每个被删除的扩展字段记录一次警告
+// 原因：Swagger 2.0 的路径项 $ref 只用于引用外部文件，工具普遍不支持；在交给 kin-openapi 之前由 libopenapi 解析，
+// 不依赖 kin-openapi 的引用解析
+//
+// 注意：扩展字段中的内容仍然是 OpenAPI 3.0 的格式，并且可能引用 Swagger 中不存在的 #/components/...，所以不能保留
+func (converter *Converter) inline30PathItemRefsForSwagger(
+	model *libopenapi.DocumentModel[v3.Document],
+) {
+	if model.Model.Paths == nil || model.Model.Paths.PathItems == nil {
+		return
+	}
+
+	containers := map[string]bool{}
+
+	for pathItem := range model.Model.Paths.PathItems.ValuesFromOldest() {
+		if lowPathItem := pathItem.GoLow(); lowPathItem != nil && lowPathItem.IsReference() {
+			// Remember extensions such as x-path-items holding the path items.
+			if key, _, _ := strings.Cut(strings.TrimPrefix(lowPathItem.GetReference(), "#/"), "/"); strings.HasPrefix(key, "x-") {
+				containers[key] = true
+			}
+
+			lowPathItem.SetReference("", nil)
+		}
+	}
+
+	if model.Model.Extensions != nil {
+		for _, key := range slices.Sorted(maps.Keys(containers)) {
+			if _, ok := model.Model.Extensions.Delete(key); ok {
+				converter.warn(jsonPointer(key), "dropped the extension, its path items were inlined into paths")
+			}
+		}
+	}
+}
+
+// inline30ResponseRefsForSwagger 在 OpenAPI 3.0 到 Swagger 2.0 转换时，将操作中引用 components.responses 的响应替换为引用的内容（--inline-response-refs）。
+// 映射关系：
+//   - OpenAPI 3.0: {responses: {"404": {$ref: "#/components/responses/NotFound"}}}
+//     -> Swagger 2.0: {responses: {"404": {description: "...", schema: {...}}}}（而不是 {$ref: "#/responses/NotFound"}）
+//
+// 操作：与 inline30PathItemRefsForSwagger 相同，清除 libopenapi 底层模型中的引用，渲染时输出引用的内容
+// 原因：一些 Swagger 工具无法解析响应中的 $ref
+//
+// 注意：
+//   - components.responses 中的响应仍然被转换为 Swagger 的 responses，以便其他文档引用
+//   - 响应中引用 schema 的 $ref 保持不变
+func inline30ResponseRefsForSwagger(
+	model *libopenapi.DocumentModel[v3.Document],
+) {
+	if model.Model.Paths == nil || model.Model.Paths.PathItems == nil {
+		return
+	}
+
+	for pathItem := range model.Model.Paths.PathItems.ValuesFromOldest() {
+		for operation := range pathItem.GetOperations().ValuesFromOldest() {
+			if operation.Responses == nil {
+				continue
+			}
+
+			responses := []*v3.Response{operation.Responses.Default}
+
+			if operation.Responses.Codes != nil {
+				responses = slices.AppendSeq(responses, operation.Responses.Codes.ValuesFromOldest())
+			}
+
+			for _, response := range responses {
+				if response == nil {
+					continue
+				}
+
+				if lowResponse := response.GoLow(); lowResponse != nil && lowResponse.IsReference() {
+					lowResponse.SetReference("", nil)
+				}
+			}
+		}
+	}
+}
+
+// ensureRequestBodyContentSchemas 确保所有请求体 content 都有有效的 schema。
+// 映射关系：
+//   - {content: {..., schema: null}} -> {content: {..., schema: {type: ["object"]}}}
+//
+// 操作：如果 content.Schema 为 nil，则创建一个默认的空对象 schema {type: ["object"]}
+// 原因：kin-openapi 的 FromV3 转换器无法处理 nil schema，需要为每个 content 提供有效的 schema
+// 注意：OpenAPI 3.1 的布尔 schema true 被 libopenapi 加载为空 schema（不是 nil），保持为 {}
+func ensureRequestBodyContentSchemas(
+	model *libopenapi.DocumentModel[v3.Document],
+) {
+	if model.Model.Paths != nil && model.Model.Paths.PathItems != nil {
+		for pathItem := range model.Model.Paths.PathItems.ValuesFromOldest() {
+			for operation := range pathItem.GetOperations().ValuesFromOldest() {
+				if operation.RequestBody != nil && operation.RequestBody.Content != nil {
+					for content := range operation.RequestBody.Content.ValuesFromOldest() {
+						// If schema is nil, create a default empty object schema
+						if content.Schema == nil {
+							content.Schema = base.CreateSchemaProxy(&base.Schema{
+								Type: []string{"object"},
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// ensureResponseContentSchemas 确保所有响应 content 都有有效的 schema。
+// 映射关系：
+//   - {content: {"application/json": {}}} -> {content: {"application/json": {schema: {}}}}
+//
+// 操作：如果 content.Schema 为 nil，则创建一个空 schema {}，包括 components.responses 中的响应
+// 原因：kin-openapi 的 FromV3 转换器读取响应 schema 时不检查 nil，没有 schema 的响应 content 会导致崩溃；
+// 没有 schema 的媒体类型允许任意内容，与空 schema 相同
+//
+// 注意：OpenAPI 3.1 的布尔 schema true 被 libopenapi 加载为空 schema（不是 nil），转换后同样为 {}
+func ensureResponseContentSchemas(
+	model *libopenapi.DocumentModel[v3.Document],
+) {
+	ensure := func(response *v3.Response) {
+		if response == nil || response.Content == nil {
+			return
+		}
+
+		for content := range response.Content.ValuesFromOldest() {
+			if content.Schema == nil {
+				content.Schema = base.CreateSchemaProxy(&base.Schema{})
+			}
+		}
+	}
+
+	if model.Model.Components != nil && model.Model.Components.Responses != nil {
+		for response := range model.Model.Components.Responses.ValuesFromOldest() {
+			ensure(response)
+		}
+	}
+
+	if model.Model.Paths != nil && model.Model.Paths.PathItems != nil {
+		for pathItem := range model.Model.Paths.PathItems.ValuesFromOldest() {
+			for operation := range pathItem.GetOperations().ValuesFromOldest() {
+				if operation.Responses == nil {
+					continue
+				}
+
+				ensure(operation.Responses.Default)
+
+				if operation.Responses.Codes != nil {
+					for response := range operation.Responses.Codes.ValuesFromOldest() {
+						ensure(response)
+					}
+				}
+			}
+		}
+	}
+}
+
+// swaggerOperationKey 标识文档中的一个操作，用于在 libopenapi 模型和 kin-openapi 模型之间传递信息
+type swaggerOperationKey struct {
+	path   string // 路径，例如 "/pets"
+	method string // 小写的 HTTP 方法，例如 "get"
+}
+
+// selectResponseMediaType 从响应的 content 中选择一个媒体类型。
+// 选择顺序：
+//  1. preferred（--response-media 指定的媒体类型）
+//  2. "application/json"
+//  3. content 中第一个声明的媒体类型
+//
+// 匹配时忽略媒体类型参数，例如 "application/json; charset=utf-8" 与 "application/json" 匹配，返回 content 中实际的键
+func selectResponseMediaType(content *orderedmap.Map[string, *v3.MediaType], preferred string) string {
+	if name, _, ok := findContentMediaType(content, preferred); ok {
+		return name
+	}
+
+	if name, _, ok := findContentMediaType(content, "application/json"); ok {
+		return name
+	}
+
+	return content.First().Key()
+}
+
+// select30ResponseMediaTypesForSwagger 在 OpenAPI 3.0 到 Swagger 2.0 转换时，为每个响应选择一个媒体类型。
+// 映射关系：
+//   - OpenAPI 3.0: {responses: {"200": {content: {"application/json": {...}, "application/xml": {...}}}}}
+//     -> {responses: {"200": {content: {"application/json": <选中的媒体类型>}}}}，其余媒体类型被丢弃并记录警告
+//
+// 操作：
+//   - 按 selectResponseMediaType 的顺序选择媒体类型，并将其放在 "application/json" 键下
+//   - 返回每个操作选中的媒体类型，用于在转换后设置 Swagger 的 produces
+//   - 返回每个响应（以 JSON Pointer 为键）选中的媒体类型，用于在转换后设置 Swagger 响应的 examples
+//
+// 原因：kin-openapi 的 FromV3 只读取响应中 "application/json" 的 schema，并且不会设置 produces
+func (converter *Converter) select30ResponseMediaTypesForSwagger(
+	model *libopenapi.DocumentModel[v3.Document],
+) (map[swaggerOperationKey][]string, map[string]string) {
+	preferred := converter.arguments.responseMedia
+
+	selectMediaType := func(response *v3.Response, pointer string, warn bool) string {
+		if response == nil || response.Content == nil || response.Content.Len() == 0 {
+			return ""
+		}
+
+		mediaType := selectResponseMediaType(response.Content, preferred)
+
+		if warn {
+			for name := range response.Content.KeysFromOldest() {
+				if name != mediaType {
+					converter.warn(
+						pointer+"/content/"+escapeJSONPointerToken(name),
+						"dropped response media type %s, Swagger responses use %s",
+						name,
+						mediaType,
+					)
+				}
+			}
+		}
+
+		if response.Content.Len() > 1 || mediaType != "application/json" {
+			selected, _ := response.Content.Get(mediaType)
+			response.Content = orderedmap.New[string, *v3.MediaType]()
+			response.Content.Set("application/json", selected)
+		}
+
+		return mediaType
+	}
+
+	responseMediaTypes := make(map[string]string)
+
+	if model.Model.Components != nil && model.Model.Components.Responses != nil {
+		for name, response := range model.Model.Components.Responses.FromOldest() {
+			pointer := jsonPointer("components", "responses", name)
+			responseMediaTypes[pointer] = selectMediaType(response, pointer, true)
+		}
+	}
+
+	produces := make(map[swaggerOperationKey][]string)
+
+	if model.Model.Paths != nil && model.Model.Paths.PathItems != nil {
+		for path, pathItem := range model.Model.Paths.PathItems.FromOldest() {
+			for method, operation := range pathItem.GetOperations().FromOldest() {
+				if operation.Responses == nil || operation.Responses.Codes == nil {
+					continue
+				}
+
+				key := swaggerOperationKey{path: path, method: method}
+
+				for code, response := range operation.Responses.Codes.FromOldest() {
+					// Referenced responses are rendered as references, so warnings
+					// are only reported once for the component response.
+					isReference := response.GoLow() != nil && response.GoLow().IsReference()
+					pointer := jsonPointer("paths", path, method, "responses", code)
+
+					mediaType := selectMediaType(response, pointer, !isReference)
+					responseMediaTypes[pointer] = mediaType
+
+					if mediaType != "" && !slices.Contains(produces[key], mediaType) {
+						produces[key] = append(produces[key], mediaType)
+					}
+				}
+			}
+		}
+	}
+
+	return produces, responseMediaTypes
+}
+
+// warn30PathServersDroppedForSwagger 在 OpenAPI 3.0 到 Swagger 2.0 转换时，为路径和操作级别的 servers 记录警告。
+// 映射关系：
+//   - OpenAPI 3.0: paths[].servers / paths[].<method>.servers -> Swagger 2.0: 无对应字段（被丢弃）
+//
+// 原因：Swagger 2.0 只有文档级别的 host/basePath/schemes，kin-openapi 会静默丢弃这些 servers
+func (converter *Converter) warn30PathServersDroppedForSwagger(
+	model *libopenapi.DocumentModel[v3.Document],
+) {
+	if model.Model.Paths == nil || model.Model.Paths.PathItems == nil {
+		return
+	}
+
+	for path, pathItem := range model.Model.Paths.PathItems.FromOldest() {
+		if len(pathItem.Servers) > 0 {
+			converter.warn(jsonPointer("paths", path, "servers"), "dropped %d path level servers, Swagger has no per-path servers", len(pathItem.Servers))
+		}
+
+		for method, operation := range pathItem.GetOperations().FromOldest() {
+			if len(operation.Servers) > 0 {
+				converter.warn(jsonPointer("paths", path, method, "servers"), "dropped %d operation level servers, Swagger has no per-operation servers", len(operation.Servers))
+			}
+		}
+	}
+}
+
+// setSwaggerServerDescription 在 OpenAPI 3.0 到 Swagger 2.0 转换时，处理第一个 server 的 description。
+// 映射关系：
+//   - OpenAPI 3.0: {servers: [{url: "https://api.example.com/v1", description: "Production"}]}
+//     -> Swagger 2.0: {host: "api.example.com", basePath: "/v1", x-server-description: "Production"}（--keep-server-description）
+//   - 没有设置 --keep-server-description 时 description 被丢弃并记录警告
+//
+// 原因：kin-openapi 使用第一个 server 的 URL 生成 host 和 basePath，Swagger 2.0 没有保存 description 的字段
+func (converter *Converter) setSwaggerServerDescription(kinOpenAPIDoc *openapi3.T, kinSwaggerDoc *openapi2.T) {
+	if len(kinOpenAPIDoc.Servers) == 0 || kinOpenAPIDoc.Servers[0] == nil || kinOpenAPIDoc.Servers[0].Description == "" {
+		return
+	}
+
+	description := kinOpenAPIDoc.Servers[0].Description
+
+	if !converter.arguments.serverDesc {
+		converter.warn(jsonPointer("servers", "0", "description"), "dropped the server description, use --keep-server-description to keep it as x-server-description")
+		return
+	}
+
+	if kinSwaggerDoc.Extensions == nil {
+		kinSwaggerDoc.Extensions = map[string]any{}
+	}
+
+	kinSwaggerDoc.Extensions["x-server-description"] = description
+}
+
+// warn30EncodingHeadersDroppedForSwagger 在 OpenAPI 3.0 到 Swagger 2.0 转换时，为请求体 encoding 中的 headers 记录警告。
+// 映射关系：
+//   - OpenAPI 3.0: requestBody.content["multipart/form-data"].encoding[part].headers -> Swagger 2.0: 无对应字段（被丢弃）
+//
+// 原因：Swagger 2.0 的 formData 参数没有为每个部分声明 header 的方式，kin-openapi 会静默丢弃 encoding
+func (converter *Converter) warn30EncodingHeadersDroppedForSwagger(
+	model *libopenapi.DocumentModel[v3.Document],
+) {
+	if model.Model.Paths == nil || model.Model.Paths.PathItems == nil {
+		return
+	}
+
+	for path, pathItem := range model.Model.Paths.PathItems.FromOldest() {
+		for method, operation := range pathItem.GetOperations().FromOldest() {
+			if operation.RequestBody == nil {
+				continue
+			}
+
+			for mediaTypeName, mediaType := range operation.RequestBody.Content.FromOldest() {
+				for part, encoding := range mediaType.Encoding.FromOldest() {
+					if encoding.Headers != nil && encoding.Headers.Len() > 0 {
+						converter.warn(
+							jsonPointer("paths", path, method, "requestBody", "content", mediaTypeName, "encoding", part, "headers"),
+							"dropped %d headers of the %q part, Swagger has no per-part headers",
+							encoding.Headers.Len(),
+							part,
+						)
+					}
+				}
+			}
+		}
+	}
+}
+
+// drop30OpenIDConnectSchemesForSwagger 在 OpenAPI 3.0 到 Swagger 2.0 转换时，删除 type 为 openIdConnect 的安全方案，并为每个方案记录警告。
+// 映射关系：
+//   - OpenAPI 3.0: components.securitySchemes[name] = {type: "openIdConnect", ...} -> Swagger 2.0: 无对应字段（被删除）
+//   - OpenAPI 3.0: {security: [{oidc: []}, {apiKey: []}]} -> Swagger 2.0: {security: [{apiKey: []}]}
+//   - OpenAPI 3.0: 操作的 {security: [{oidc: []}]} -> Swagger 2.0: 操作没有 security
+//
+// 原因：Swagger 2.0 没有 OpenID Connect，kin-openapi 的 FromV3 会输出没有 type 的无效 securityDefinitions；
+// 只有 openIdConnectUrl 无法得到 oauth2 需要的 flow 和 URL，所以不会近似为 oauth2
+//
+// 注意：与 removeSecurityRequirements 相同，使用被删除方案的整个 security 条目被移除，
+// 所有条目都被移除的操作删除 security，继承根对象的 security（见 filterSecurityRequirements）
+func (converter *Converter) drop30OpenIDConnectSchemesForSwagger(kinOpenAPIDoc *openapi3.T) {
+	var dropped []string
+
+	for _, name := range slices.Sorted(maps.Keys(kinOpenAPIDoc.Components.SecuritySchemes)) {
+		scheme := kinOpenAPIDoc.Components.SecuritySchemes[name]
+
+		if scheme == nil || scheme.Value == nil || scheme.Value.Type != "openIdConnect" {
+			continue
+		}
+
+		delete(kinOpenAPIDoc.Components.SecuritySchemes, name)
+		dropped = append(dropped, name)
+		converter.warn(jsonPointer("components", "securitySchemes", name), "dropped the openIdConnect security scheme, Swagger has no OpenID Connect")
+	}
+
+	if len(dropped) == 0 {
+		return
+	}
+
+	schemes := func(requirement openapi3.SecurityRequirement) []string {
+		return slices.Collect(maps.Keys(requirement))
+	}
+
+	if security, emptied := filterSecurityRequirements(converter, kinOpenAPIDoc.Security, dropped, schemes, jsonPointer("security")); emptied {
+		kinOpenAPIDoc.Security = nil
+	} else {
+		kinOpenAPIDoc.Security = security
+	}
+
+	if kinOpenAPIDoc.Paths == nil {
+		return
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(kinOpenAPIDoc.Paths.Map())) {
+		operations := kinOpenAPIDoc.Paths.Value(path).Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			operation := operations[method]
+
+			if operation.Security == nil {
+				continue
+			}
+
+			pointer := jsonPointer("paths", path, strings.ToLower(method), "security")
+
+			if security, emptied := filterSecurityRequirements(converter, *operation.Security, dropped, schemes, pointer); emptied {
+				operation.Security = nil
+			} else {
+				*operation.Security = security
+			}
+		}
+	}
+}
+
+// setSwaggerOperationProduces 根据 select30ResponseMediaTypesForSwagger 选中的媒体类型设置 Swagger 操作的 produces。
 // 映射关系：
-//   - {content: {..., schema: null}} -> {content: {..., schema: {type: ["object"]}}}
+//   - 操作的响应选中了 "application/xml" -> operation.produces: ["application/xml"]
+func setSwaggerOperationProduces(kinSwaggerDoc *openapi2.T, produces map[swaggerOperationKey][]string) {
+	for key, mediaTypes := range produces {
+		pathItem, ok := kinSwaggerDoc.Paths[key.path]
+
+		if !ok || key.method == "trace" {
+			// Swagger has no TRACE operations.
+			continue
+		}
+
+		if operation := pathItem.GetOperation(strings.ToUpper(key.method)); operation != nil {
+			operation.Produces = mediaTypes
+		}
+	}
+}
+
+// setSwaggerResponseExamples 将 OpenAPI 3.0 响应中选中媒体类型的示例映射到 Swagger 2.0 响应的 examples。
+// 映射关系：
+//   - OpenAPI 3.0: {content: {"application/json": {example: value}}} -> Swagger 2.0: {examples: {"application/json": value}}
+//   - OpenAPI 3.0: {content: {"application/json": {examples: {first: {value: value}, ...}}}} -> {examples: {"application/json": value}}（只取名称排序后的第一个，并记录警告）
 //
-// 操作：如果 content.Schema 为 nil，则创建一个默认的空对象 schema {type: ["object"]}
-// 原因：kin-openapi 的 FromV3 转换器无法处理 nil schema，需要为每个 content 提供有效的 schema
-func ensureRequestBodyContentSchemas(
-	model *libopenapi.DocumentModel[v3.Document],
+// 参数：
+//   - kinOpenAPIDoc: 转换前的 kin-openapi 文档，选中的媒体类型已经被放在 "application/json" 键下
+//   - responseMediaTypes: select30ResponseMediaTypesForSwagger 返回的每个响应选中的媒体类型，用作 examples 的键
+//
+// 原因：kin-openapi 的 FromV3 会丢弃响应的示例
+func (converter *Converter) setSwaggerResponseExamples(
+	kinOpenAPIDoc *openapi3.T,
+	kinSwaggerDoc *openapi2.T,
+	responseMediaTypes map[string]string,
 ) {
-	if model.Model.Paths != nil && model.Model.Paths.PathItems != nil {
-		for pathItem := range model.Model.Paths.PathItems.ValuesFromOldest() {
-			for operation := range pathItem.GetOperations().ValuesFromOldest() {
-				if operation.RequestBody != nil && operation.RequestBody.Content != nil {
-					for content := range operation.RequestBody.Content.ValuesFromOldest() {
-						// If schema is nil, create a default empty object schema
-						if content.Schema == nil {
-							content.Schema = base.CreateSchemaProxy(&base.Schema{
-								Type: []string{"object"},
-							})
-						}
-					}
-				}
+	setExample := func(responseRef *openapi3.ResponseRef, response *openapi2.Response, pointer string) {
+		mediaType := responseMediaTypes[pointer]
+
+		if responseRef == nil || responseRef.Value == nil || response == nil || response.Ref != "" || mediaType == "" {
+			return
+		}
+
+		content := responseRef.Value.Content.Get("application/json")
+
+		if content == nil {
+			return
+		}
+
+		example := content.Example
+
+		if example == nil && len(content.Examples) > 0 {
+			names := slices.Sorted(maps.Keys(content.Examples))
+
+			if exampleRef := content.Examples[names[0]]; exampleRef != nil && exampleRef.Value != nil {
+				example = exampleRef.Value.Value
+			}
+
+			if len(names) > 1 {
+				converter.warn(
+					pointer+"/content/"+escapeJSONPointerToken(mediaType)+"/examples",
+					"Swagger responses have one example per media type, using the %q example",
+					names[0],
+				)
+			}
+		}
+
+		if example != nil {
+			if response.Examples == nil {
+				response.Examples = make(map[string]any)
+			}
+
+			response.Examples[mediaType] = example
+		}
+	}
+
+	// Iterate in sorted order so warnings are reported in a stable order.
+	if kinOpenAPIDoc.Components != nil {
+		for _, name := range slices.Sorted(maps.Keys(kinOpenAPIDoc.Components.Responses)) {
+			responseRef := kinOpenAPIDoc.Components.Responses[name]
+			setExample(responseRef, kinSwaggerDoc.Responses[name], jsonPointer("components", "responses", name))
+		}
+	}
+
+	paths := kinOpenAPIDoc.Paths.Map()
+
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		swaggerPathItem := kinSwaggerDoc.Paths[path]
+
+		if swaggerPathItem == nil {
+			continue
+		}
+
+		operations := paths[path].Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			operation := operations[method]
+			swaggerOperation := swaggerPathItem.GetOperation(method)
+
+			if operation.Responses == nil || swaggerOperation == nil {
+				continue
+			}
+
+			responses := operation.Responses.Map()
+
+			for _, code := range slices.Sorted(maps.Keys(responses)) {
+				pointer := jsonPointer("paths", path, strings.ToLower(method), "responses", code)
+				setExample(responses[code], swaggerOperation.Responses[code], pointer)
 			}
 		}
 	}
@@ -591,12 +2682,16 @@ func ensureRequestBodyContentSchemas(
 //   - Swagger 2.0: {consumes: ["application/octet-stream"], parameters: [{in: "body", schema: null}]}
 //     -> Swagger 2.0: {consumes: ["application/octet-stream"], parameters: [{in: "body", schema: {type: "string", format: "binary"}}]}
 //
-// 操作：如果操作 consumes "application/octet-stream" 且 body 参数的 schema 为 nil，则添加 {type: "string", format: "binary"}
+// 操作：如果操作 consumes "application/octet-stream"（忽略媒体类型参数）且 body 参数的 schema 为 nil，则添加 {type: "string", format: "binary"}
 // 原因：kin-openapi 转换器在创建上传规范时不会自动添加 schema，需要手动补充
 func fixSwaggerOperationUploadFormat(operation *openapi2.Operation) {
-	if operation != nil && slices.Contains(operation.Consumes, "application/octet-stream") {
+	isOctetStream := func(mediaType string) bool {
+		return mediaTypeEssence(mediaType) == "application/octet-stream"
+	}
+
+	if operation != nil && slices.ContainsFunc(operation.Consumes, isOctetStream) {
 		for _, param := range operation.Parameters {
-			if param.In == "body" && param.Schema == nil {
+			if param != nil && param.In == "body" && param.Schema == nil {
 				param.Schema = &openapi2.SchemaRef{
 					Value: &openapi2.Schema{
 						Type:   &openapi3.Types{"string"},
@@ -617,6 +2712,10 @@ func fixSwaggerOperationUploadFormat(operation *openapi2.Operation) {
 // 操作：对每个符合条件的操作调用 fixSwaggerOperationUploadFormat 进行修复
 func fixSwaggerDocUploadFormats(kinSwaggerDoc *openapi2.T) {
 	for _, path := range kinSwaggerDoc.Paths {
+		if path == nil {
+			continue
+		}
+
 		// HEAD, GET, DELETE we don't check here.
 		// All other operations we try to fix.
 		fixSwaggerOperationUploadFormat(path.Post)
@@ -626,72 +2725,86 @@ func fixSwaggerDocUploadFormats(kinSwaggerDoc *openapi2.T) {
 	}
 }
 
+// extractGRPCMethodName 从 OperationID 中提取 gRPC 接口方法名称。
+// 映射规则：
+//   - "ServiceName_MethodName" -> "MethodName"（取最后一个下划线之后的部分）
+//   - 不包含下划线（或下划线位于末尾）时，使用整个 OperationID
+func extractGRPCMethodName(operation *openapi2.Operation) string {
+	if operation.OperationID == "" {
+		return ""
+	}
+
+	// 如果 OperationID 包含下划线，提取下划线后的部分作为方法名
+	if idx := strings.LastIndex(operation.OperationID, "_"); idx >= 0 && idx < len(operation.OperationID)-1 {
+		return operation.OperationID[idx+1:]
+	}
+
+	// 如果没有下划线，使用整个 OperationID 作为方法名
+	return operation.OperationID
+}
+
 // copyDescriptionToSummary 处理操作的 summary 和 description 字段映射。
 // 映射规则：
 //  1. 如果有 summary，使用 summary 映射到 summary 字段（保持不变）
 //  2. 如果没有 summary，将 description 映射到 summary 上
-//  3. description 映射后每次都追加 gRPC客户端名称和接口方法名称到映射的 description 里
+//  3. 如果 description 也为空，使用接口方法名称作为 summary
 //
-// 操作：
-//   - 如果 operation.Summary 不为空，保留 summary
-//   - 如果 operation.Summary 为空且 operation.Description 不为空，将 description 复制到 summary
-//   - 在 description 后面追加 gRPC 客户端名称（从 Tags 获取）和接口方法名称（从 OperationID 提取）
-//
-// 原因：某些工具或规范要求操作必须有 summary 字段，同时需要在 description 中包含 gRPC 信息
+// 原因：某些工具或规范要求操作必须有 summary 字段
 func copyDescriptionToSummary(operation *openapi2.Operation) {
 	if operation == nil {
 		return
 	}
 
+	// 如果有 summary，保留 summary；如果没有，将 description 复制到 summary
+	if operation.Summary == "" {
+		if operation.Description != "" {
+			operation.Summary = operation.Description
+		} else {
+			operation.Summary = extractGRPCMethodName(operation)
+		}
+	}
+}
+
+// appendGRPCInfoToDescription 在 description 中追加 gRPC 客户端名称和接口方法名称。
+// 映射规则：
+//   - gRPC 客户端名称从 Tags 的第一个元素获取
+//   - 接口方法名称从 OperationID 提取（见 extractGRPCMethodName）
+//   - 原 description 不为空时，包裹在 <p> 中并放在 gRPC 信息之后
+//
+// 原因：需要在 description 中包含 gRPC 信息，便于文档读者定位对应的 gRPC 接口
+func appendGRPCInfoToDescription(operation *openapi2.Operation) {
+	if operation == nil {
+		return
+	}
+
 	// 提取 gRPC 客户端名称（从 Tags 的第一个元素）
 	grpcClientName := ""
 	if len(operation.Tags) > 0 {
 		grpcClientName = operation.Tags[0]
 	}
 
-	// 提取接口方法名称（从 OperationID，格式通常是 "ServiceName_MethodName"）
-	methodName := ""
-	if operation.OperationID != "" {
-		// 如果 OperationID 包含下划线，提取下划线后的部分作为方法名
-		if idx := strings.LastIndex(operation.OperationID, "_"); idx >= 0 && idx < len(operation.OperationID)-1 {
-			methodName = operation.OperationID[idx+1:]
-		} else {
-			// 如果没有下划线，使用整个 OperationID 作为方法名
-			methodName = operation.OperationID
-		}
-	}
+	methodName := extractGRPCMethodName(operation)
 
 	// 构建要追加到 description 的 gRPC 信息
-	grpcInfo := ""
-	if grpcClientName != "" || methodName != "" {
-		var parts []string
-		if grpcClientName != "" {
-			parts = append(parts, fmt.Sprintf("<p><strong>gRPC客户端名称</strong>：%s</p>", grpcClientName))
-		}
-		if methodName != "" {
-			parts = append(parts, fmt.Sprintf("<p><strong>接口方法名称</strong>：%s</p>", methodName))
-		}
-		if len(parts) > 0 {
-			grpcInfo = "" + strings.Join(parts, "\n\n")
-		}
+	var parts []string
+	if grpcClientName != "" {
+		parts = append(parts, fmt.Sprintf("<p><strong>gRPC客户端名称</strong>：%s</p>", grpcClientName))
+	}
+	if methodName != "" {
+		parts = append(parts, fmt.Sprintf("<p><strong>接口方法名称</strong>：%s</p>", methodName))
 	}
 
-	// 如果有 summary，保留 summary；如果没有，将 description 复制到 summary
-	if operation.Summary == "" {
-		if operation.Description != "" {
-			operation.Summary = operation.Description
-		} else {
-			operation.Summary = methodName
-		}
+	if len(parts) == 0 {
+		return
 	}
 
+	grpcInfo := strings.Join(parts, "\n\n")
+
 	// 在 description 后面追加 gRPC 信息
-	if grpcInfo != "" {
-		if operation.Description != "" {
-			operation.Description = grpcInfo + "\n\n<p>" + operation.Description + "</p>"
-		} else {
-			operation.Description = strings.TrimPrefix(grpcInfo, "\n\n")
-		}
+	if operation.Description != "" {
+		operation.Description = grpcInfo + "\n\n<p>" + operation.Description + "</p>"
+	} else {
+		operation.Description = grpcInfo
 	}
 }
 
@@ -717,9 +2830,12 @@ func deduplicateTags(operation *openapi2.Operation) {
 // addDefaultErrorResponseToOperation 为操作添加默认错误响应，引用 rpcStatus schema。
 // 映射关系：
 //   - {responses: {}} -> {responses: {"default": {description: "...", schema: {ref: "#/definitions/rpcStatus"}}}}
+//   - {responses: {"404": {...}}} -> {responses: {"404": {...}, "default": {..., schema: {ref: "#/definitions/rpcStatus"}}}}
+//   - {responses: {"default": {schema: {ref: "#/definitions/Error"}}}} -> 不修改
 //
-// 操作：在 operation.Responses 中添加或更新 "default" 响应，其 schema 引用 "#/definitions/rpcStatus"
-// 原因：为所有操作提供统一的错误响应格式，符合 gRPC 规范
+// 操作：只在 operation.Responses 没有 "default" 响应时添加，其 schema 引用 "#/definitions/rpcStatus"
+// 原因：为所有操作提供统一的错误响应格式，符合 gRPC 规范；显式的错误响应（4xx/5xx）和已有的 default
+// 可能引用其它错误 schema，覆盖它们会使同一个操作的错误响应不一致
 func addDefaultErrorResponseToOperation(operation *openapi2.Operation) {
 	if operation == nil {
 		return
@@ -730,13 +2846,15 @@ func addDefaultErrorResponseToOperation(operation *openapi2.Operation) {
 		operation.Responses = make(map[string]*openapi2.Response)
 	}
 
-	// // Always update default error response to use rpcStatus
-	// operation.Responses["default"] = &openapi2.Response{
-	// 	Description: "An unexpected error response.",
-	// 	Schema: &openapi2.SchemaRef{
-	// 		Ref: "#/definitions/rpcStatus",
-	// 	},
-	// }
+	// Only fill the default slot, explicit error responses and defaults are kept
+	if _, exists := operation.Responses["default"]; !exists {
+		operation.Responses["default"] = &openapi2.Response{
+			Description: "An unexpected error response.",
+			Schema: &openapi2.SchemaRef{
+				Ref: "#/definitions/rpcStatus",
+			},
+		}
+	}
 }
 
 // addDefaultErrorResponses 为 Swagger 文档添加默认错误响应和相关的 schema 定义。
@@ -745,82 +2863,101 @@ func addDefaultErrorResponseToOperation(operation *openapi2.Operation) {
 //  2. 添加 googleprotobufAny schema 定义（如果不存在）
 //  3. 添加或更新 rpcStatus schema 定义（如果不存在）
 //  4. 为所有路径的所有操作执行以下操作：
-//     a. 将 description 复制到 summary（如果 summary 为空）
-//     b. 去重操作 tags
-//     c. 添加默认错误响应（引用 rpcStatus）
+//     a. 将 description 复制到 summary（如果 summary 为空，可通过 --no-grpc-summary 关闭）
+//     b. 在 description 中追加 gRPC 信息（可通过 --no-grpc-annotation 关闭）
+//     c. 去重操作 tags
+//     d. 添加默认错误响应（引用 rpcStatus）
 //
 // 映射关系：
 //   - definitions -> definitions["googleprotobufAny"]（Google Protobuf Any 类型定义）
 //   - definitions -> definitions["rpcStatus"]（gRPC 状态码定义，包含 code、message、details 字段）
 //   - operation.Responses -> operation.Responses["default"]（默认错误响应）
-func addDefaultErrorResponses(kinSwaggerDoc *openapi2.T) {
+func addDefaultErrorResponses(kinSwaggerDoc *openapi2.T, arguments Arguments) {
 	// Ensure definitions map exists
 	if kinSwaggerDoc.Definitions == nil {
 		kinSwaggerDoc.Definitions = make(map[string]*openapi2.SchemaRef)
 	}
 
-	// // Add googleprotobufAny definition if it doesn't exist
-	// if _, exists := kinSwaggerDoc.Definitions["googleprotobufAny"]; !exists {
-	// 	kinSwaggerDoc.Definitions["googleprotobufAny"] = &openapi2.SchemaRef{
-	// 		Value: &openapi2.Schema{
-	// 			Type:        &openapi3.Types{"object"},
-	// 			Description: "`Any` contains an arbitrary serialized protocol buffer message along with a\nURL that describes the type of the serialized message.\n\nProtobuf library provides support to pack/unpack Any values in the form\nof utility functions or additional generated methods of the Any type.\n\nExample 1: Pack and unpack a message in C++.\n\n    Foo foo = ...;\n    Any any;\n    any.PackFrom(foo);\n    ...\n    if (any.UnpackTo(&foo)) {\n      ...\n    }\n\nExample 2: Pack and unpack a message in Java.\n\n    Foo foo = ...;\n    Any any = Any.pack(foo);\n    ...\n    if (any.is(Foo.class)) {\n      foo = any.unpack(Foo.class);\n    }\n\nExample 3: Pack and unpack a message in Python.\n\n    foo = Foo(...)\n    any = Any()\n    any.Pack(foo)\n    ...\n    if any.Is(Foo.DESCRIPTOR):\n      any.Unpack(foo)\n      ...\n\nExample 4: Pack and unpack a message in Go\n\n     foo := &pb.Foo{...}\n     any, err := anypb.New(foo)\n     if err != nil {\n       ...\n     }\n     ...\n     foo := &pb.Foo{}\n     if err := any.UnmarshalTo(foo); err != nil {\n       ...\n     }\n\nThe pack methods provided by protobuf library will by default use\n'type.googleapis.com/full.type.name' as the type URL and the unpack\nmethods only use the fully qualified type name after the last '/'\nin the type URL, for example \"foo.bar.com/x/y.z\" will yield type\nname \"y.z\".\n\n\nJSON\n\nThe JSON representation of an `Any` value uses the regular\nrepresentation of the deserialized, embedded message, with an\nadditional field `@type` which contains the type URL. Example:\n\n    package google.profile;\n    message Person {\n      string first_name = 1;\n      string last_name = 2;\n    }\n\n    {\n      \"@type\": \"type.googleapis.com/google.profile.Person\",\n      \"firstName\": <string>,\n      \"lastName\": <string>\n    }\n\nIf the embedded message type is well-known and has a custom JSON\nrepresentation, that representation will be embedded adding a field\n`value` which holds the custom JSON in addition to the `@type`\nfield. Example (for message [google.protobuf.Duration][]):\n\n    {\n      \"@type\": \"type.googleapis.com/google.protobuf.Duration\",\n      \"value\": \"1.212s\"\n    }",
-	// 			Properties: map[string]*openapi2.SchemaRef{
-	// 				"@type": {
-	// 					Value: &openapi2.Schema{
-	// 						Type:        &openapi3.Types{"string"},
-	// 						Description: "A URL/resource name that uniquely identifies the type of the serialized\nprotocol buffer message. This string must contain at least\none \"/\" character. The last segment of the URL's path must represent\nthe fully qualified name of the type (as in\n`path/google.protobuf.Duration`). The name should be in a canonical form\n(e.g., leading \".\" is not accepted).\n\nIn practice, teams usually precompile into the binary all types that they\nexpect it to use in the context of Any. However, for URLs which use the\nscheme `http`, `https`, or no scheme, one can optionally set up a type\nserver that maps type URLs to message definitions as follows:\n\n* If no scheme is provided, `https` is assumed.\n* An HTTP GET on the URL must yield a [google.protobuf.Type][]\n  value in binary format, or produce an error.\n* Applications are allowed to cache lookup results based on the\n  URL, or have them precompiled into a binary to avoid any\n  lookup. Therefore, binary compatibility needs to be preserved\n  on changes to types. (Use versioned type names to manage\n  breaking changes.)\n\nNote: this functionality is not currently available in the official\nprotobuf release, and it is not used for type URLs beginning with\ntype.googleapis.com.\n\nSchemes other than `http`, `https` (or the empty scheme) might be\nused with implementation specific semantics.",
-	// 					},
-	// 				},
-	// 			},
-	// 			AdditionalProperties: openapi3.AdditionalProperties{
-	// 				Schema: &openapi3.SchemaRef{
-	// 					Value: &openapi3.Schema{},
-	// 				},
-	// 			},
-	// 		},
-	// 	}
-	// }
-
-	// // Add or update rpcStatus definition
-	// if _, exists := kinSwaggerDoc.Definitions["rpcStatus"]; !exists {
-	// 	kinSwaggerDoc.Definitions["rpcStatus"] = &openapi2.SchemaRef{
-	// 		Value: &openapi2.Schema{
-	// 			Type: &openapi3.Types{"object"},
-	// 			Properties: map[string]*openapi2.SchemaRef{
-	// 				"code": {
-	// 					Value: &openapi2.Schema{
-	// 						Type:   &openapi3.Types{"integer"},
-	// 						Format: "int32",
-	// 					},
-	// 				},
-	// 				"message": {
-	// 					Value: &openapi2.Schema{
-	// 						Type: &openapi3.Types{"string"},
-	// 					},
-	// 				},
-	// 				"details": {
-	// 					Value: &openapi2.Schema{
-	// 						Type: &openapi3.Types{"array"},
-	// 						Items: &openapi2.SchemaRef{
-	// 							Ref: "#/definitions/googleprotobufAny",
-	// 						},
-	// 					},
-	// 				},
-	// 			},
-	// 		},
-	// 	}
-	// }
-
-	// Copy description to summary, deduplicate tags, and add default error response to all operations
+	// Add googleprotobufAny definition if it doesn't exist
+	if _, exists := kinSwaggerDoc.Definitions["googleprotobufAny"]; !exists {
+		kinSwaggerDoc.Definitions["googleprotobufAny"] = &openapi2.SchemaRef{
+			Value: &openapi2.Schema{
+				Type:        &openapi3.Types{"object"},
+				Description: "`Any` contains an arbitrary serialized protocol buffer message along with a\nURL that describes the type of the serialized message.\n\nProtobuf library provides support to pack/unpack Any values in the form\nof utility functions or additional generated methods of the Any type.\n\nExample 1: Pack and unpack a message in C++.\n\n    Foo foo = ...;\n    Any any;\n    any.PackFrom(foo);\n    ...\n    if (any.UnpackTo(&foo)) {\n      ...\n    }\n\nExample 2: Pack and unpack a message in Java.\n\n    Foo foo = ...;\n    Any any = Any.pack(foo);\n    ...\n    if (any.is(Foo.class)) {\n      foo = any.unpack(Foo.class);\n    }\n\nExample 3: Pack and unpack a message in Python.\n\n    foo = Foo(...)\n    any = Any()\n    any.Pack(foo)\n    ...\n    if any.Is(Foo.DESCRIPTOR):\n      any.Unpack(foo)\n      ...\n\nExample 4: Pack and unpack a message in Go\n\n     foo := &pb.Foo{...}\n     any, err := anypb.New(foo)\n     if err != nil {\n       ...\n     }\n     ...\n     foo := &pb.Foo{}\n     if err := any.UnmarshalTo(foo); err != nil {\n       ...\n     }\n\nThe pack methods provided by protobuf library will by default use\n'type.googleapis.com/full.type.name' as the type URL and the unpack\nmethods only use the fully qualified type name after the last '/'\nin the type URL, for example \"foo.bar.com/x/y.z\" will yield type\nname \"y.z\".\n\n\nJSON\n\nThe JSON representation of an `Any` value uses the regular\nrepresentation of the deserialized, embedded message, with an\nadditional field `@type` which contains the type URL. Example:\n\n    package google.profile;\n    message Person {\n      string first_name = 1;\n      string last_name = 2;\n    }\n\n    {\n      \"@type\": \"type.googleapis.com/google.profile.Person\",\n      \"firstName\": <string>,\n      \"lastName\": <string>\n    }\n\nIf the embedded message type is well-known and has a custom JSON\nrepresentation, that representation will be embedded adding a field\n`value` which holds the custom JSON in addition to the `@type`\nfield. Example (for message [google.protobuf.Duration][]):\n\n    {\n      \"@type\": \"type.googleapis.com/google.protobuf.Duration\",\n      \"value\": \"1.212s\"\n    }",
+				Properties: map[string]*openapi2.SchemaRef{
+					"@type": {
+						Value: &openapi2.Schema{
+							Type:        &openapi3.Types{"string"},
+							Description: "A URL/resource name that uniquely identifies the type of the serialized\nprotocol buffer message. This string must contain at least\none \"/\" character. The last segment of the URL's path must represent\nthe fully qualified name of the type (as in\n`path/google.protobuf.Duration`). The name should be in a canonical form\n(e.g., leading \".\" is not accepted).\n\nIn practice, teams usually precompile into the binary all types that they\nexpect it to use in the context of Any. However, for URLs which use the\nscheme `http`, `https`, or no scheme, one can optionally set up a type\nserver that maps type URLs to message definitions as follows:\n\n* If no scheme is provided, `https` is assumed.\n* An HTTP GET on the URL must yield a [google.protobuf.Type][]\n  value in binary format, or produce an error.\n* Applications are allowed to cache lookup results based on the\n  URL, or have them precompiled into a binary to avoid any\n  lookup. Therefore, binary compatibility needs to be preserved\n  on changes to types. (Use versioned type names to manage\n  breaking changes.)\n\nNote: this functionality is not currently available in the official\nprotobuf release, and it is not used for type URLs beginning with\ntype.googleapis.com.\n\nSchemes other than `http`, `https` (or the empty scheme) might be\nused with implementation specific semantics.",
+						},
+					},
+				},
+				AdditionalProperties: openapi3.AdditionalProperties{
+					Schema: &openapi3.SchemaRef{
+						Value: &openapi3.Schema{},
+					},
+				},
+			},
+		}
+	}
+
+	// Add or update rpcStatus definition
+	if _, exists := kinSwaggerDoc.Definitions["rpcStatus"]; !exists {
+		kinSwaggerDoc.Definitions["rpcStatus"] = &openapi2.SchemaRef{
+			Value: &openapi2.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi2.SchemaRef{
+					"code": {
+						Value: &openapi2.Schema{
+							Type:   &openapi3.Types{"integer"},
+							Format: "int32",
+						},
+					},
+					"message": {
+						Value: &openapi2.Schema{
+							Type: &openapi3.Types{"string"},
+						},
+					},
+					"details": {
+						Value: &openapi2.Schema{
+							Type: &openapi3.Types{"array"},
+							Items: &openapi2.SchemaRef{
+								Ref: "#/definitions/googleprotobufAny",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	// Copy description to summary, append gRPC info, deduplicate tags,
+	// and add default error response to all operations
 	for _, path := range kinSwaggerDoc.Paths {
-		copyDescriptionToSummary(path.Delete)
-		copyDescriptionToSummary(path.Get)
-		copyDescriptionToSummary(path.Head)
-		copyDescriptionToSummary(path.Options)
-		copyDescriptionToSummary(path.Patch)
-		copyDescriptionToSummary(path.Post)
-		copyDescriptionToSummary(path.Put)
+		if path == nil {
+			continue
+		}
+
+		// Summaries must be copied before gRPC info is appended to descriptions.
+		if arguments.grpcSummary {
+			copyDescriptionToSummary(path.Delete)
+			copyDescriptionToSummary(path.Get)
+			copyDescriptionToSummary(path.Head)
+			copyDescriptionToSummary(path.Options)
+			copyDescriptionToSummary(path.Patch)
+			copyDescriptionToSummary(path.Post)
+			copyDescriptionToSummary(path.Put)
+		}
+
+		if arguments.grpcAnnotation {
+			appendGRPCInfoToDescription(path.Delete)
+			appendGRPCInfoToDescription(path.Get)
+			appendGRPCInfoToDescription(path.Head)
+			appendGRPCInfoToDescription(path.Options)
+			appendGRPCInfoToDescription(path.Patch)
+			appendGRPCInfoToDescription(path.Post)
+			appendGRPCInfoToDescription(path.Put)
+		}
 
 		deduplicateTags(path.Delete)
 		deduplicateTags(path.Get)
@@ -840,6 +2977,197 @@ func addDefaultErrorResponses(kinSwaggerDoc *openapi2.T) {
 	}
 }
 
+// addSwaggerRelativeBasePathServer 在 Swagger 2.0 到 OpenAPI 3.0 转换时，为没有 host 的文档保留 basePath。
+// 映射关系：
+//   - Swagger 2.0: {basePath: "/v1"}（没有 host）-> OpenAPI 3.0: {servers: [{url: "/v1"}]}
+//   - Swagger 2.0: {host: "example.com", basePath: "/v1"} -> 由 kin-openapi 生成 {servers: [{url: "https://example.com/v1"}]}
+//
+// 操作：如果 host 为空且 basePath 不为空（也不是 "/"），则添加一个相对路径的 server
+// 原因：kin-openapi 只在 host 存在时生成 servers，否则 basePath 会被丢弃
+func addSwaggerRelativeBasePathServer(kinSwaggerDoc *openapi2.T, kinOpenAPIDoc *openapi3.T) {
+	if kinSwaggerDoc.Host != "" || kinSwaggerDoc.BasePath == "" || kinSwaggerDoc.BasePath == "/" {
+		return
+	}
+
+	if len(kinOpenAPIDoc.Servers) == 0 {
+		kinOpenAPIDoc.AddServer(&openapi3.Server{URL: kinSwaggerDoc.BasePath})
+	}
+}
+
+// setSwaggerArrayResponseHeaderStylesFor30 在 Swagger 2.0 到 OpenAPI 3.0 转换时，将数组响应 header 的 collectionFormat 映射为 style。
+// 映射关系：
+//   - Swagger 2.0: {type: "array", collectionFormat: "csv"}（或没有 collectionFormat）-> OpenAPI 3.0: {style: "simple", explode: false, schema: {...}}
+//   - Swagger 2.0: {type: "array", collectionFormat: "ssv"/"tsv"/"pipes"/"multi"} -> {style: "simple", explode: false}，并记录警告
+//
+// 操作：遍历文档级别和每个操作的响应，为数组类型的 header 设置 style 和 explode
+// 原因：kin-openapi 会忽略 collectionFormat，而 OpenAPI 3.0 的 header 只支持 simple（逗号分隔）风格
+func (converter *Converter) setSwaggerArrayResponseHeaderStylesFor30(kinSwaggerDoc *openapi2.T, kinOpenAPIDoc *openapi3.T) {
+	setStyles := func(response *openapi2.Response, responseRef *openapi3.ResponseRef, pointer string) {
+		if response == nil || responseRef == nil || responseRef.Value == nil {
+			return
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(response.Headers)) {
+			header := response.Headers[name]
+			headerRef := responseRef.Value.Headers[name]
+
+			if header == nil || header.Type == nil || !header.Type.Is("array") || headerRef == nil || headerRef.Value == nil {
+				continue
+			}
+
+			explode := false
+			headerRef.Value.Style = openapi3.SerializationSimple
+			headerRef.Value.Explode = &explode
+
+			if header.CollectionFormat != "" && header.CollectionFormat != "csv" {
+				converter.warn(
+					pointer+"/headers/"+escapeJSONPointerToken(name),
+					"collectionFormat %s has no OpenAPI 3.0 header style, using comma separated values",
+					header.CollectionFormat,
+				)
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(kinSwaggerDoc.Responses)) {
+		if kinOpenAPIDoc.Components != nil {
+			setStyles(kinSwaggerDoc.Responses[name], kinOpenAPIDoc.Components.Responses[name], jsonPointer("responses", name))
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(kinSwaggerDoc.Paths)) {
+		pathItem := kinOpenAPIDoc.Paths.Value(path)
+
+		if pathItem == nil || kinSwaggerDoc.Paths[path] == nil {
+			continue
+		}
+
+		operations := kinSwaggerDoc.Paths[path].Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			operation := pathItem.GetOperation(method)
+
+			if operation == nil || operation.Responses == nil {
+				continue
+			}
+
+			for _, code := range slices.Sorted(maps.Keys(operations[method].Responses)) {
+				pointer := jsonPointer("paths", path, strings.ToLower(method), "responses", code)
+				setStyles(operations[method].Responses[code], operation.Responses.Value(code), pointer)
+			}
+		}
+	}
+}
+
+// recordStage 记录从 start 开始到现在的阶段耗时，并返回当前时间作为下一阶段的开始时间。
+//
+// 用法：
+//
+//	stageStart := time.Now()
+//	// ... 执行 load 阶段 ...
+//	stageStart = converter.recordStage("3.0 -> 3.1", "load", stageStart)
+func (converter *Converter) recordStage(conversion string, stage string, start time.Time) time.Time {
+	now := time.Now()
+
+	converter.timings = append(converter.timings, StageTiming{
+		Conversion: conversion,
+		Stage:      stage,
+		Duration:   now.Sub(start),
+	})
+
+	return now
+}
+
+// warn 记录一条有损转换警告。
+func (converter *Converter) warn(path string, format string, args ...any) {
+	converter.warnings = append(converter.warnings, Warning{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// printWarnings 将收集到的有损转换警告打印到 w，每条警告一行。
+// 设置了 --log-format json 时，每条警告是一个 level 为 "warning" 的 logEntry
+func (converter *Converter) printWarnings(w io.Writer) error {
+	for _, warning := range converter.warnings {
+		if converter.arguments.logJSON {
+			entry := logEntry{Level: "warning", Message: warning.Message, Line: warning.Line, Path: warning.Path}
+
+			if err := writeLogEntry(w, entry); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		location := warning.Path
+
+		if warning.Line > 0 {
+			location = fmt.Sprintf("line %d: %s", warning.Line, warning.Path)
+		}
+
+		if _, err := fmt.Fprintf(w, "Warning: %s: %s\n", location, warning.Message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeWarningsFile 将收集到的有损转换警告以 Warning 的 JSON 数组写入 filename，没有警告时写入空数组。
+func (converter *Converter) writeWarningsFile(filename string) error {
+	warnings := converter.warnings
+
+	if warnings == nil {
+		warnings = []Warning{}
+	}
+
+	data, err := json.MarshalIndent(warnings, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// printTimings 将收集到的各阶段耗时打印到 w。
+// 输出格式：
+//   - 默认每个阶段一行文本，例如 "3.1 -> 3.0 load: 1.2ms"
+//   - 如果设置了 --log-format json，则每个阶段一个 level 为 "info" 的 logEntry（同时设置了 --timings-json 时也是如此，
+//     使标准错误输出的每一行都是一条日志）
+//   - 否则如果设置了 --timings-json，则输出一个 StageTiming 的 JSON 数组
+func (converter *Converter) printTimings(w io.Writer) error {
+	if converter.arguments.timingsJSON && !converter.arguments.logJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+
+		return encoder.Encode(converter.timings)
+	}
+
+	for _, timing := range converter.timings {
+		message := fmt.Sprintf("%s %s: %v", timing.Conversion, timing.Stage, timing.Duration)
+
+		if converter.arguments.logJSON {
+			entry := logEntry{
+				Level:      "info",
+				Message:    message,
+				Conversion: timing.Conversion,
+				Stage:      timing.Stage,
+				Duration:   timing.Duration,
+			}
+
+			if err := writeLogEntry(w, entry); err != nil {
+				return err
+			}
+		} else if _, err := fmt.Fprintln(w, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // convertSwaggerToOpenAPI30 将 Swagger 2.0 文档转换为 OpenAPI 3.0 文档。
 // 主要结构映射（由 kin-openapi 库处理）：
 //  1. swagger: "2.0" -> openapi: "3.0.x"
@@ -852,15 +3180,48 @@ func addDefaultErrorResponses(kinSwaggerDoc *openapi2.T) {
 //  8. operation.consumes/produces -> operation.requestBody.content / operation.responses[].content（媒体类型映射）
 //
 // 操作流程：
-//  1. 检测输入格式（YAML/JSON），如果是 YAML 则先转换为 JSON（kin-openapi 无法正确解析 YAML）
+//  1. 检测输入格式（YAML/JSON，可以通过 --input-format 强制指定），如果是 YAML 则先转换为 JSON（kin-openapi 无法正确解析 YAML）
 //  2. 使用 openapispecconverter.UnmarshalSwagger 解析 Swagger 2.0 文档
 //  3. 使用 openapi2conv.ToV3 转换为 OpenAPI 3.0 文档
-//  4. 返回 JSON 格式的 OpenAPI 3.0 文档
-func convertSwaggerToOpenAPI30(data []byte) ([]byte, error) {
+//  4. 为没有 host 的文档将 basePath 转换为相对路径的 server
+//  5. 为数组类型的响应 header 设置 style（见 setSwaggerArrayResponseHeaderStylesFor30）
+//  6. 将参数和 $ref 同级的 x-nullable 转换为 nullable（见 convertSwaggerXNullableRefs 和 setSwaggerParameterNullablesFor30）
+//  7. 移除 schema 的 required 中重复的属性名称（见 dedupeRequiredPropertyNodes）
+//  8. 返回 JSON 格式的 OpenAPI 3.0 文档（openapi 字段是 kin-openapi 输出的 3.0.3）
+func (converter *Converter) convertSwaggerToOpenAPI30(data []byte) ([]byte, error) {
+	const conversion = "swagger -> 3.0"
 	var kinSwaggerDoc openapi2.T
 
+	converter.steps = append(converter.steps, conversion)
+	stageStart := time.Now()
 	dataFormat := checkDataFormat(data)
 
+	// Swagger is only ever read from the input, so honour --input-format,
+	// unless pre-processing has already rendered the input as YAML.
+	if converter.arguments.inputFormat != nil && !converter.hasPreProcessSteps() {
+		dataFormat = *converter.arguments.inputFormat
+	}
+
+	// kin-openapi drops the siblings of `$ref`, so rewrite x-nullable references first.
+	if root, err := parseDocumentNode(data); err == nil && convertSwaggerXNullableRefs(root) {
+		if data, err = renderDocumentNode(root); err != nil {
+			return nil, fmt.Errorf("Error rendering document: %w", err)
+		}
+
+		dataFormat = YAML
+		stageStart = converter.recordStage(conversion, "nullable references", stageStart)
+	}
+
+	// Remove duplicate required entries some generators emit, as the 3.0 -> 3.1 schema walk does.
+	if root, err := parseDocumentNode(data); err == nil && dedupeRequiredPropertyNodes(root) {
+		if data, err = renderDocumentNode(root); err != nil {
+			return nil, fmt.Errorf("Error rendering document: %w", err)
+		}
+
+		dataFormat = YAML
+		stageStart = converter.recordStage(conversion, "required properties", stageStart)
+	}
+
 	// kin-openapi cannot unmarshal YAML correctly, so we have to first convert input to JSON.
 	if dataFormat != JSON {
 		var err error
@@ -869,17 +3230,58 @@ func convertSwaggerToOpenAPI30(data []byte) ([]byte, error) {
 		if err != nil {
 			return nil, fmt.Errorf("Error converting Swagger YAML to JSON: %w", err)
 		}
+
+		stageStart = converter.recordStage(conversion, "yaml to json", stageStart)
 	}
 
 	if err := openapispecconverter.UnmarshalSwagger(data, &kinSwaggerDoc); err != nil {
 		return nil, fmt.Errorf("Error loading Swagger data: %w", err)
 	}
 
-	if kinOpenAPIDoc, err := openapi2conv.ToV3(&kinSwaggerDoc); err == nil {
-		return kinOpenAPIDoc.MarshalJSON()
-	} else {
+	stageStart = converter.recordStage(conversion, "load", stageStart)
+
+	kinOpenAPIDoc, err := openapi2conv.ToV3(&kinSwaggerDoc)
+
+	if err != nil {
 		return nil, fmt.Errorf("Error converting Swagger to 3.0 %w", err)
 	}
+
+	// kin-openapi only creates servers when there's a host, so keep relative base paths.
+	addSwaggerRelativeBasePathServer(&kinSwaggerDoc, kinOpenAPIDoc)
+
+	// kin-openapi ignores collectionFormat, so set styles for array response headers.
+	converter.setSwaggerArrayResponseHeaderStylesFor30(&kinSwaggerDoc, kinOpenAPIDoc)
+
+	// kin-openapi leaves x-nullable on parameters instead of their schemas.
+	setSwaggerParameterNullablesFor30(kinOpenAPIDoc)
+
+	stageStart = converter.recordStage(conversion, "kin conversion", stageStart)
+	data, err = kinOpenAPIDoc.MarshalJSON()
+	converter.recordStage(conversion, "marshal", stageStart)
+
+	return data, err
+}
+
+// marshalSwaggerDocument 将 kin-openapi 的 Swagger 2.0 文档序列化为指定格式。
+// 输出格式：
+//   - JSON: 直接使用 kin-openapi 的 MarshalJSON
+//   - YAML: 通过 yaml.v3 节点树渲染，字段按 Swagger 规范顺序排列，标量保留原始类型（例如 swagger: "2.0" 是字符串）
+func marshalSwaggerDocument(kinSwaggerDoc *openapi2.T, format Format) ([]byte, error) {
+	data, err := kinSwaggerDoc.MarshalJSON()
+
+	if err != nil || format != YAML {
+		return data, err
+	}
+
+	root, err := parseDocumentNode(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	orderSwaggerRootKeys(root)
+
+	return renderDocumentNode(root)
 }
 
 // convertOpenAPI30ToSwagger 将 OpenAPI 3.0 文档转换为 Swagger 2.0 文档。
@@ -890,30 +3292,45 @@ func convertSwaggerToOpenAPI30(data []byte) ([]byte, error) {
 //  4. components.responses -> responses（组件响应移到全局 responses）
 //  5. components.securitySchemes -> securityDefinitions（安全方案移到全局 securityDefinitions）
 //  6. operation.requestBody -> operation.parameters（requestBody 转为 body 参数）
-//  7. operation.requestBody.content / operation.responses[].content -> operation.consumes/produces（媒体类型映射）
+//  7. operation.requestBody.content / operation.responses[].content -> operation.consumes/produces（媒体类型映射，
+//     设置了 --single-consumes 时只保留一个媒体类型，见 singleSwaggerMediaTypes）
 //
 // 字段映射处理：
 //  1. schema.Required + schema.ReadOnly -> schema.Required（移除同时为 readonly 的 required 属性）
+//     schema.ReadOnly + schema.WriteOnly（同时为 true）-> schema.ReadOnly（删除 writeOnly 并记录警告）
 //  2. content.Schema (nil) -> content.Schema ({type: "object"})（为 nil schema 添加默认值）
-//  3. content["application/octet-stream"].Schema -> parameters[].Schema ({type: "string", format: "binary"})（文件上传格式修复）
-//  4. operation.Responses -> operation.Responses["default"]（添加默认错误响应）
-//  5. definitions -> definitions["rpcStatus"] 和 definitions["googleprotobufAny"]（添加 gRPC 标准定义）
+//  3. responses[].content（多个媒体类型）-> responses[].schema + operation.produces（按 --response-media 选择一个媒体类型）
+//  4. paths[].servers / operation.servers -> 丢弃并记录警告（Swagger 2.0 没有对应字段）
+//     components.securitySchemes 中 type 为 openIdConnect 的方案 -> 丢弃并记录警告，并从 security 中移除对它的引用
+//     servers[0].description -> x-server-description（--keep-server-description），否则丢弃并记录警告
+//     parameters[].allowEmptyValue -> 复制到 query 参数；parameters[].allowReserved -> 丢弃并记录警告
+//  5. content["application/octet-stream"].Schema -> parameters[].Schema ({type: "string", format: "binary"})（文件上传格式修复）
+//  6. operation.Responses -> operation.Responses["default"]（添加默认错误响应）
+//     operation.Responses["2XX"] -> operation.Responses["200"]（范围响应码，"200" 已存在时丢弃并记录警告）
+//  7. definitions -> definitions["rpcStatus"] 和 definitions["googleprotobufAny"]（添加 gRPC 标准定义）
 //
 // 操作流程：
 //  1. 使用 libopenapi 加载并构建 OpenAPI 3.0 文档模型
 //  2. 修复 schema 中的 required/readonly 冲突
-//  3. 确保所有 requestBody content 都有有效的 schema
+//  3. 确保所有 requestBody content 都有有效的 schema，为每个响应选择一个媒体类型
 //  4. 重新渲染并重新加载文档
-//  5. 使用 kin-openapi 的 FromV3 转换为 Swagger 2.0
-//  6. 修复文件上传格式和添加默认错误响应
-//  7. 返回 JSON 格式的 Swagger 2.0 文档
-func convertOpenAPI30ToSwagger(data []byte) ([]byte, error) {
+//  5. 使用 kin-openapi 加载（设置了 --preserve-examples-format 时先将 YAML 转换为 JSON），并使用 FromV3 转换为 Swagger 2.0
+//  6. 修复文件上传格式、设置 produces（--produces-default 为没有 produces 的操作设置）和添加默认错误响应
+//  7. 返回 Swagger 2.0 文档（输出格式为 YAML 时直接渲染为 YAML，见 marshalSwaggerDocument）
+func (converter *Converter) convertOpenAPI30ToSwagger(data []byte) ([]byte, error) {
+	const conversion = "3.0 -> swagger"
+
+	converter.steps = append(converter.steps, conversion)
+	stageStart := time.Now()
+	input := data
 	doc, err := libopenapi.NewDocument(data)
 
 	if err != nil {
 		return nil, fmt.Errorf("Error loading document: %w", err)
 	}
 
+	stageStart = converter.recordStage(conversion, "load", stageStart)
+
 	// Build the document in libopenapi so we can modify the document
 	// to correct issues not handled by kin-openapi.
 	model, errs := doc.BuildV3Model()
@@ -922,42 +3339,133 @@ func convertOpenAPI30ToSwagger(data []byte) ([]byte, error) {
 		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
 	}
 
+	stageStart = converter.recordStage(conversion, "build", stageStart)
+
+	// Parameters can't have both forms, and Swagger can only describe the schema.
+	converter.drop30ParameterContentWithSchema(model)
+
+	// Swagger has no local path item references, so resolve them with libopenapi.
+	// They are resolved first, so the inlined path items are also updated.
+	converter.inline30PathItemRefsForSwagger(model)
+
+	pointers := nodeJSONPointers(doc.GetSpecInfo().RootNode)
+
 	updateAllSchema(model, func(schema *base.Schema) {
+		// A property can't be both, so readOnly wins before `required` is fixed.
+		converter.resolve30ReadOnlyAndWriteOnlyForSwagger(schema, pointers)
 		// We must make every property that is both required and also readonly
 		// only be readonly, or they will break Swagger validation.
 		make30RequiredAndReadonlyPropertiesOnlyReadonly(schema)
 	})
 
-	// Ensure all request body content has valid schemas before conversion
+	// Ensure all request body and response content has valid schemas before conversion
 	// kin-openapi's FromV3 converter cannot handle nil schemas
 	ensureRequestBodyContentSchemas(model)
+	ensureResponseContentSchemas(model)
+
+	// kin-openapi only reads `application/json` response content, so pick one
+	// media type for each response and remember it for `produces`.
+	produces, responseMediaTypes := converter.select30ResponseMediaTypesForSwagger(model)
+
+	// Media types are selected first, so only the shared response is warned about.
+	if converter.arguments.inlineResponses {
+		inline30ResponseRefsForSwagger(model)
+	}
+
+	converter.warn30PathServersDroppedForSwagger(model)
+	converter.warn30EncodingHeadersDroppedForSwagger(model)
+
+	stageStart = converter.recordStage(conversion, "schema walk", stageStart)
+	data, doc, model, errs = doc.RenderAndReload()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	stageStart = converter.recordStage(conversion, "render and reload", stageStart)
+
+	// libopenapi can render large integer bounds as YAML which can't be read again.
+	// Ambiguous integers are reported once the Swagger document is restored in convertDocument.
+	if data, _, err = restoreIntegerLiterals(input, data); err != nil {
+		return nil, fmt.Errorf("Error restoring integers: %w", err)
+	}
+
+	// kin-openapi decodes unquoted YAML dates in examples as timestamps,
+	// e.g. 2024-01-02 -> "2024-01-02T00:00:00Z", so load the document from JSON
+	// when the examples have to be kept as they were written.
+	if converter.arguments.keepExamples && checkDataFormat(data) != JSON {
+		if data, err = ghodssYaml.YAMLToJSON(data); err != nil {
+			return nil, fmt.Errorf("Error converting 3.0 YAML to JSON: %w", err)
+		}
+
+		stageStart = converter.recordStage(conversion, "yaml to json", stageStart)
+	}
+
+	kinOpenAPIDoc, err := openapi3.NewLoader().LoadFromData(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error Load 3.0 for converting to Swagger %w", err)
+	}
 
-	data, doc, model, errs = doc.RenderAndReload()
+	// FromV3 dereferences the info without checking it exists.
+	if kinOpenAPIDoc.Info == nil {
+		return nil, fmt.Errorf("Error converting 3.0 to Swagger: the document has no info, use --ensure-info to add one")
+	}
 
-	if len(errs) > 0 {
-		return nil, errors.Join(errs...)
+	// FromV3 reads the components without checking they exist.
+	if kinOpenAPIDoc.Components == nil {
+		kinOpenAPIDoc.Components = &openapi3.Components{}
 	}
 
-	var kinSwaggerDoc *openapi2.T
+	// FromV3 would output OpenID Connect schemes without a Swagger type.
+	converter.drop30OpenIDConnectSchemesForSwagger(kinOpenAPIDoc)
 
-	if kinOpenAPIDoc, err := openapi3.NewLoader().LoadFromData(data); err == nil {
-		kinSwaggerDoc, err = openapi2conv.FromV3(kinOpenAPIDoc)
+	kinSwaggerDoc, err := openapi2conv.FromV3(kinOpenAPIDoc)
 
-		if err != nil {
-			return nil, fmt.Errorf("Error converting 3.0 to Swagger %w", err)
-		}
-	} else {
-		return nil, fmt.Errorf("Error Load 3.0 for converting to Swagger %w", err)
+	if err != nil {
+		return nil, fmt.Errorf("Error converting 3.0 to Swagger %w", err)
 	}
 
+	stageStart = converter.recordStage(conversion, "kin conversion", stageStart)
+
 	// The kin-openapi Swagger converter doesn't add {schema: {type: "string", format: "binary"}}
 	// when creating upload specs for binary content. We need to add it back in again.
 	fixSwaggerDocUploadFormats(kinSwaggerDoc)
 
+	setSwaggerOperationProduces(kinSwaggerDoc, produces)
+
+	if len(converter.arguments.defaultProduces) > 0 {
+		setSwaggerDefaultProduces(kinSwaggerDoc, converter.arguments.defaultProduces)
+	}
+
+	// kin-openapi picks the body schema from a random request media type.
+	// This isn't limited to --single-consumes, as the output would change between runs.
+	setSwaggerRequestBodySchemas(kinOpenAPIDoc, kinSwaggerDoc)
+
+	if converter.arguments.singleConsumes {
+		converter.singleSwaggerMediaTypes(kinSwaggerDoc)
+	}
+
+	// host and basePath come from the first server, which loses its description.
+	converter.setSwaggerServerDescription(kinOpenAPIDoc, kinSwaggerDoc)
+
+	// kin-openapi doesn't copy allowEmptyValue, and drops allowReserved.
+	converter.setSwaggerParameterFlags(kinOpenAPIDoc, kinSwaggerDoc)
+
+	// kin-openapi drops response examples, so copy them into Swagger's `examples`.
+	converter.setSwaggerResponseExamples(kinOpenAPIDoc, kinSwaggerDoc, responseMediaTypes)
+
+	// Swagger has no range response codes like `2XX`.
+	converter.convertSwaggerRangeResponseCodes(kinSwaggerDoc)
+
 	// Add default error response to all operations
-	addDefaultErrorResponses(kinSwaggerDoc)
+	addDefaultErrorResponses(kinSwaggerDoc, converter.arguments)
+
+	stageStart = converter.recordStage(conversion, "swagger fixups", stageStart)
+	data, err = marshalSwaggerDocument(kinSwaggerDoc, converter.arguments.outputFormat)
+	converter.recordStage(conversion, "marshal", stageStart)
 
-	return kinSwaggerDoc.MarshalJSON()
+	return data, err
 }
 
 // convertOpenAPI30To31 将 OpenAPI 3.0 文档转换为 OpenAPI 3.1 文档。
@@ -969,21 +3477,47 @@ func convertOpenAPI30ToSwagger(data []byte) ([]byte, error) {
 //  5. schema.Example -> schema.Examples 数组
 //  6. schema.Format -> lowSchema.ContentMediaType 或 lowSchema.ContentEncoding
 //  7. content["application/octet-stream"].Schema -> null（清除）
+//  8. schema.Required 中重复的属性名称 -> 移除（保留第一次出现的顺序）
 //
 // 参考：https://www.openapis.org/blog/2021/02/16/migrating-from-openapi-3-0-to-3-1-0
-func convertOpenAPI30To31(data []byte) ([]byte, error) {
+func (converter *Converter) convertOpenAPI30To31(data []byte) ([]byte, error) {
+	const conversion = "3.0 -> 3.1"
+
+	converter.steps = append(converter.steps, conversion)
+	stageStart := time.Now()
+
+	// libopenapi keeps `$ref` schemas as they are, so rewrite nullable references first.
+	if root, err := parseDocumentNode(data); err == nil {
+		changed := convert30NullableRefsTo31AnyOf(root)
+
+		if converter.arguments.nullableAnyOf {
+			changed = convert30NullablesTo31AnyOf(root) || changed
+		}
+
+		if changed {
+			if data, err = renderDocumentNode(root); err != nil {
+				return nil, fmt.Errorf("Error rendering document: %w", err)
+			}
+
+			stageStart = converter.recordStage(conversion, "nullable references", stageStart)
+		}
+	}
+
 	doc, err := libopenapi.NewDocument(data)
 
 	if err != nil {
 		return nil, fmt.Errorf("Error loading document: %w", err)
 	}
 
+	stageStart = converter.recordStage(conversion, "load", stageStart)
 	model, errs := doc.BuildV3Model()
 
 	if len(errs) > 0 {
 		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
 	}
 
+	stageStart = converter.recordStage(conversion, "build", stageStart)
+
 	// See: https://www.openapis.org/blog/2021/02/16/migrating-from-openapi-3-0-to-3-1-0
 	//
 	// The following changes need to be made.
@@ -995,11 +3529,14 @@ func convertOpenAPI30To31(data []byte) ([]byte, error) {
 	// 5. Modify file upload schemas.
 
 	// 1. Change the `openapi` version to 3.1.x.
-	model.Model.Version = "3.1.1"
+	model.Model.Version = outputVersionString(OpenAPI31)
 
 	// Before scanning all schema, apply step 5. early to clear schema for request bodies.
 	clear30RequestFileContentSchemaFor31(model)
 
+	// Parameters can't have both forms, so only the schema is converted and kept.
+	converter.drop30ParameterContentWithSchema(model)
+
 	updateAllSchema(model, func(schema *base.Schema) {
 		// 2. Swap nullable for type arrays.
 		convert30NullablesTo31TypeArrays(schema)
@@ -1009,14 +3546,19 @@ func convertOpenAPI30To31(data []byte) ([]byte, error) {
 		convert30ExampleTo31Examples(schema)
 		// 5. Modify file upload schemas.
 		convert30FormatsTo31ContentFields(schema)
+		// Remove duplicate required entries some generators emit.
+		dedupeRequiredProperties(schema)
 	})
 
+	stageStart = converter.recordStage(conversion, "schema walk", stageStart)
 	data, doc, model, errs = doc.RenderAndReload()
 
 	if len(errs) > 0 {
 		return nil, errors.Join(errs...)
 	}
 
+	converter.recordStage(conversion, "render and reload", stageStart)
+
 	return data, nil
 }
 
@@ -1030,41 +3572,67 @@ func convertOpenAPI30To31(data []byte) ([]byte, error) {
 //  6. lowSchema.ContentMediaType / lowSchema.ContentEncoding -> schema.Format
 //  7. content["application/octet-stream"].Schema (null) -> content["application/octet-stream"].Schema ({type: "string", format: "binary"})
 //  8. model.Model.JsonSchemaDialect -> ""（移除 3.1 特有字段）
-//  9. model.Model.Webhooks -> nil（移除 3.1 特有字段）
-//  10. model.Model.Info.Summary -> ""（移除 3.1 特有字段）
+//  9. model.Model.Webhooks -> nil（移除 3.1 特有字段，丢弃之前记录列出每个 webhook 的警告）
+//  10. model.Model.Info.Summary -> ""（移除 3.1 特有字段，设置了 --preserve-info-summary 时先添加到 Info.Description 的开头）
+//  11. paths[].$ref -> components.pathItems 的内联内容（移除 3.1 特有的 components.pathItems）
 //
 // 操作流程：
 //  1. 使用 libopenapi 加载并构建 OpenAPI 3.1 文档模型
 //  2. 修改版本号为 3.0.4
-//  3. 为文件上传请求体添加 schema
+//  3. 为文件上传请求体添加 schema，内联引用 components.pathItems 的路径
 //  4. 递归更新所有 schema：类型数组、最小值/最大值、示例、格式字段
 //  5. 移除 3.1 特有的字段（JsonSchemaDialect、Webhooks、Info.Summary）
 //  6. 重新渲染并重新加载文档
 //  7. 返回转换后的 OpenAPI 3.0 文档
-func convertOpenAPI31To30(data []byte) ([]byte, error) {
+func (converter *Converter) convertOpenAPI31To30(data []byte) ([]byte, error) {
+	const conversion = "3.1 -> 3.0"
+
+	converter.steps = append(converter.steps, conversion)
+	stageStart := time.Now()
 	doc, err := libopenapi.NewDocument(data)
 
 	if err != nil {
 		return nil, fmt.Errorf("Error loading document: %w", err)
 	}
 
+	stageStart = converter.recordStage(conversion, "load", stageStart)
 	model, errs := doc.BuildV3Model()
 
 	if len(errs) > 0 {
 		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
 	}
 
+	stageStart = converter.recordStage(conversion, "build", stageStart)
+
 	// We need to perform the inverse of the conversion steps in the 3.0 to 3.1 function.
 
 	// 1. Change the `openapi` version to 3.0.x
-	model.Model.Version = "3.0.4"
+	model.Model.Version = outputVersionString(OpenAPI30)
 
 	// Before scanning all schema, apply step 5. early to schema schema for file uploads where needed.
 	set31RequestFileContentSchemaFor30(model)
 
+	// Inline path items referencing `components.pathItems`, which only exists in 3.1.
+	inline31ComponentPathItemsFor30(model)
+
+	// Parameters can't have both forms, so only the schema is converted and kept.
+	converter.drop30ParameterContentWithSchema(model)
+
+	pointers := nodeJSONPointers(doc.GetSpecInfo().RootNode)
+
 	updateAllSchema(model, func(schema *base.Schema) {
+		// Some tools wrongly emit `examples` as an object keyed by name.
+		converter.take31KeyedSchemaExamples(schema, pointers)
+		// Tuples can only be described as arrays of any of the item schemas.
+		converter.convert31PrefixItemsTo30(schema, pointers)
+		converter.warn31AdditionalItemsDroppedFor30(schema, pointers)
+		// Conditionals can be written with `oneOf` and `not` instead.
+		converter.convert31ConditionalsTo30(schema, pointers)
+		converter.remove31ContainsFor30(schema, pointers)
 		// 2. Swap type arrays for either `nullable` or `oneOf`
-		convert31TypeArraysTo30(schema)
+		converter.convert31TypeArraysTo30(schema, pointers)
+		// Treat `anyOf`/`oneOf` with a `{type: "null"}` branch the same way.
+		collapse31NullBranchesTo30(schema)
 		// 3. Replace `minimum` and `exclusiveMinimum`, and `maximum` and `exclusiveMaximum`.
 		convert31MinMaxTo30(schema)
 		// 4. Replace `examples` with `example` wherever we see it.
@@ -1075,21 +3643,348 @@ func convertOpenAPI31To30(data []byte) ([]byte, error) {
 
 	// We must remove additional properties only used in 3.1.
 	model.Model.JsonSchemaDialect = ""
+	converter.warn31WebhooksDroppedFor30(model)
 	model.Model.Webhooks = nil
 
+	if converter.arguments.infoSummary {
+		merge31InfoSummaryIntoDescriptionFor30(model.Model.Info)
+	}
+
 	if model.Model.Info != nil {
 		model.Model.Info.Summary = ""
 	}
 
+	stageStart = converter.recordStage(conversion, "schema walk", stageStart)
 	data, doc, model, errs = doc.RenderAndReload()
 
 	if len(errs) > 0 {
 		return nil, errors.Join(errs...)
 	}
 
+	converter.recordStage(conversion, "render and reload", stageStart)
+
 	return data, nil
 }
 
+// fixPathKeys 为 paths 中缺少前导 "/" 的路径添加 "/"，并为每个修改的路径记录警告。
+// 映射关系：
+//   - {paths: {"pets": {...}}} -> {paths: {"/pets": {...}}}
+//
+// 注意：x- 扩展不会被修改；如果添加 "/" 之后的路径已经存在，则保留原来的路径并记录警告
+func (converter *Converter) fixPathKeys(root *yaml.Node) {
+	paths := mappingValue(root, "paths")
+
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		key := paths.Content[i]
+
+		if strings.HasPrefix(key.Value, "/") || strings.HasPrefix(key.Value, "x-") {
+			continue
+		}
+
+		fixed := "/" + key.Value
+
+		if mappingValue(paths, fixed) != nil {
+			converter.warn(jsonPointer("paths", key.Value), "path has no leading slash, but %s already exists", fixed)
+			continue
+		}
+
+		converter.warn(jsonPointer("paths", key.Value), "added a leading slash to the path, using %s", fixed)
+		key.Value = fixed
+	}
+}
+
+// hasPreProcessSteps 判断是否启用了任何预处理步骤，启用时 preProcessDocument 会将输入渲染为 YAML。
+func (converter *Converter) hasPreProcessSteps() bool {
+	arguments := converter.arguments
+
+	return arguments.fixPaths || arguments.mergeSlashPaths || arguments.ensureInfo || arguments.remoteRefs ||
+		arguments.assumedVersion != nil || len(arguments.mergeBase) > 0
+}
+
+// preProcessDocument 在转换之前对输入文档执行可选的预处理步骤。
+// 预处理步骤：
+//  1. --assume-version: 为没有 swagger 或 openapi 字段的文档添加假定的版本（见 assumeDocumentVersion）
+//  2. --merge-base: 合并基础文档中的 info、servers、security、tags 和 components（见 mergeBaseDocument）
+//  3. --allow-remote-refs: 下载并内联指向 http(s) URL 的 $ref（见 bundleRemoteRefs）
+//  4. --fix-paths: 为缺少前导 "/" 的路径添加 "/"（见 fixPathKeys）
+//  5. --merge-trailing-slash: 将以 "/" 结尾的路径合并到没有结尾 "/" 的相同路径中（见 mergeTrailingSlashPaths）
+//  6. --ensure-info: 为没有 info 的文档添加最小的 info，并补充缺少的 title 和 version（见 ensureDocumentInfo）
+//
+// 操作：将文档解析为 YAML 节点树，执行启用的步骤，然后渲染为 YAML
+func (converter *Converter) preProcessDocument(data []byte) ([]byte, error) {
+	const conversion = "pre-process"
+
+	if !converter.hasPreProcessSteps() {
+		return data, nil
+	}
+
+	stageStart := time.Now()
+
+	if version := converter.arguments.assumedVersion; version != nil {
+		root, err := parseDocumentNode(data)
+
+		if err != nil {
+			return nil, fmt.Errorf("Error loading input document: %w", err)
+		}
+
+		if assumeDocumentVersion(root, *version) {
+			converter.warn("#", "the document has no swagger or openapi version, assuming %s", outputVersionString(*version))
+
+			if data, err = renderDocumentNode(root); err != nil {
+				return nil, err
+			}
+
+			converter.steps = append(converter.steps, "assume version")
+		}
+
+		stageStart = converter.recordStage(conversion, "assume version", stageStart)
+	}
+
+	if len(converter.arguments.mergeBase) > 0 {
+		root, err := parseDocumentNode(data)
+
+		if err != nil {
+			return nil, fmt.Errorf("Error loading input document: %w", err)
+		}
+
+		base, err := readBaseDocument(converter.arguments.mergeBase)
+
+		if err != nil {
+			return nil, fmt.Errorf("Error reading base document: %w", err)
+		}
+
+		if err = mergeBaseDocument(root, base); err != nil {
+			return nil, err
+		}
+
+		if data, err = renderDocumentNode(root); err != nil {
+			return nil, err
+		}
+
+		converter.steps = append(converter.steps, "merge base")
+		stageStart = converter.recordStage(conversion, "merge base", stageStart)
+	}
+
+	if converter.arguments.remoteRefs {
+		var err error
+
+		if data, err = bundleRemoteRefs(data); err != nil {
+			return nil, fmt.Errorf("Error bundling remote references: %w", err)
+		}
+
+		converter.steps = append(converter.steps, "bundle remote refs")
+		stageStart = converter.recordStage(conversion, "bundle remote refs", stageStart)
+	}
+
+	root, err := parseDocumentNode(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading input document: %w", err)
+	}
+
+	stageStart = converter.recordStage(conversion, "load", stageStart)
+
+	if converter.arguments.fixPaths {
+		converter.fixPathKeys(root)
+		converter.steps = append(converter.steps, "fix paths")
+		stageStart = converter.recordStage(conversion, "fix paths", stageStart)
+	}
+
+	if converter.arguments.mergeSlashPaths {
+		converter.mergeTrailingSlashPaths(root)
+		converter.steps = append(converter.steps, "merge trailing slash paths")
+		stageStart = converter.recordStage(conversion, "merge trailing slash paths", stageStart)
+	}
+
+	if converter.arguments.ensureInfo {
+		converter.ensureDocumentInfo(root)
+		converter.steps = append(converter.steps, "ensure info")
+		stageStart = converter.recordStage(conversion, "ensure info", stageStart)
+	}
+
+	data, err = renderDocumentNode(root)
+	converter.recordStage(conversion, "render", stageStart)
+
+	return data, err
+}
+
+// postProcessDocument 在转换完成后对文档执行可选的后处理步骤。
+// 后处理步骤（按执行顺序）：
+//  1. --flatten-allof: 将 Swagger 文档中单层的 allOf 合并为扁平的 schema（见 flattenSwaggerAllOf）
+//  2. --polyfill-nullable-enum: 为 OpenAPI 3.0 中 nullable 的 schema 的 enum 添加 null（见 polyfill30NullableEnums）
+//  3. --flatten-nullable-oneof: 将 OpenAPI 3.0 中只包含基本类型的 oneOf 合并为一个类型（见 flatten30PrimitiveOneOf）
+//  4. --drop-unsupported: 删除 OpenAPI 3.0 不支持的 schema 关键字、mutualTLS 安全方案和 license.identifier（见 dropUnsupportedFeatures）
+//  5. --upgrade-int-formats: 为 OpenAPI 3.1 中 format 为 int32 或 int64 的 integer schema 添加 minimum 和 maximum（见 add31IntegerFormatBounds）
+//  6. --examples-from-default: 为 OpenAPI 3.1 中有 default 但没有示例的 schema 添加 examples（见 add31ExamplesFromDefaults）
+//  7. --strip-ext: 删除名称以指定前缀开头的扩展（见 stripExtensions）
+//  8. --strip-readonly / --strip-writeonly: 删除 readOnly 或 writeOnly 的属性（见 stripFlaggedProperties）
+//  9. --map-format: 重写 schema 的 format（见 mapSchemaFormats）
+//  10. --dedupe-schemas: 将结构相同的 inline schema 提升到 components 中（见 dedupeSchemas），之前先为没有 mapping 的 discriminator 添加显式的 mapping（见 materializeDiscriminatorMappings）
+//  11. --sanitize-names: 将 Swagger definitions 名称中的特殊字符替换为 "_" 并更新 $ref（见 sanitizeSwaggerDefinitionNames）
+//  12. --declare-tags: 为操作使用但没有声明的标签在根对象的 tags 中添加条目（见 declareOperationTags）
+//  13. --require-operation-id: 检查每个操作都有 operationId，否则返回错误（见 requireOperationIDs）
+//  14. --fail-unknown-keywords: 检查 schema 中没有目标版本不支持的关键字，否则返回错误（见 failUnknownKeywords）
+//  15. --host / --base-path / --schemes: 设置 Swagger 文档的 host、basePath 和 schemes（见 setSwaggerLocation）
+//  16. --clean: 删除根对象和 components 中为空的字段，例如 components.schemas: {}（见 removeEmptySections）
+//  17. --output-openapi-version: 设置输出文档的 swagger 或 openapi 字段值（见 setDocumentVersion）
+//
+// 操作：将文档解析为 YAML 节点树，执行启用的步骤，然后渲染为 YAML
+// 原因：这些步骤同时适用于 Swagger 2.0 和 OpenAPI 3.x 输出，直接修改节点树比分别修改两种模型更简单
+func (converter *Converter) postProcessDocument(data []byte) ([]byte, error) {
+	const conversion = "post-process"
+
+	if !converter.arguments.dedupeSchemas && !converter.arguments.flattenAllOf && len(converter.arguments.stripExtensions) == 0 &&
+		!converter.arguments.requireOpIDs && !converter.arguments.failUnknownKeys && !converter.arguments.nullableEnums &&
+		!converter.arguments.flattenOneOf && !converter.arguments.intBounds && !converter.arguments.defaultExamples &&
+		!converter.arguments.dropUnsupported && !converter.arguments.sanitizeNames && len(converter.arguments.outputVersion) == 0 &&
+		len(converter.arguments.formatMappings) == 0 && !converter.arguments.stripReadOnly && !converter.arguments.stripWriteOnly &&
+		!converter.arguments.declareTags && len(converter.arguments.swaggerHost) == 0 && len(converter.arguments.basePath) == 0 &&
+		len(converter.arguments.schemes) == 0 && !converter.arguments.clean {
+		return data, nil
+	}
+
+	stageStart := time.Now()
+	root, err := parseDocumentNode(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading converted document: %w", err)
+	}
+
+	stageStart = converter.recordStage(conversion, "load", stageStart)
+
+	if converter.arguments.flattenAllOf {
+		if err := converter.flattenSwaggerAllOf(root); err != nil {
+			return nil, fmt.Errorf("Error flattening allOf schemas: %w", err)
+		}
+
+		converter.steps = append(converter.steps, "flatten allOf")
+		stageStart = converter.recordStage(conversion, "flatten allOf", stageStart)
+	}
+
+	if converter.arguments.nullableEnums {
+		polyfill30NullableEnums(root)
+		converter.steps = append(converter.steps, "polyfill nullable enums")
+		stageStart = converter.recordStage(conversion, "polyfill nullable enums", stageStart)
+	}
+
+	if converter.arguments.flattenOneOf {
+		converter.flatten30PrimitiveOneOf(root)
+		converter.steps = append(converter.steps, "flatten oneOf")
+		stageStart = converter.recordStage(conversion, "flatten oneOf", stageStart)
+	}
+
+	if converter.arguments.dropUnsupported {
+		converter.dropUnsupportedFeatures(root)
+		converter.steps = append(converter.steps, "drop unsupported features")
+		stageStart = converter.recordStage(conversion, "drop unsupported features", stageStart)
+	}
+
+	if converter.arguments.intBounds {
+		add31IntegerFormatBounds(root)
+		converter.steps = append(converter.steps, "add integer format bounds")
+		stageStart = converter.recordStage(conversion, "add integer format bounds", stageStart)
+	}
+
+	if converter.arguments.defaultExamples {
+		add31ExamplesFromDefaults(root)
+		converter.steps = append(converter.steps, "add examples from defaults")
+		stageStart = converter.recordStage(conversion, "add examples from defaults", stageStart)
+	}
+
+	if len(converter.arguments.stripExtensions) > 0 {
+		stripExtensions(root, converter.arguments.stripExtensions)
+		converter.steps = append(converter.steps, "strip extensions")
+		stageStart = converter.recordStage(conversion, "strip extensions", stageStart)
+	}
+
+	if converter.arguments.stripReadOnly {
+		stripFlaggedProperties(root, "readOnly")
+		converter.steps = append(converter.steps, "strip readOnly properties")
+		stageStart = converter.recordStage(conversion, "strip readOnly properties", stageStart)
+	}
+
+	if converter.arguments.stripWriteOnly {
+		stripFlaggedProperties(root, "writeOnly")
+		converter.steps = append(converter.steps, "strip writeOnly properties")
+		stageStart = converter.recordStage(conversion, "strip writeOnly properties", stageStart)
+	}
+
+	if len(converter.arguments.formatMappings) > 0 {
+		mapSchemaFormats(root, converter.arguments.formatMappings)
+		converter.steps = append(converter.steps, "map formats")
+		stageStart = converter.recordStage(conversion, "map formats", stageStart)
+	}
+
+	if converter.arguments.dedupeSchemas {
+		// Hoisted schemas can change which names an implicit mapping matches.
+		materializeDiscriminatorMappings(root)
+
+		if err := dedupeSchemas(root, converter.arguments.componentPrefix, converter.arguments.schemaNameCase); err != nil {
+			return nil, fmt.Errorf("Error deduplicating schemas: %w", err)
+		}
+
+		converter.steps = append(converter.steps, "dedupe schemas")
+		stageStart = converter.recordStage(conversion, "dedupe schemas", stageStart)
+	}
+
+	if converter.arguments.sanitizeNames {
+		converter.renames = sanitizeSwaggerDefinitionNames(root, converter.arguments.schemaNameCase)
+		converter.steps = append(converter.steps, "sanitize names")
+		stageStart = converter.recordStage(conversion, "sanitize names", stageStart)
+	}
+
+	if converter.arguments.declareTags {
+		declareOperationTags(root)
+		converter.steps = append(converter.steps, "declare tags")
+		stageStart = converter.recordStage(conversion, "declare tags", stageStart)
+	}
+
+	if converter.arguments.requireOpIDs {
+		if err := requireOperationIDs(root); err != nil {
+			return nil, err
+		}
+
+		converter.steps = append(converter.steps, "require operation IDs")
+		stageStart = converter.recordStage(conversion, "require operation IDs", stageStart)
+	}
+
+	if converter.arguments.failUnknownKeys {
+		if err := failUnknownKeywords(root); err != nil {
+			return nil, err
+		}
+
+		converter.steps = append(converter.steps, "check keywords")
+		stageStart = converter.recordStage(conversion, "check keywords", stageStart)
+	}
+
+	if len(converter.arguments.swaggerHost) > 0 || len(converter.arguments.basePath) > 0 || len(converter.arguments.schemes) > 0 {
+		setSwaggerLocation(root, converter.arguments.swaggerHost, converter.arguments.basePath, converter.arguments.schemes)
+		converter.steps = append(converter.steps, "set host and basePath")
+		stageStart = converter.recordStage(conversion, "set host and basePath", stageStart)
+	}
+
+	if converter.arguments.clean {
+		removeEmptySections(root)
+		converter.steps = append(converter.steps, "remove empty sections")
+		stageStart = converter.recordStage(conversion, "remove empty sections", stageStart)
+	}
+
+	if len(converter.arguments.outputVersion) > 0 {
+		setDocumentVersion(root, converter.arguments.outputVersion)
+		converter.steps = append(converter.steps, "set output version")
+		stageStart = converter.recordStage(conversion, "set output version", stageStart)
+	}
+
+	data, err = renderDocumentNode(root)
+	converter.recordStage(conversion, "render", stageStart)
+
+	return data, err
+}
+
 // convertDocument 将文档从任意版本转换为目标版本。
 // 支持的版本转换路径：
 //   - Swagger 2.0 <-> OpenAPI 3.0 <-> OpenAPI 3.1
@@ -1100,12 +3995,27 @@ func convertOpenAPI31To30(data []byte) ([]byte, error) {
 //   - Swagger 2.0: swagger: "2.0"
 //   - OpenAPI 3.0: openapi: "3.0.0" ~ "3.0.4"
 //   - OpenAPI 3.1: openapi: "3.1.0" ~ "3.1.1"
+//   - 支持的版本字符串定义在 specVersions 中
 //
 // 转换策略：
 //   - 如果目标版本高于输入版本，逐步升级（Swagger -> 3.0 -> 3.1）
 //   - 如果目标版本低于输入版本，逐步降级（3.1 -> 3.0 -> Swagger）
 //   - 每次转换只跨越一个版本，确保转换的准确性
-func convertDocument(data []byte, outputVersion SpecVersion) ([]byte, error) {
+//   - 转换之前执行启用的预处理步骤（preProcessDocument）
+//   - 转换完成后执行启用的后处理步骤（postProcessDocument）
+//   - 设置了 --validate-strict 时，最后使用 libopenapi 和 kin-openapi 验证文档（validateDocument）
+//   - 设置了 --target postman 时，最后将 OpenAPI 3.0 文档转换为 Postman Collection（convertOpenAPI30ToPostman）
+//   - 设置了 --normalize 时不转换版本，只执行启用的预处理和后处理步骤，然后规范化文档（normalizeDocument）
+//   - 设置了 --canonicalize 时同样不转换版本，最后将文档转换为规范形式（canonicalizeDocument）
+func (converter *Converter) convertDocument(data []byte) ([]byte, error) {
+	outputVersion := converter.arguments.outputTarget
+
+	data, err := converter.preProcessDocument(data)
+
+	if err != nil {
+		return nil, err
+	}
+
 	// First we'll parse the document in the simplest way to determine the document version.
 	type BasicDoc struct {
 		OpenAPI string `json:"openapi" yaml:"openapi"`
@@ -1123,37 +4033,42 @@ func convertDocument(data []byte, outputVersion SpecVersion) ([]byte, error) {
 	}
 
 	// Build the model using libopenapi and determine the input version.
-	var inputVersion SpecVersion
-
-	switch basicDoc.OpenAPI {
-	case "2.0":
-		inputVersion = Swagger
-	case "3.0.0", "3.0.1", "3.0.2", "3.0.3", "3.0.4":
-		inputVersion = OpenAPI30
-	case "3.1.0", "3.1.1":
-		inputVersion = OpenAPI31
-	default:
+	inputVersion, ok := inputSpecVersion(basicDoc.OpenAPI)
+
+	if !ok {
 		return nil, fmt.Errorf("Unsuppoted input document OpenAPI version: %s", basicDoc.OpenAPI)
 	}
 
-	var err error
+	if converter.arguments.normalize || converter.arguments.canonicalize {
+		if data, err = converter.postProcessDocument(data); err != nil {
+			return nil, err
+		}
+
+		if converter.arguments.canonicalize {
+			return converter.canonicalizeDocument(data)
+		}
+
+		return converter.normalizeDocument(data)
+	}
 
 	// Cycle through document versions until we hit the one we want.
 	for inputVersion != outputVersion {
+		input := data
+
 		if inputVersion < outputVersion {
 			if inputVersion == Swagger {
-				data, err = convertSwaggerToOpenAPI30(data)
+				data, err = converter.convertSwaggerToOpenAPI30(data)
 				inputVersion = OpenAPI30
 			} else {
-				data, err = convertOpenAPI30To31(data)
+				data, err = converter.convertOpenAPI30To31(data)
 				inputVersion = OpenAPI31
 			}
 		} else {
 			if inputVersion == OpenAPI31 {
-				data, err = convertOpenAPI31To30(data)
+				data, err = converter.convertOpenAPI31To30(data)
 				inputVersion = OpenAPI30
 			} else {
-				data, err = convertOpenAPI30ToSwagger(data)
+				data, err = converter.convertOpenAPI30ToSwagger(data)
 				inputVersion = Swagger
 			}
 		}
@@ -1161,9 +4076,46 @@ func convertDocument(data []byte, outputVersion SpecVersion) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		// Bounds and kin-openapi data pass through float64, so restore large integers.
+		var warnings []Warning
+
+		if data, warnings, err = restoreIntegerLiterals(input, data); err != nil {
+			return nil, fmt.Errorf("Error restoring integers: %w", err)
+		}
+
+		converter.warnings = append(converter.warnings, warnings...)
+	}
+
+	if data, err = converter.postProcessDocument(data); err != nil {
+		return nil, err
+	}
+
+	// Recursive schemas are valid, so they are only an error when asked for.
+	stageStart := time.Now()
+
+	if err = converter.checkSchemaRefCycles(data); err != nil {
+		return nil, err
+	}
+
+	converter.recordStage("check", "check reference cycles", stageStart)
+
+	if converter.arguments.validateStrict {
+		stageStart = time.Now()
+
+		if err = validateDocument(data, outputVersion); err != nil {
+			return nil, err
+		}
+
+		converter.steps = append(converter.steps, "validate")
+		converter.recordStage("validate", "validate", stageStart)
 	}
 
-	return data, err
+	if converter.arguments.postman {
+		return converter.convertOpenAPI30ToPostman(data)
+	}
+
+	return data, nil
 }
 
 // checkDataFormat 检测数据格式是 JSON 还是 YAML。
@@ -1187,50 +4139,141 @@ func checkDataFormat(data []byte) Format {
 	return YAML
 }
 
+// convertToFormat 将 JSON 或 YAML 数据转换为 format 格式，数据已经是 format 格式时原样返回。
+func convertToFormat(data []byte, format Format) ([]byte, error) {
+	if checkDataFormat(data) == format {
+		return data, nil
+	}
+
+	if format == JSON {
+		return ghodssYaml.YAMLToJSON(data)
+	}
+
+	return jsonToYAML(data)
+}
+
 // main 程序主入口函数，执行 OpenAPI 规范转换的完整流程。
 // 执行步骤：
 //  1. 解析命令行参数（parseArgs）
-//  2. 读取输入文件或标准输入（readInputFile）
-//  3. 将文档转换为目标版本（convertDocument）
-//  4. 检测输出数据格式，如果与目标格式不匹配则进行格式转换（JSON <-> YAML）
-//  5. 将结果写入输出文件或标准输出
+//  2. 读取输入文件、标准输入或 --input-env 指定的环境变量（readInputFile）
+//  3. 将文档转换为目标版本（convertDocument），如果设置了 --verbose 则打印各阶段耗时
+//     警告打印到标准错误输出，如果设置了 --warnings-file 则写入该文件
+//     如果设置了 --report，则将转换报告写入该文件（writeReport）
+//  4. 检测输出数据格式，如果与目标格式不匹配则进行格式转换（convertToFormat）
+//     如果设置了 --preserve-examples-format，则恢复输入中示例的 YAML 书写风格（preserveExampleStyles）
+//     如果设置了 --yaml-explicit，则在 YAML 的开头添加 "---" 文档标记
+//  5. 将结果写入输出文件（writeOutputFile，使用 --chmod 指定的权限，设置了 --no-clobber 时不覆盖已经存在的文件）或标准输出
+//
+// 第一个参数是 serve 时，不转换输入，而是启动 HTTP 转换服务（见 serve）
+//
+// 设置了 --jsonl 时，第 2 步之后的每一行输入被单独转换（convertJSONLines），某一行转换失败时其余的行仍然会被输出，
+// 最后以非零状态退出；每一行转换完成后在标准错误输出中打印进度，除非设置了 --quiet
 //
 // 错误处理：
 //   - 任何步骤出错都会使用 log.Fatalf 终止程序并输出错误信息
+//   - 设置了 --log-format json 时，log 包输出的错误和 --jsonl 的进度同样以 JSON 行输出（见 jsonLogWriter）
 func main() {
-	arguments := parseArgs()
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve(os.Args[1:])
+		return
+	}
 
-	data, err := readInputFile(arguments)
+	arguments := parseArgs()
 
-	if err != nil {
-		log.Fatalf("Error reading input file %v\n", err)
+	if arguments.logJSON {
+		log.SetFlags(0)
+		log.SetOutput(jsonLogWriter{w: os.Stderr, level: "error"})
 	}
 
-	data, err = convertDocument(data, arguments.outputTarget)
+	var data []byte
+	var err error
+	var lineErrors []error
+	var input []byte
+	converter := Converter{arguments: arguments}
 
-	if err != nil {
-		log.Fatalf("Error converting document: %+v\n", err)
-	}
+	if arguments.listVersions {
+		if data, err = versionsReport(); err != nil {
+			log.Fatalf("Error listing versions: %v\n", err)
+		}
+	} else {
+		if data, err = readInputFile(arguments); err != nil {
+			log.Fatalf("Error reading input file %v\n", err)
+		}
 
-	dataFormat := checkDataFormat(data)
+		if arguments.jsonl {
+			var progress io.Writer = os.Stderr
+
+			if arguments.quiet {
+				progress = nil
+			} else if arguments.logJSON {
+				progress = jsonLogWriter{w: os.Stderr, level: "info"}
+			}
+
+			// Errors on a line are reported, but don't stop the other lines.
+			data, lineErrors = converter.convertJSONLines(data, progress)
 
-	if dataFormat != arguments.outputFormat {
-		if arguments.outputFormat == JSON {
-			data, err = ghodssYaml.YAMLToJSON(data)
+			for _, err := range lineErrors {
+				log.Printf("Error converting document on %+v\n", err)
+			}
 		} else {
-			data, err = ghodssYaml.JSONToYAML(data)
+			if err = checkInputFormat(data, arguments); err != nil {
+				log.Fatalf("Error reading input file %v\n", err)
+			}
+
+			input = data
+
+			if data, err = converter.convertDocument(data); err != nil {
+				log.Fatalf("Error converting document: %+v\n", err)
+			}
+
+			if len(arguments.reportFile) > 0 {
+				if err = converter.writeReport(arguments.reportFile, input, data); err != nil {
+					log.Fatalf("Error writing report: %v\n", err)
+				}
+			}
 		}
+	}
 
-		if err != nil {
+	if len(arguments.warningsFile) > 0 {
+		if err = converter.writeWarningsFile(arguments.warningsFile); err != nil {
+			log.Fatalf("Error writing warnings file: %v\n", err)
+		}
+	} else if err = converter.printWarnings(os.Stderr); err != nil {
+		log.Fatalf("Error printing warnings: %v\n", err)
+	}
+
+	if arguments.verbose {
+		if err = converter.printTimings(os.Stderr); err != nil {
+			log.Fatalf("Error printing timings: %v\n", err)
+		}
+	}
+
+	// NDJSON output is already JSON, and can't be converted as a whole.
+	if !arguments.jsonl {
+		if data, err = convertToFormat(data, arguments.outputFormat); err != nil {
 			log.Fatalf("Error converting to output format: %v\n", err)
 		}
 	}
 
+	if arguments.keepExamples {
+		if data, err = preserveExampleStyles(input, data); err != nil {
+			log.Fatalf("Error preserving example formats: %v\n", err)
+		}
+	}
+
+	if arguments.yamlExplicit && !bytes.HasPrefix(data, []byte("---")) {
+		data = append([]byte("---\n"), data...)
+	}
+
 	if len(arguments.outputFilename) > 0 {
-		if err = os.WriteFile(arguments.outputFilename, data, 0644); err != nil {
+		if err = writeOutputFile(arguments, data); err != nil {
 			log.Fatalf("Error writing output file: %v\n", err)
 		}
 	} else {
 		fmt.Println(string(data))
 	}
+
+	if len(lineErrors) > 0 {
+		os.Exit(1)
+	}
 }
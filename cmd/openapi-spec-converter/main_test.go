@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+)
+
+// schemaWalkTestDocument has an alias component, which is itself a $ref to Pet.
+const schemaWalkTestDocument = `openapi: 3.1.1
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200':
+          description: A pet.
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/PetAlias'
+components:
+  schemas:
+    Pet:
+      title: Pet
+      type: object
+      properties:
+        name:
+          title: Name
+          type: string
+    PetAlias:
+      $ref: '#/components/schemas/Pet'
+`
+
+func TestUpdateAllSchemaSkipsAliasComponents(t *testing.T) {
+	document, err := libopenapi.NewDocument([]byte(schemaWalkTestDocument))
+
+	if err != nil {
+		t.Fatalf("NewDocument() error = %v", err)
+	}
+
+	model, errs := document.BuildV3Model()
+
+	if len(errs) > 0 {
+		t.Fatalf("BuildV3Model() errors = %v", errs)
+	}
+
+	updates := map[string]int{}
+
+	updateAllSchema(model, func(schema *base.Schema) {
+		updates[schema.Title]++
+	})
+
+	if updates["Pet"] != 1 || updates["Name"] != 1 {
+		t.Errorf("updateAllSchema() updated Pet %d and its property %d times, want 1 and 1", updates["Pet"], updates["Name"])
+	}
+}
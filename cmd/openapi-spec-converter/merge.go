@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readBaseDocument 读取并解析 --merge-base 指定的基础文档。
+func readBaseDocument(filename string) (*yaml.Node, error) {
+	data, err := os.ReadFile(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := parseDocumentNode(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if base.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not an OpenAPI document fragment", filename)
+	}
+
+	return base, nil
+}
+
+// mergeMissingKeys 将 base 中 node 没有的键复制到 node 的末尾，node 中已有的键保持不变。
+func mergeMissingKeys(node *yaml.Node, base *yaml.Node) {
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		if mappingValue(node, base.Content[i].Value) == nil {
+			setMappingValue(node, base.Content[i].Value, copyNode(base.Content[i+1]))
+		}
+	}
+}
+
+// mergeBaseDocument 将基础文档中的 info、servers、security、tags 和 components 合并到 OpenAPI 3.x 文档中（--merge-base）。
+// 映射关系：
+//   - info: 基础文档中有而输入中没有的字段被添加，例如 {title: "Pets"} + {contact: {...}} -> {title: "Pets", contact: {...}}
+//   - servers / security: 只在输入中没有这个字段时使用基础文档中的值，不会合并列表
+//     （输入中的 security: [] 表示不需要认证，所以被保留）
+//   - tags: 基础文档中名称没有在输入中声明的标签被添加到末尾
+//   - components: 每个分类（schemas、securitySchemes 等）中输入没有的名称被添加
+//
+// 原因：团队可以在一个文件中维护公共的 info、servers、security 和 components，在多个文档中复用
+//
+// 注意：
+//   - 冲突时使用输入文档中的值
+//   - 合并后根对象的字段按规范的书写顺序重新排列（见 orderRootKeys），添加的 servers 等字段不会排在 paths 之后
+//   - 只支持 OpenAPI 3.x 的输入和基础文档，Swagger 2.0 没有 servers 和 components
+func mergeBaseDocument(root *yaml.Node, base *yaml.Node) error {
+	if isSwaggerDocumentNode(root) || isSwaggerDocumentNode(base) {
+		return fmt.Errorf("Merging a base document is only supported for OpenAPI 3.x documents")
+	}
+
+	for _, key := range []string{"info", "servers", "security", "tags", "components"} {
+		baseValue := mappingValue(base, key)
+
+		if baseValue == nil {
+			continue
+		}
+
+		value := mappingValue(root, key)
+
+		// An empty list is kept, as `security: []` explicitly disables security.
+		if value == nil {
+			setMappingValue(root, key, copyNode(baseValue))
+			continue
+		}
+
+		switch key {
+		case "info":
+			if value.Kind == yaml.MappingNode && baseValue.Kind == yaml.MappingNode {
+				mergeMissingKeys(value, baseValue)
+			}
+		case "tags":
+			if value.Kind != yaml.SequenceNode || baseValue.Kind != yaml.SequenceNode {
+				continue
+			}
+
+			declared := map[string]bool{}
+
+			for _, tag := range value.Content {
+				if name := mappingValue(tag, "name"); name != nil {
+					declared[name.Value] = true
+				}
+			}
+
+			for _, tag := range baseValue.Content {
+				if name := mappingValue(tag, "name"); name != nil && !declared[name.Value] {
+					value.Content = append(value.Content, copyNode(tag))
+				}
+			}
+		case "components":
+			if value.Kind != yaml.MappingNode || baseValue.Kind != yaml.MappingNode {
+				continue
+			}
+
+			for i := 0; i+1 < len(baseValue.Content); i += 2 {
+				section, baseSection := baseValue.Content[i].Value, baseValue.Content[i+1]
+
+				if existing := mappingValue(value, section); existing == nil {
+					setMappingValue(value, section, copyNode(baseSection))
+				} else if existing.Kind == yaml.MappingNode && baseSection.Kind == yaml.MappingNode {
+					mergeMissingKeys(existing, baseSection)
+				}
+			}
+		}
+	}
+
+	orderRootKeys(root, openAPIRootKeyOrder)
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NameCase 表示生成或重命名的组件名称使用的大小写风格
+type NameCase int
+
+const (
+	KeepCase   NameCase = iota // 保持生成的名称不变
+	PascalCase                 // PascalCase，例如 "PetSummary"
+	CamelCase                  // camelCase，例如 "petSummary"
+	SnakeCase                  // snake_case，例如 "pet_summary"
+)
+
+// nameCaseNames 是 --schema-name-case 可以使用的值
+var nameCaseNames = map[string]NameCase{
+	"pascalcase": PascalCase,
+	"camelcase":  CamelCase,
+	"snake_case": SnakeCase,
+}
+
+// splitNameWords 将名称拆分为小写的单词。
+// 映射关系：
+//   - "Pet Summary" -> ["pet", "summary"]
+//   - "Billing_Generated1" -> ["billing", "generated1"]
+//   - "HTTPServer" -> ["http", "server"]
+//
+// 注意：字母和数字以外的字符都是分隔符，数字属于前面的单词
+func splitNameWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, strings.ToLower(string(word)))
+				word = nil
+			}
+
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(word) > 0 {
+			previous := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			// Start a word at "Summary" in "PetSummary" and "Server" in "HTTPServer".
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				words = append(words, strings.ToLower(string(word)))
+				word = nil
+			}
+		}
+
+		word = append(word, r)
+	}
+
+	if len(word) > 0 {
+		words = append(words, strings.ToLower(string(word)))
+	}
+
+	return words
+}
+
+// applyNameCase 将名称转换为 nameCase 风格，KeepCase 或没有字母和数字的名称原样返回。
+// 映射关系：
+//   - PascalCase: "Pets_Generated1" -> "PetsGenerated1"
+//   - CamelCase: "Pets_Generated1" -> "petsGenerated1"
+//   - SnakeCase: "Pets_Generated1" -> "pets_generated1"
+func applyNameCase(name string, nameCase NameCase) string {
+	words := splitNameWords(name)
+
+	if nameCase == KeepCase || len(words) == 0 {
+		return name
+	}
+
+	if nameCase == SnakeCase {
+		return strings.Join(words, "_")
+	}
+
+	for i, word := range words {
+		if i > 0 || nameCase == PascalCase {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			words[i] = string(runes)
+		}
+	}
+
+	return strings.Join(words, "")
+}
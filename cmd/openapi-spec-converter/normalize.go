@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// openAPIRootKeyOrder 是 OpenAPI 3.x 规范中根对象字段的书写顺序
+var openAPIRootKeyOrder = []string{
+	"openapi",
+	"info",
+	"jsonSchemaDialect",
+	"servers",
+	"paths",
+	"webhooks",
+	"components",
+	"security",
+	"tags",
+	"externalDocs",
+}
+
+// normalizeDocument 在不改变版本的情况下规范化文档（--normalize）。
+// 操作：
+//   - 按规范的书写顺序重新排列根对象的字段（见 orderRootKeys）
+//   - 以两个空格缩进的块风格 YAML 重新渲染文档
+//   - 文件开头的注释保持在文件开头
+//
+// 注意：该函数直接修改 YAML 节点树，不经过 JSON 或 libopenapi 渲染，因此 YAML 输入中的注释会被保留；
+// 跨版本转换需要经过 libopenapi 和 kin-openapi 的模型，无法保留注释
+func (converter *Converter) normalizeDocument(data []byte) ([]byte, error) {
+	const conversion = "normalize"
+
+	converter.steps = append(converter.steps, conversion)
+	stageStart := time.Now()
+	root, err := parseDocumentNode(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	stageStart = converter.recordStage(conversion, "load", stageStart)
+
+	// A comment at the top of the file belongs to the first key, keep it at the top after reordering.
+	if len(root.Content) > 0 {
+		root.HeadComment = joinComments(root.HeadComment, root.Content[0].HeadComment)
+		root.Content[0].HeadComment = ""
+	}
+
+	if isSwaggerDocumentNode(root) {
+		orderSwaggerRootKeys(root)
+	} else {
+		orderRootKeys(root, openAPIRootKeyOrder)
+	}
+
+	stageStart = converter.recordStage(conversion, "order keys", stageStart)
+	data, err = renderDocumentNode(root)
+	converter.recordStage(conversion, "render", stageStart)
+
+	return data, err
+}
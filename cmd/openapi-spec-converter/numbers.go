@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// walkNumberScalars 对 node 下所有数字（!!int 和 !!float）标量节点调用 visit，pointer 是标量的 JSON Pointer（node 的 JSON Pointer 为 pointer）。
+func walkNumberScalars(node *yaml.Node, pointer string, visit func(scalar *yaml.Node, pointer string)) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!int" || node.Tag == "!!float" {
+			visit(node, pointer)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkNumberScalars(node.Content[i+1], pointer+"/"+escapeJSONPointerToken(node.Content[i].Value), visit)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			walkNumberScalars(child, pointer+"/"+strconv.Itoa(i), visit)
+		}
+	}
+}
+
+// integerLiteral 判断标量是否是十进制整数，例如 5 和 9007199254740993（2^53 + 1）。
+// 返回：整数的 float64 近似值（超过 2^53 的整数可能丢失精度），以及标量是否是十进制整数
+func integerLiteral(scalar *yaml.Node) (float64, bool) {
+	integer, ok := new(big.Int).SetString(scalar.Value, 10)
+
+	if !ok || scalar.Tag != "!!int" {
+		return 0, false
+	}
+
+	value, _ := new(big.Float).SetInt(integer).Float64()
+
+	return value, true
+}
+
+// restoreIntegerLiterals 将输入中整数的原始写法恢复到转换后的文档中。
+// 映射关系：
+//   - 输入: {maximum: 9223372036854775807} -> 转换后: {maximum: 9.223372036854776e+18} -> 恢复为 {maximum: 9223372036854775807}
+//   - 输入: {minimum: 9007199254740993} -> 转换后: {minimum: 9007199254740992} -> 恢复为 {minimum: 9007199254740993}
+//   - 输入: {minimum: -9223372036854775808} -> 转换后: {minimum: !!int -9223372036854776000} -> 恢复为 {minimum: -9223372036854775808}
+//   - 输入: {default: 5} -> 转换后: {default: 5.0} -> 恢复为 {default: 5}
+//   - 输入: {minimum: 9007199254740992, maximum: 9007199254740993} -> 转换后: {maximum: 9.007199254740992e+15}
+//     -> 改为 {maximum: 9007199254740992}，并返回警告
+//
+// 原因：libopenapi 和 kin-openapi 的 minimum、maximum 等字段是 float64，kin-openapi 的 default、enum 等任意数据
+// 也经过 float64，超过 2^53 的 int64 值会丢失精度，libopenapi 还可能输出无法读取的 YAML（!!int -9223372036854776000），
+// 整数也可能被输出为 5.0 等浮点数的写法，严格的 JSON 使用方会将它们当作不同的值
+//
+// 注意：
+//   - 输入和输出的数字按 float64 的值对应
+//   - 多个不同的整数（例如 9007199254740992 和 9007199254740993）对应同一个 float64 值时无法确定原始值，
+//     为输出中每个这个值的数字返回警告；输出的写法与其中一个整数相同时不做修改，否则不会保留浮点数的写法，
+//     而是改为这个 float64 值的整数写法
+//   - 整数和写法不同的浮点数（例如 5 和 5.0）对应同一个 float64 值时，它们是同一个数字，输出不做修改
+//   - 没有需要恢复的数字时原样返回 output，否则返回重新渲染的 YAML（output 是 JSON 时也返回 YAML）
+//
+// 返回：恢复后的文档，以及无法确定原始值的数字的警告（Path 为输出中的位置）
+func restoreIntegerLiterals(input []byte, output []byte) ([]byte, []Warning, error) {
+	inputRoot, err := parseDocumentNode(input)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The distinct integers, and whether a float was written, for each float64 value.
+	integers := map[float64][]string{}
+	floats := map[float64]bool{}
+
+	walkNumberScalars(inputRoot, "#", func(scalar *yaml.Node, _ string) {
+		if value, integer := integerLiteral(scalar); integer {
+			if !slices.Contains(integers[value], scalar.Value) {
+				integers[value] = append(integers[value], scalar.Value)
+			}
+		} else if value, err := strconv.ParseFloat(scalar.Value, 64); err == nil {
+			// Floats with the same value as an integer make the integer ambiguous.
+			floats[value] = true
+		}
+	})
+
+	if len(integers) == 0 {
+		return output, nil, nil
+	}
+
+	outputRoot, err := parseDocumentNode(output)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var warnings []Warning
+	restored := false
+
+	walkNumberScalars(outputRoot, "#", func(scalar *yaml.Node, pointer string) {
+		value, err := strconv.ParseFloat(scalar.Value, 64)
+
+		if err != nil {
+			return
+		}
+
+		candidates := integers[value]
+		literal := ""
+
+		switch {
+		case len(candidates) == 1 && !floats[value]:
+			literal = candidates[0]
+		case len(candidates) > 1:
+			// Keep a value written like one of the integers, or at least use the integer notation.
+			if literal = scalar.Value; !slices.Contains(candidates, literal) {
+				literal = strconv.FormatFloat(value, 'f', -1, 64)
+			}
+
+			warnings = append(warnings, Warning{
+				Path: pointer,
+				Message: fmt.Sprintf(
+					"%s may have lost precision, the input has %s with the same float64 value",
+					literal,
+					strings.Join(candidates, ", "),
+				),
+			})
+		}
+
+		if literal != "" && literal != scalar.Value {
+			scalar.Value = literal
+			scalar.Tag = "!!int"
+			restored = true
+		}
+	})
+
+	if !restored {
+		return output, warnings, nil
+	}
+
+	output, err = renderDocumentNode(outputRoot)
+
+	return output, warnings, err
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// primitiveOneOfTypes 返回 oneOf 中每个分支的类型，以及是否有分支是 nullable 的。
+// 每个分支只能有 type（string、integer、number 或 boolean）和 nullable 字段，否则返回 false。
+func primitiveOneOfTypes(oneOf *yaml.Node) ([]string, bool, bool) {
+	if oneOf == nil || oneOf.Kind != yaml.SequenceNode || len(oneOf.Content) < 2 {
+		return nil, false, false
+	}
+
+	var types []string
+	nullable := false
+
+	for _, branch := range oneOf.Content {
+		if branch.Kind != yaml.MappingNode {
+			return nil, false, false
+		}
+
+		for i := 0; i+1 < len(branch.Content); i += 2 {
+			key, value := branch.Content[i].Value, branch.Content[i+1]
+
+			switch {
+			case key == "type" && value.Kind == yaml.ScalarNode &&
+				slices.Contains([]string{"string", "integer", "number", "boolean"}, value.Value):
+				if !slices.Contains(types, value.Value) {
+					types = append(types, value.Value)
+				}
+			case key == "nullable" && value.Kind == yaml.ScalarNode:
+				nullable = nullable || value.Value == "true"
+			default:
+				return nil, false, false
+			}
+		}
+
+		if mappingValue(branch, "type") == nil {
+			return nil, false, false
+		}
+	}
+
+	return types, nullable, true
+}
+
+// flatten30PrimitiveOneOf 将 OpenAPI 3.0 中只包含基本类型的 oneOf 合并为一个范围最大的类型（--flatten-nullable-oneof）。
+// 映射关系：
+//   - {oneOf: [{type: "integer", nullable: true}, {type: "number", nullable: true}]} -> {type: "number", nullable: true}
+//   - {oneOf: [{type: "string"}, {type: "integer"}]} -> {type: "string"}，并记录 integer 值不再有效的警告
+//
+// 操作：
+//   - 所有分支都是数值类型时使用 number，number 已经包含 integer，不会丢失信息
+//   - 有分支是 string 时使用 string，其他类型的值不再有效，记录警告
+//   - 其他组合（例如 boolean 和 integer）没有合适的类型，保留 oneOf 并记录警告
+//
+// 原因：从 3.1 降级时 {type: ["string", "integer", "null"]} 被拆分为 oneOf，一些代码生成器更适合处理单个 nullable 的类型
+//
+// 注意：只处理每个分支只有 type 和 nullable 字段，并且 schema 本身没有 type 的 oneOf，例如带有 format 或 enum 的分支保持不变
+func (converter *Converter) flatten30PrimitiveOneOf(root *yaml.Node) {
+	pointers := nodeJSONPointers(root)
+
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		oneOf := mappingValue(schema, "oneOf")
+		types, nullable, ok := primitiveOneOfTypes(oneOf)
+
+		if !ok || mappingValue(schema, "type") != nil {
+			return true
+		}
+
+		flattened := "number"
+
+		if slices.Contains(types, "string") {
+			flattened = "string"
+		}
+
+		var lost []string
+
+		for _, value := range types {
+			if value != flattened && !(flattened == "number" && value == "integer") {
+				lost = append(lost, value)
+			}
+		}
+
+		if flattened == "number" && len(lost) > 0 {
+			converter.warn(pointers[oneOf], "oneOf of %s was not flattened, no single type accepts all of them", strings.Join(types, ", "))
+			return true
+		}
+
+		if len(lost) > 0 {
+			converter.warn(pointers[oneOf], "flattened oneOf of %s into %s, %s values are no longer valid", strings.Join(types, ", "), flattened, strings.Join(lost, " and "))
+		}
+
+		// Replace oneOf where it was, so the schema keeps its key order.
+		for i := 0; i+1 < len(schema.Content); i += 2 {
+			if schema.Content[i].Value == "oneOf" {
+				schema.Content[i], schema.Content[i+1] = newStringNode("type"), newStringNode(flattened)
+			}
+		}
+
+		if nullable && mappingValue(schema, "nullable") == nil {
+			setMappingValue(schema, "nullable", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// operationMethods 是路径项中值为操作对象的 HTTP 方法，按规范中的顺序排列
+var operationMethods = []string{
+	"get",
+	"put",
+	"post",
+	"delete",
+	"options",
+	"head",
+	"patch",
+	"trace",
+}
+
+// missingOperationIDs 返回文档 paths 中没有 operationId（或 operationId 为空）的操作，例如 ["GET /pets", "POST /pets"]。
+// 注意：x- 扩展和引用其他路径项的 $ref 不会被检查
+func missingOperationIDs(root *yaml.Node) []string {
+	var missing []string
+	paths := mappingValue(root, "paths")
+
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		path, pathItem := paths.Content[i].Value, paths.Content[i+1]
+
+		if strings.HasPrefix(path, "x-") || pathItem.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for _, method := range operationMethods {
+			operation := mappingValue(pathItem, method)
+
+			if operation == nil || operation.Kind != yaml.MappingNode {
+				continue
+			}
+
+			if operationID := mappingValue(operation, "operationId"); operationID == nil || len(operationID.Value) == 0 {
+				missing = append(missing, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+
+	return missing
+}
+
+// requireOperationIDs 检查转换后的文档中每个操作都有 operationId，因为代码生成器需要用它命名方法。
+// 返回：列出所有缺少 operationId 的操作的错误，所有操作都有 operationId 时返回 nil
+func requireOperationIDs(root *yaml.Node) error {
+	if missing := missingOperationIDs(root); len(missing) > 0 {
+		return fmt.Errorf("Operations without an operationId: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
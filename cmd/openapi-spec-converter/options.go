@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Options 存储 Convert 使用的转换参数，由 With* 函数设置。
+// 没有设置的参数使用与命令行相同的默认值（见 defaultArguments）。
+type Options struct {
+	arguments    Arguments     // 转换使用的参数，与命令行参数的含义相同
+	warningsSink func(Warning) // 接收有损转换警告的函数（nil 表示丢弃警告）
+}
+
+// Option 设置 Options 中的一个或多个参数，传递给 Convert。
+type Option func(options *Options)
+
+// defaultArguments 返回没有指定命令行参数时使用的转换参数。
+// 默认值：目标版本为 OpenAPI 3.1，输出 JSON，转换为 Swagger 时处理 gRPC 的 summary 和 description，
+// 响应优先使用 application/json
+func defaultArguments() Arguments {
+	return Arguments{
+		outputTarget:   OpenAPI31,
+		outputFormat:   JSON,
+		grpcSummary:    true,
+		grpcAnnotation: true,
+		responseMedia:  "application/json",
+	}
+}
+
+// WithTarget 设置目标版本（默认为 OpenAPI31），与 --target swagger、3.0、3.1 相同。
+func WithTarget(target SpecVersion) Option {
+	return func(options *Options) {
+		options.arguments.outputTarget = target
+		options.arguments.postman = false
+	}
+}
+
+// WithPostman 设置输出 Postman Collection v2.1，与 --target postman 相同（只能输出 JSON）。
+func WithPostman() Option {
+	return func(options *Options) {
+		// Postman collections are built from the 3.0 document.
+		options.arguments.outputTarget = OpenAPI30
+		options.arguments.postman = true
+	}
+}
+
+// WithFormat 设置输出格式（默认为 JSON），与 --format 相同。
+func WithFormat(format Format) Option {
+	return func(options *Options) {
+		options.arguments.outputFormat = format
+	}
+}
+
+// WithGRPCDefaults 设置转换为 Swagger 时是否将 description 复制到空的 summary，以及是否在 description 中追加 gRPC 信息，
+// 默认两者都启用，WithGRPCDefaults(false, false) 与 --no-grpc-summary --no-grpc-annotation 相同。
+func WithGRPCDefaults(summary bool, annotation bool) Option {
+	return func(options *Options) {
+		options.arguments.grpcSummary = summary
+		options.arguments.grpcAnnotation = annotation
+	}
+}
+
+// WithStrict 设置转换后如果 schema 中有目标版本不支持的关键字，是否返回错误，与 --fail-unknown-keywords 相同。
+func WithStrict(strict bool) Option {
+	return func(options *Options) {
+		options.arguments.failUnknownKeys = strict
+	}
+}
+
+// WithWarningsSink 设置接收有损转换警告的函数，Convert 按发现顺序为每个警告调用一次 sink，转换失败时同样会调用。
+func WithWarningsSink(sink func(Warning)) Option {
+	return func(options *Options) {
+		options.warningsSink = sink
+	}
+}
+
+// newOptions 从默认参数开始按顺序应用 options，并检查参数的组合是否有效。
+func newOptions(options []Option) (Options, error) {
+	result := Options{arguments: defaultArguments()}
+
+	for _, option := range options {
+		option(&result)
+	}
+
+	if result.arguments.postman && result.arguments.outputFormat != JSON {
+		return result, fmt.Errorf("target postman can only output JSON")
+	}
+
+	return result, nil
+}
+
+// Convert 将 data（任意支持版本的 JSON 或 YAML 文档）转换为 options 设置的目标版本和输出格式。
+// 操作：
+//   - 没有设置的参数使用与命令行相同的默认值，例如 Convert(data) 将文档转换为 OpenAPI 3.1 JSON
+//   - 使用与命令行相同的转换流程（convertDocument），然后转换为输出格式（convertToFormat）
+//   - 转换过程中记录的警告被传递给 WithWarningsSink 设置的函数
+//
+// 注意：Convert 不会写入文件或标准错误输出，也不返回各阶段的耗时
+func Convert(data []byte, options ...Option) ([]byte, error) {
+	opts, err := newOptions(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	output, _, err := opts.convert(data, nil)
+
+	return output, err
+}
+
+// convert 使用 options 中的参数转换 data，并将警告传递给 warningsSink，cache 不为 nil 时使用 cache 中的结果。
+// 返回：转换后的文档，以及结果是否来自缓存
+func (options Options) convert(data []byte, cache *CachingConverter) ([]byte, bool, error) {
+	converter := Converter{arguments: options.arguments}
+
+	var output []byte
+	var cached bool
+	var err error
+
+	if cache != nil {
+		output, cached, err = cache.convert(&converter, data)
+	} else if output, err = converter.convertDocument(data); err == nil {
+		output, err = convertToFormat(output, options.arguments.outputFormat)
+	}
+
+	if options.warningsSink != nil {
+		for _, warning := range converter.warnings {
+			options.warningsSink(warning)
+		}
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return output, cached, nil
+}
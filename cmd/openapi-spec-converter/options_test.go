@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// optionsTestDocument has a path level server, which Swagger drops with a warning.
+const optionsTestDocument = `openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /pets:
+    servers:
+      - url: https://pets.example.com/v1
+    get:
+      operationId: listPets
+      description: Lists the pets.
+      responses:
+        '200':
+          description: A list of pets.
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: string
+`
+
+// optionsTestKeywordsDocument uses patternProperties, which 3.0 doesn't support.
+const optionsTestKeywordsDocument = `openapi: 3.1.1
+info:
+  title: Labels
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Labels:
+      type: object
+      patternProperties:
+        '^x-':
+          type: string
+`
+
+func TestConvertDefaults(t *testing.T) {
+	output, err := Convert([]byte(optionsTestDocument))
+
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	var document struct {
+		OpenAPI string `json:"openapi"`
+	}
+
+	if err := json.Unmarshal(output, &document); err != nil {
+		t.Fatalf("Convert() output isn't JSON: %v", err)
+	}
+
+	if !strings.HasPrefix(document.OpenAPI, "3.1.") {
+		t.Errorf("Convert() openapi = %q, want 3.1.x", document.OpenAPI)
+	}
+}
+
+func TestConvertWithTargetAndFormat(t *testing.T) {
+	output, err := Convert([]byte(optionsTestDocument), WithTarget(Swagger), WithFormat(YAML))
+
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if !bytes.HasPrefix(output, []byte("swagger: \"2.0\"\n")) {
+		t.Errorf("Convert() output doesn't start with the Swagger version:\n%s", output)
+	}
+}
+
+func TestConvertWithGRPCDefaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []Option
+		wantSummary bool
+	}{
+		{"default", nil, true},
+		{"disabled", []Option{WithGRPCDefaults(false, false)}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := append([]Option{WithTarget(Swagger), WithFormat(YAML)}, test.options...)
+			output, err := Convert([]byte(optionsTestDocument), options...)
+
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+
+			if got := bytes.Contains(output, []byte("summary: Lists the pets.")); got != test.wantSummary {
+				t.Errorf("Convert() copied the description to the summary = %t, want %t", got, test.wantSummary)
+			}
+
+			if got := bytes.Contains(output, []byte("接口方法名称")); got != test.wantSummary {
+				t.Errorf("Convert() appended gRPC info to the description = %t, want %t", got, test.wantSummary)
+			}
+		})
+	}
+}
+
+func TestConvertWithStrict(t *testing.T) {
+	if _, err := Convert([]byte(optionsTestKeywordsDocument), WithTarget(OpenAPI30)); err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	_, err := Convert([]byte(optionsTestKeywordsDocument), WithTarget(OpenAPI30), WithStrict(true))
+
+	if err == nil || !strings.Contains(err.Error(), "#/components/schemas/Labels/patternProperties") {
+		t.Errorf("Convert() error = %v, want the unsupported patternProperties", err)
+	}
+}
+
+func TestConvertWithWarningsSink(t *testing.T) {
+	var warnings []Warning
+
+	_, err := Convert([]byte(optionsTestDocument), WithTarget(Swagger), WithWarningsSink(func(warning Warning) {
+		warnings = append(warnings, warning)
+	}))
+
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if len(warnings) != 1 || warnings[0].Path != "#/paths/~1pets/servers" {
+		t.Errorf("Convert() warnings = %+v, want one for the dropped path servers", warnings)
+	}
+}
+
+func TestConvertWithPostman(t *testing.T) {
+	output, err := Convert([]byte(optionsTestDocument), WithPostman())
+
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if !bytes.Contains(output, []byte("collection/v2.1.0")) {
+		t.Errorf("Convert() output isn't a Postman Collection v2.1:\n%s", output)
+	}
+
+	if _, err := Convert([]byte(optionsTestDocument), WithPostman(), WithFormat(YAML)); err == nil {
+		t.Error("Convert() with postman and YAML succeeded, want an error")
+	}
+
+	// A later target replaces postman.
+	output, err = Convert([]byte(optionsTestDocument), WithPostman(), WithTarget(OpenAPI31))
+
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if !bytes.Contains(output, []byte(`"openapi"`)) {
+		t.Errorf("Convert() output isn't an OpenAPI document:\n%s", output)
+	}
+}
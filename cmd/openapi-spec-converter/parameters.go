@@ -0,0 +1,99 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// findSwaggerParameter 返回 parameters 中名称和位置都相同的参数，不存在时返回 nil。
+func findSwaggerParameter(parameters openapi2.Parameters, name string, in string) *openapi2.Parameter {
+	for _, parameter := range parameters {
+		if parameter != nil && parameter.Ref == "" && parameter.Name == name && parameter.In == in {
+			return parameter
+		}
+	}
+
+	return nil
+}
+
+// setSwaggerParameterFlags 在 OpenAPI 3.0 到 Swagger 2.0 转换时，处理参数的 allowEmptyValue、allowReserved 和示例。
+// 映射关系：
+//   - OpenAPI 3.0: {in: "query", allowEmptyValue: true} -> Swagger 2.0: {in: "query", allowEmptyValue: true}
+//   - OpenAPI 3.0: {allowReserved: true} -> Swagger 2.0: 无对应字段（被丢弃并记录警告）
+//   - OpenAPI 3.0: {example: ...} / {examples: {dog: {$ref: "#/components/examples/DogTag"}}} -> Swagger 2.0: 无对应字段（被丢弃并记录警告）
+//
+// 操作：遍历文档级别、路径级别和操作级别的参数，按名称和位置找到 kin-openapi 转换后的 Swagger 参数
+// 原因：kin-openapi 的 FromV3 不会复制 allowEmptyValue，并且会静默丢弃 allowReserved 和参数的示例
+//
+// 注意：
+//   - 引用其他参数的 $ref 不会被处理，被引用的参数在文档级别的 parameters 中处理
+//   - Swagger 2.0 没有 components.examples，响应中引用的示例由 kin-openapi 解析，在 setSwaggerResponseExamples 中被内联
+func (converter *Converter) setSwaggerParameterFlags(kinOpenAPIDoc *openapi3.T, kinSwaggerDoc *openapi2.T) {
+	setFlags := func(parameterRef *openapi3.ParameterRef, parameter *openapi2.Parameter, pointer string) {
+		if parameterRef == nil || parameterRef.Ref != "" || parameterRef.Value == nil {
+			return
+		}
+
+		if parameterRef.Value.AllowEmptyValue && parameterRef.Value.In == openapi3.ParameterInQuery && parameter != nil {
+			parameter.AllowEmptyValue = true
+		}
+
+		if parameterRef.Value.AllowReserved {
+			converter.warn(pointer+"/allowReserved", "dropped allowReserved, Swagger parameters can't allow reserved characters")
+		}
+
+		if parameterRef.Value.Example != nil {
+			converter.warn(pointer+"/example", "dropped the example, Swagger parameters have no examples")
+		}
+
+		if len(parameterRef.Value.Examples) > 0 {
+			converter.warn(pointer+"/examples", "dropped %d examples, Swagger parameters have no examples", len(parameterRef.Value.Examples))
+		}
+	}
+
+	setListFlags := func(parameterRefs openapi3.Parameters, parameters openapi2.Parameters, pointer string) {
+		for i, parameterRef := range parameterRefs {
+			if parameterRef == nil || parameterRef.Value == nil {
+				continue
+			}
+
+			parameter := findSwaggerParameter(parameters, parameterRef.Value.Name, parameterRef.Value.In)
+			setFlags(parameterRef, parameter, pointer+"/"+strconv.Itoa(i))
+		}
+	}
+
+	if kinOpenAPIDoc.Components != nil {
+		for _, name := range slices.Sorted(maps.Keys(kinOpenAPIDoc.Components.Parameters)) {
+			setFlags(kinOpenAPIDoc.Components.Parameters[name], kinSwaggerDoc.Parameters[name], jsonPointer("components", "parameters", name))
+		}
+	}
+
+	if kinOpenAPIDoc.Paths == nil {
+		return
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(kinSwaggerDoc.Paths)) {
+		pathItem := kinOpenAPIDoc.Paths.Value(path)
+		swaggerPathItem := kinSwaggerDoc.Paths[path]
+
+		if pathItem == nil || swaggerPathItem == nil {
+			continue
+		}
+
+		setListFlags(pathItem.Parameters, swaggerPathItem.Parameters, jsonPointer("paths", path, "parameters"))
+
+		operations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			if operation := pathItem.GetOperation(method); operation != nil {
+				pointer := jsonPointer("paths", path, strings.ToLower(method), "parameters")
+				setListFlags(operation.Parameters, operations[method].Parameters, pointer)
+			}
+		}
+	}
+}
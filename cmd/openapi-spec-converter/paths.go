@@ -0,0 +1,99 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// hasParameter 判断参数列表中是否有名称和位置都与 parameter 相同的参数，引用其他参数的 $ref 按引用的值比较。
+func hasParameter(parameters *yaml.Node, parameter *yaml.Node) bool {
+	for _, existing := range parameters.Content {
+		if ref := mappingValue(parameter, "$ref"); ref != nil {
+			if existingRef := mappingValue(existing, "$ref"); existingRef != nil && existingRef.Value == ref.Value {
+				return true
+			}
+
+			continue
+		}
+
+		name, in := mappingValue(parameter, "name"), mappingValue(parameter, "in")
+		existingName, existingIn := mappingValue(existing, "name"), mappingValue(existing, "in")
+
+		if name != nil && in != nil && existingName != nil && existingIn != nil &&
+			name.Value == existingName.Value && in.Value == existingIn.Value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeTrailingSlashPaths 将以 "/" 结尾的路径合并到没有结尾 "/" 的相同路径中，并记录警告（--merge-trailing-slash）。
+// 映射关系：
+//   - {paths: {"/pets": {get: ...}, "/pets/": {post: ...}}} -> {paths: {"/pets": {get: ..., post: ...}}}
+//   - 两个路径都有的操作：保留 "/pets" 中的操作，丢弃 "/pets/" 中的操作并记录警告
+//   - 路径级别的 parameters：添加 "/pets/" 中名称和位置不同的参数
+//   - 其他字段（summary、description、servers 等）：只在 "/pets" 中没有时使用 "/pets/" 中的值
+//
+// 原因："/pets" 和 "/pets/" 是不同的路径，但通常是书写错误，一些工具和验证器认为它们是相同的路径
+//
+// 注意：
+//   - 没有对应的不带 "/" 的路径时，以 "/" 结尾的路径保持不变
+//   - 引用其他路径项的 $ref 和 x- 扩展不会被合并
+func (converter *Converter) mergeTrailingSlashPaths(root *yaml.Node) {
+	paths := mappingValue(root, "paths")
+
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return
+	}
+
+	var merged []string
+
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		path, pathItem := paths.Content[i].Value, paths.Content[i+1]
+		trimmed := strings.TrimSuffix(path, "/")
+
+		if path == "/" || trimmed == path || strings.HasPrefix(path, "x-") {
+			continue
+		}
+
+		target := mappingValue(paths, trimmed)
+
+		if target == nil {
+			continue
+		}
+
+		if target.Kind != yaml.MappingNode || pathItem.Kind != yaml.MappingNode ||
+			mappingValue(target, "$ref") != nil || mappingValue(pathItem, "$ref") != nil {
+			converter.warn(jsonPointer("paths", path), "%s duplicates %s, but referenced path items can't be merged", path, trimmed)
+			continue
+		}
+
+		for j := 0; j+1 < len(pathItem.Content); j += 2 {
+			key, value := pathItem.Content[j].Value, pathItem.Content[j+1]
+			existing := mappingValue(target, key)
+
+			switch {
+			case existing == nil:
+				setMappingValue(target, key, value)
+			case slices.Contains(operationMethods, key):
+				converter.warn(jsonPointer("paths", path, key), "both %s and %s define %s, keeping the operation for %s", path, trimmed, strings.ToUpper(key), trimmed)
+			case key == "parameters" && existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+				for _, parameter := range value.Content {
+					if !hasParameter(existing, parameter) {
+						existing.Content = append(existing.Content, parameter)
+					}
+				}
+			}
+		}
+
+		converter.warn(jsonPointer("paths", path), "merged the path into %s, which only differs by a trailing slash", trimmed)
+		merged = append(merged, path)
+	}
+
+	for _, path := range merged {
+		deleteMappingKey(paths, path)
+	}
+}
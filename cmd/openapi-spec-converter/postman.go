@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// postmanSchemaURL 是 Postman Collection v2.1 格式的 schema 地址
+const postmanSchemaURL = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanMaxExampleDepth 是根据 schema 生成示例时最多经过的 $ref 数量
+const postmanMaxExampleDepth = 8
+
+// postmanCollection 是 Postman Collection v2.1 文档
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+// postmanInfo 是 Postman Collection 的基本信息
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// postmanItem 是 Postman Collection 中的文件夹（Item 不为空）或请求（Request 不为 nil）
+type postmanItem struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Item        []postmanItem   `json:"item,omitempty"`
+	Request     *postmanRequest `json:"request,omitempty"`
+}
+
+// postmanRequest 是 Postman Collection 中的一个请求
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanKeyValue `json:"header"`
+	URL    postmanURL        `json:"url"`
+	Body   *postmanBody      `json:"body,omitempty"`
+}
+
+// postmanURL 是请求的 URL，路径参数以 ":name" 表示
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []postmanKeyValue `json:"query,omitempty"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+// postmanKeyValue 是 header、查询参数、路径参数和 collection 变量使用的键值对
+type postmanKeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// postmanBody 是请求体，只使用 raw 模式
+type postmanBody struct {
+	Mode    string              `json:"mode"`
+	Raw     string              `json:"raw"`
+	Options *postmanBodyOptions `json:"options,omitempty"`
+}
+
+// postmanBodyOptions 设置 raw 请求体的语言，用于 Postman 的语法高亮
+type postmanBodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+// decodeExampleNode 将 YAML 示例节点解码为可以编码为 JSON 的值，解码失败时返回 nil。
+func decodeExampleNode(node *yaml.Node) any {
+	var value any
+
+	if node == nil || node.Decode(&value) != nil {
+		return nil
+	}
+
+	return value
+}
+
+// schemaExampleValue 根据 schema 生成一个示例值。
+// 生成规则（按优先级）：
+//  1. example、default 或 enum 的第一个值
+//  2. allOf: 合并所有成员生成的对象；oneOf/anyOf: 使用第一个成员
+//  3. 按 type 生成：object -> 每个属性的示例，array -> 包含一个元素的数组，
+//     string -> "string"（date 和 date-time 使用日期），integer/number -> 0，boolean -> true
+//
+// 注意：再次引用正在生成的 schema（循环引用）或经过超过 postmanMaxExampleDepth 个 $ref 的 schema 生成 nil
+//
+// 参数 refs 是生成当前示例时经过的 $ref
+func schemaExampleValue(proxy *base.SchemaProxy, refs []string) any {
+	if proxy == nil || len(refs) > postmanMaxExampleDepth {
+		return nil
+	}
+
+	if proxy.IsReference() {
+		if slices.Contains(refs, proxy.GetReference()) {
+			return nil
+		}
+
+		refs = append(slices.Clip(refs), proxy.GetReference())
+	}
+
+	schema := proxy.Schema()
+
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Example != nil {
+		return decodeExampleNode(schema.Example)
+	}
+
+	if schema.Default != nil {
+		return decodeExampleNode(schema.Default)
+	}
+
+	if len(schema.Enum) > 0 {
+		return decodeExampleNode(schema.Enum[0])
+	}
+
+	if len(schema.AllOf) > 0 {
+		merged := map[string]any{}
+
+		for _, member := range schema.AllOf {
+			if object, ok := schemaExampleValue(member, refs).(map[string]any); ok {
+				for key, value := range object {
+					merged[key] = value
+				}
+			}
+		}
+
+		return merged
+	}
+
+	if len(schema.OneOf) > 0 {
+		return schemaExampleValue(schema.OneOf[0], refs)
+	}
+
+	if len(schema.AnyOf) > 0 {
+		return schemaExampleValue(schema.AnyOf[0], refs)
+	}
+
+	switch {
+	case slices.Contains(schema.Type, "object") || schema.Properties != nil:
+		object := map[string]any{}
+
+		if schema.Properties != nil {
+			for name, property := range schema.Properties.FromOldest() {
+				object[name] = schemaExampleValue(property, refs)
+			}
+		}
+
+		return object
+	case slices.Contains(schema.Type, "array"):
+		if schema.Items != nil && schema.Items.IsA() {
+			return []any{schemaExampleValue(schema.Items.A, refs)}
+		}
+
+		return []any{}
+	case slices.Contains(schema.Type, "string"):
+		switch schema.Format {
+		case "date":
+			return "2024-01-01"
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		default:
+			return "string"
+		}
+	case slices.Contains(schema.Type, "integer"), slices.Contains(schema.Type, "number"):
+		return 0
+	case slices.Contains(schema.Type, "boolean"):
+		return true
+	}
+
+	return nil
+}
+
+// parameterExampleString 返回参数的示例值的字符串形式，用作 Postman 中参数的默认值。
+func parameterExampleString(parameter *v3.Parameter) string {
+	value := decodeExampleNode(parameter.Example)
+
+	if value == nil {
+		value = schemaExampleValue(parameter.Schema, nil)
+	}
+
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		data, err := json.Marshal(value)
+
+		if err != nil {
+			return ""
+		}
+
+		return string(data)
+	}
+}
+
+// operationParameters 合并路径级别和操作级别的参数，操作级别的参数覆盖同名同位置的路径级别参数。
+func operationParameters(pathItem *v3.PathItem, operation *v3.Operation) []*v3.Parameter {
+	parameters := slices.Clone(operation.Parameters)
+
+	for _, parameter := range pathItem.Parameters {
+		overridden := slices.ContainsFunc(parameters, func(other *v3.Parameter) bool {
+			return other != nil && parameter != nil && other.Name == parameter.Name && other.In == parameter.In
+		})
+
+		if !overridden {
+			parameters = append(parameters, parameter)
+		}
+	}
+
+	return parameters
+}
+
+// postmanRequestBody 根据请求体生成 raw 模式的 Postman 请求体，优先使用 application/json。
+// 返回：请求体和请求体的媒体类型，没有请求体时返回 nil 和空字符串
+func postmanRequestBody(requestBody *v3.RequestBody) (*postmanBody, string) {
+	if requestBody == nil || requestBody.Content == nil || requestBody.Content.Len() == 0 {
+		return nil, ""
+	}
+
+	mediaTypeName, mediaType, ok := findContentMediaType(requestBody.Content, "application/json")
+
+	if !ok {
+		mediaTypeName, mediaType = requestBody.Content.First().Key(), requestBody.Content.First().Value()
+	}
+
+	body := &postmanBody{Mode: "raw"}
+	example := decodeExampleNode(mediaType.Example)
+
+	if example == nil && mediaType.Examples != nil && mediaType.Examples.Len() > 0 {
+		if first := mediaType.Examples.First().Value(); first != nil {
+			example = decodeExampleNode(first.Value)
+		}
+	}
+
+	if example == nil {
+		example = schemaExampleValue(mediaType.Schema, nil)
+	}
+
+	essence := mediaTypeEssence(mediaTypeName)
+
+	if essence == "application/json" || strings.HasSuffix(essence, "+json") {
+		data, err := json.MarshalIndent(example, "", "  ")
+
+		if err == nil && example != nil {
+			body.Raw = string(data)
+		}
+
+		body.Options = &postmanBodyOptions{}
+		body.Options.Raw.Language = "json"
+	} else if text, ok := example.(string); ok {
+		body.Raw = text
+	}
+
+	return body, mediaTypeName
+}
+
+// postmanOperationRequest 将一个 OpenAPI 操作转换为 Postman 请求项。
+// 映射关系：
+//   - 名称: summary，没有 summary 时使用 operationId，都没有时使用 "GET /pets/{id}"
+//   - URL: "{{baseUrl}}/pets/:id"，路径参数为 URL 变量，查询参数为 query
+//   - header 参数 -> header，请求体 -> raw 模式的 body 和 Content-Type header
+func postmanOperationRequest(path string, method string, pathItem *v3.PathItem, operation *v3.Operation) postmanItem {
+	name := operation.Summary
+
+	if name == "" {
+		name = operation.OperationId
+	}
+
+	if name == "" {
+		name = strings.ToUpper(method) + " " + path
+	}
+
+	request := &postmanRequest{
+		Method: strings.ToUpper(method),
+		Header: []postmanKeyValue{},
+		URL:    postmanURL{Host: []string{"{{baseUrl}}"}, Path: []string{}},
+	}
+
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+
+		// Postman uses `:name` for path variables.
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segment = ":" + strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		}
+
+		request.URL.Path = append(request.URL.Path, segment)
+	}
+
+	for _, parameter := range operationParameters(pathItem, operation) {
+		if parameter == nil {
+			continue
+		}
+
+		keyValue := postmanKeyValue{
+			Key:         parameter.Name,
+			Value:       parameterExampleString(parameter),
+			Description: parameter.Description,
+		}
+
+		switch parameter.In {
+		case "path":
+			request.URL.Variable = append(request.URL.Variable, keyValue)
+		case "query":
+			request.URL.Query = append(request.URL.Query, keyValue)
+		case "header":
+			request.Header = append(request.Header, keyValue)
+		}
+	}
+
+	if body, mediaType := postmanRequestBody(operation.RequestBody); body != nil {
+		request.Body = body
+		request.Header = append(request.Header, postmanKeyValue{Key: "Content-Type", Value: mediaType})
+	}
+
+	request.URL.Raw = "{{baseUrl}}/" + strings.Join(request.URL.Path, "/")
+
+	if len(request.URL.Query) > 0 {
+		query := make([]string, 0, len(request.URL.Query))
+
+		for _, keyValue := range request.URL.Query {
+			query = append(query, keyValue.Key+"="+keyValue.Value)
+		}
+
+		request.URL.Raw += "?" + strings.Join(query, "&")
+	}
+
+	return postmanItem{Name: name, Description: operation.Description, Request: request}
+}
+
+// postmanBaseURL 返回第一个 server 的 URL，server 变量被替换为默认值，没有 server 时返回空字符串。
+func postmanBaseURL(document *v3.Document) string {
+	if len(document.Servers) == 0 || document.Servers[0] == nil {
+		return ""
+	}
+
+	server := document.Servers[0]
+	url := server.URL
+
+	if server.Variables != nil {
+		for name, variable := range server.Variables.FromOldest() {
+			url = strings.ReplaceAll(url, "{"+name+"}", variable.Default)
+		}
+	}
+
+	return strings.TrimSuffix(url, "/")
+}
+
+// buildPostmanCollection 根据 OpenAPI 3.0 文档模型生成 Postman Collection。
+// 映射关系：
+//   - info.title/info.description -> info.name/info.description
+//   - 每个操作 -> 一个请求，放在以操作的第一个 tag 命名的文件夹中，没有 tag 的操作放在 collection 的顶层
+//   - 第一个 server 的 URL -> collection 变量 baseUrl
+//
+// 注意：文件夹按文档 tags 中的顺序排列，未在 tags 中声明的 tag 按第一次出现的顺序排在后面
+func buildPostmanCollection(document *v3.Document) postmanCollection {
+	collection := postmanCollection{
+		Info: postmanInfo{Schema: postmanSchemaURL},
+		Item: []postmanItem{},
+		Variable: []postmanKeyValue{
+			{Key: "baseUrl", Value: postmanBaseURL(document)},
+		},
+	}
+
+	if document.Info != nil {
+		collection.Info.Name = document.Info.Title
+		collection.Info.Description = document.Info.Description
+	}
+
+	var folderNames []string
+	folders := map[string][]postmanItem{}
+	var ungrouped []postmanItem
+
+	for _, tag := range document.Tags {
+		if tag != nil && !slices.Contains(folderNames, tag.Name) {
+			folderNames = append(folderNames, tag.Name)
+		}
+	}
+
+	if document.Paths != nil && document.Paths.PathItems != nil {
+		for path, pathItem := range document.Paths.PathItems.FromOldest() {
+			for method, operation := range pathItem.GetOperations().FromOldest() {
+				item := postmanOperationRequest(path, method, pathItem, operation)
+
+				if len(operation.Tags) == 0 {
+					ungrouped = append(ungrouped, item)
+					continue
+				}
+
+				if !slices.Contains(folderNames, operation.Tags[0]) {
+					folderNames = append(folderNames, operation.Tags[0])
+				}
+
+				folders[operation.Tags[0]] = append(folders[operation.Tags[0]], item)
+			}
+		}
+	}
+
+	for _, name := range folderNames {
+		if len(folders[name]) > 0 {
+			collection.Item = append(collection.Item, postmanItem{Name: name, Item: folders[name]})
+		}
+	}
+
+	collection.Item = append(collection.Item, ungrouped...)
+
+	return collection
+}
+
+// convertOpenAPI30ToPostman 将 OpenAPI 3.0 文档转换为 Postman Collection v2.1 JSON（--target postman）。
+// 只生成可以导入 Postman 的基本内容（文件夹、请求、参数和示例请求体），不保证完整的保真度。
+func (converter *Converter) convertOpenAPI30ToPostman(data []byte) ([]byte, error) {
+	const conversion = "3.0 -> postman"
+
+	converter.steps = append(converter.steps, conversion)
+	stageStart := time.Now()
+	doc, err := libopenapi.NewDocument(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	stageStart = converter.recordStage(conversion, "load", stageStart)
+	model, errs := doc.BuildV3Model()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
+	}
+
+	stageStart = converter.recordStage(conversion, "build", stageStart)
+	collection := buildPostmanCollection(&model.Model)
+	stageStart = converter.recordStage(conversion, "collection", stageStart)
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(collection); err != nil {
+		return nil, err
+	}
+
+	converter.recordStage(conversion, "marshal", stageStart)
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// isFlaggedSchema 判断 schema 的 keyword（readOnly 或 writeOnly）是否为 true，$ref 引用的组件 schema 同样会被检查。
+func isFlaggedSchema(root *yaml.Node, schema *yaml.Node, keyword string) bool {
+	if ref := mappingValue(schema, "$ref"); ref != nil {
+		if resolved := resolveLocalSchemaRef(root, ref.Value); resolved != nil && resolved != schema {
+			schema = resolved
+		}
+	}
+
+	flag := mappingValue(schema, keyword)
+
+	return flag != nil && flag.Kind == yaml.ScalarNode && flag.Value == "true"
+}
+
+// stripFlaggedProperties 删除文档中所有 schema 里 keyword（readOnly 或 writeOnly）为 true 的属性，并从 required 中移除它们的名称。
+// 映射关系：
+//   - --strip-readonly: {properties: {id: {readOnly: true}, name: {...}}, required: [id, name]} -> {properties: {name: {...}}, required: [name]}
+//   - --strip-writeonly: {properties: {password: {writeOnly: true}, name: {...}}} -> {properties: {name: {...}}}
+//
+// 原因：只生成请求或只生成响应的代码时，请求中不应该出现 readOnly 属性，响应中不应该出现 writeOnly 属性
+//
+// 注意：
+//   - 属性的 $ref 引用的组件 schema 为 readOnly 或 writeOnly 时，属性同样会被删除，但组件 schema 本身不会被删除
+//   - required 变为空时被删除，因为 OpenAPI 3.0 和 Swagger 2.0 不允许空的 required
+func stripFlaggedProperties(root *yaml.Node, keyword string) {
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		properties := mappingValue(schema, "properties")
+
+		if properties == nil || properties.Kind != yaml.MappingNode {
+			return true
+		}
+
+		var stripped []string
+
+		for i := 0; i+1 < len(properties.Content); {
+			if isFlaggedSchema(root, properties.Content[i+1], keyword) {
+				stripped = append(stripped, properties.Content[i].Value)
+				properties.Content = append(properties.Content[:i], properties.Content[i+2:]...)
+			} else {
+				i += 2
+			}
+		}
+
+		if required := mappingValue(schema, "required"); required != nil && required.Kind == yaml.SequenceNode && len(stripped) > 0 {
+			required.Content = slices.DeleteFunc(required.Content, func(name *yaml.Node) bool {
+				return slices.Contains(stripped, name.Value)
+			})
+
+			if len(required.Content) == 0 {
+				deleteMappingKey(schema, "required")
+			}
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pb33f/libopenapi/bundler"
+	"github.com/pb33f/libopenapi/datamodel"
+)
+
+// remoteRefTimeout 是 --allow-remote-refs 下载一个远程文档的最长时间
+const remoteRefTimeout = 10 * time.Second
+
+// remoteRefMaxBytes 是 --allow-remote-refs 下载的一个远程文档的最大字节数
+const remoteRefMaxBytes = 10 << 20
+
+// remoteRefClient 是下载远程文档使用的 HTTP 客户端
+var remoteRefClient = &http.Client{Timeout: remoteRefTimeout}
+
+// fetchRemoteRef 下载 $ref 引用的远程文档，用作 libopenapi 的 RemoteURLHandler。
+// 限制：
+//   - 每个请求最多 remoteRefTimeout
+//   - 响应最多 remoteRefMaxBytes 字节，超出时返回错误而不是截断文档
+//   - 非 2xx 的响应返回错误
+func fetchRemoteRef(url string) (*http.Response, error) {
+	response, err := remoteRefClient.Get(url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("Error fetching %s: %s", url, response.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, remoteRefMaxBytes+1))
+
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %w", url, err)
+	}
+
+	if len(data) > remoteRefMaxBytes {
+		return nil, fmt.Errorf("Error fetching %s: documents are limited to %d bytes", url, remoteRefMaxBytes)
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(data))
+
+	return response, nil
+}
+
+// bundleRemoteRefs 将 OpenAPI 3.x 文档中指向 http(s) URL 的 $ref 替换为下载的内容（--allow-remote-refs）。
+// 映射关系：
+//   - {$ref: "https://example.com/schemas/pet.yaml"} -> pet.yaml 的内容
+//   - {$ref: "#/components/schemas/Pet"} -> 不变（文档内的引用仍然是引用）
+//
+// 原因：转换过程中 libopenapi 和 kin-openapi 都不会下载远程文档，远程 $ref 在转换前被内联后才能被正确转换
+//
+// 注意：
+//   - 只支持 OpenAPI 3.x 输入，libopenapi 的 bundler 不支持 Swagger 2.0
+//   - 循环引用不会被内联，保持为远程 $ref
+//   - 远程文档中相对于远程文档的 $ref 按远程文档的 URL 解析
+func bundleRemoteRefs(data []byte) ([]byte, error) {
+	root, err := parseDocumentNode(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if isSwaggerDocumentNode(root) {
+		return nil, fmt.Errorf("Remote references are only supported in OpenAPI 3.x documents")
+	}
+
+	config := datamodel.NewDocumentConfiguration()
+	config.AllowRemoteReferences = true
+	config.RemoteURLHandler = fetchRemoteRef
+	config.ExtractRefsSequentially = true
+	// libopenapi logs to stdout by default, which would be mixed into the output.
+	config.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	return bundler.BundleBytes(data, config)
+}
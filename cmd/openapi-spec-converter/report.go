@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConversionReport 是 --report 写入的 JSON 报告，记录一次转换的完整过程
+type ConversionReport struct {
+	InputVersion    string            `json:"inputVersion"`    // 输入文档的 swagger 或 openapi 字段值，例如 "3.1.0"
+	OutputVersion   string            `json:"outputVersion"`   // 输出文档的 swagger 或 openapi 字段值，例如 "3.0.4"
+	Steps           []string          `json:"steps"`           // 按执行顺序执行的步骤，例如 ["3.1 -> 3.0", "dedupe schemas"]
+	Warnings        []Warning         `json:"warnings"`        // 有损转换警告
+	Timings         []StageTiming     `json:"timings"`         // 各阶段耗时
+	DroppedKeywords map[string]int    `json:"droppedKeywords"` // 输出中被删除的、输出版本不支持的 schema 关键字及其次数
+	Renames         map[string]string `json:"renames"`         // --sanitize-names 重命名的 definitions，旧名称 -> 新名称
+}
+
+// documentVersionString 返回文档根节点的 swagger 或 openapi 字段值。
+func documentVersionString(root *yaml.Node) string {
+	if version := mappingValue(root, "swagger"); version != nil {
+		return version.Value
+	}
+
+	if version := mappingValue(root, "openapi"); version != nil {
+		return version.Value
+	}
+
+	return ""
+}
+
+// countSchemaKeywords 统计文档的 schema 中每个关键字出现的次数，x- 扩展不被统计。
+func countSchemaKeywords(root *yaml.Node) map[string]int {
+	counts := map[string]int{}
+
+	walkDocumentSchemaNodes(root, func(schema *yaml.Node, component bool) bool {
+		for i := 0; i+1 < len(schema.Content); i += 2 {
+			if key := schema.Content[i].Value; !strings.HasPrefix(key, "x-") {
+				counts[key]++
+			}
+		}
+
+		return true
+	})
+
+	return counts
+}
+
+// droppedSchemaKeywords 比较输入和输出文档的 schema，返回输出版本不支持、并且在输出中减少的关键字及减少的次数。
+// 映射关系：
+//   - 3.0 -> 3.1: 输入中的 3 个 nullable 都被转换为 type 数组 -> {"nullable": 3}
+//
+// 注意：被删除的关键字可能已经被转换为其他关键字（例如 nullable），也可能被丢弃（例如 additionalItems），
+// 被丢弃的关键字通常同时有对应的警告
+func droppedSchemaKeywords(input *yaml.Node, output *yaml.Node) map[string]int {
+	dropped := map[string]int{}
+	outputCounts := countSchemaKeywords(output)
+	keywords := openAPI31SchemaKeywords
+
+	if version, ok := documentSpecVersion(output); ok {
+		keywords = schemaKeywordsForVersion(version)
+	}
+
+	for key, count := range countSchemaKeywords(input) {
+		if !slices.Contains(keywords, key) && count > outputCounts[key] {
+			dropped[key] = count - outputCounts[key]
+		}
+	}
+
+	return dropped
+}
+
+// buildReport 根据输入数据、转换后的数据和 converter 中收集的信息生成转换报告。
+func (converter *Converter) buildReport(input []byte, output []byte) (ConversionReport, error) {
+	report := ConversionReport{
+		Steps:           converter.steps,
+		Warnings:        converter.warnings,
+		Timings:         converter.timings,
+		DroppedKeywords: map[string]int{},
+		Renames:         converter.renames,
+	}
+
+	// Empty lists are written as [] rather than null.
+	if report.Steps == nil {
+		report.Steps = []string{}
+	}
+
+	if report.Warnings == nil {
+		report.Warnings = []Warning{}
+	}
+
+	if report.Timings == nil {
+		report.Timings = []StageTiming{}
+	}
+
+	if report.Renames == nil {
+		report.Renames = map[string]string{}
+	}
+
+	inputRoot, err := parseDocumentNode(input)
+
+	if err != nil {
+		return report, err
+	}
+
+	outputRoot, err := parseDocumentNode(output)
+
+	if err != nil {
+		return report, err
+	}
+
+	report.InputVersion = documentVersionString(inputRoot)
+	report.OutputVersion = documentVersionString(outputRoot)
+	report.DroppedKeywords = droppedSchemaKeywords(inputRoot, outputRoot)
+
+	return report, nil
+}
+
+// writeReport 将转换报告（ConversionReport）以 JSON 写入 filename。
+func (converter *Converter) writeReport(filename string, input []byte, output []byte) error {
+	report, err := converter.buildReport(input, output)
+
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, buffer.Bytes(), 0644)
+}
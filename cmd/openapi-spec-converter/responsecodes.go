@@ -0,0 +1,56 @@
+package main
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+)
+
+// rangeResponseCodePattern 匹配 OpenAPI 3.x 的范围响应码，例如 "2XX"（大小写不敏感）
+var rangeResponseCodePattern = regexp.MustCompile(`^[1-5][xX][xX]$`)
+
+// convertSwaggerRangeResponseCodes 将 OpenAPI 3.0 的范围响应码替换为 Swagger 2.0 支持的具体状态码，并记录警告。
+// 映射关系：
+//   - OpenAPI 3.0: {responses: {"2XX": {...}}} -> Swagger 2.0: {responses: {"200": {...}}}
+//   - OpenAPI 3.0: {responses: {"201": {...}, "2XX": {...}}} -> Swagger 2.0: {responses: {"201": {...}, "200": {...}}}
+//   - OpenAPI 3.0: {responses: {"200": {...}, "2XX": {...}}} -> Swagger 2.0: {responses: {"200": {...}}}（"2XX" 被丢弃）
+//
+// 原因：Swagger 2.0 的响应码只能是 HTTP 状态码或 "default"，kin-openapi 会原样输出 "2XX"，导致文档无效
+//
+// 注意：需要在 setSwaggerResponseExamples 之后调用，示例按 OpenAPI 3.0 的响应码复制
+func (converter *Converter) convertSwaggerRangeResponseCodes(kinSwaggerDoc *openapi2.T) {
+	for _, path := range slices.Sorted(maps.Keys(kinSwaggerDoc.Paths)) {
+		pathItem := kinSwaggerDoc.Paths[path]
+
+		if pathItem == nil {
+			continue
+		}
+
+		operations := pathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			responses := operations[method].Responses
+
+			for _, code := range slices.Sorted(maps.Keys(responses)) {
+				if !rangeResponseCodePattern.MatchString(code) {
+					continue
+				}
+
+				pointer := jsonPointer("paths", path, strings.ToLower(method), "responses", code)
+				statusCode := code[:1] + "00"
+
+				if _, ok := responses[statusCode]; ok {
+					converter.warn(pointer, "dropped the %s response, Swagger has no range response codes and %s is already defined", code, statusCode)
+				} else {
+					responses[statusCode] = responses[code]
+					converter.warn(pointer, "converted the %s response to %s, Swagger has no range response codes", code, statusCode)
+				}
+
+				delete(responses, code)
+			}
+		}
+	}
+}
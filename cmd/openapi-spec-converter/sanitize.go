@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sanitizeDefinitionName 将名称中 componentNameCharacters 以外的字符替换为 "_"，例如 "Owner (v2)" -> "Owner__v2_"。
+func sanitizeDefinitionName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(componentNameCharacters, r) {
+			return r
+		}
+
+		return '_'
+	}, name)
+}
+
+// sanitizeSwaggerDefinitionNames 将 Swagger 2.0 definitions 中的名称改为只包含字母、数字、"."、"-" 和 "_"，
+// 并更新文档中所有指向这些 definitions 的 $ref（--sanitize-names）。
+// 映射关系：
+//   - definitions["Pet Summary"] -> definitions["Pet_Summary"]
+//   - {$ref: "#/definitions/Pet Summary"} -> {$ref: "#/definitions/Pet_Summary"}
+//   - 新名称已经被使用时添加数字后缀：definitions["Owner (v2)"] 和 definitions["Owner__v2_"] -> "Owner__v2__2" 和 "Owner__v2_"
+//   - nameCase 为 PascalCase 时：definitions["pet summary"] -> definitions["PetSummary"]（见 applyNameCase）
+//
+// 原因：Swagger 2.0 没有限制 definitions 的名称，但一些代码生成工具无法处理包含空格等特殊字符的名称
+//
+// 注意：nameCase 只用于需要重命名的名称，已经有效的名称保持不变，所以引用它们的外部文档仍然有效
+//
+// 返回：旧名称到新名称的映射，只包含被重命名的 definitions
+func sanitizeSwaggerDefinitionNames(root *yaml.Node, nameCase NameCase) map[string]string {
+	renames := map[string]string{}
+	definitions := mappingValue(root, "definitions")
+
+	if definitions == nil || definitions.Kind != yaml.MappingNode {
+		return renames
+	}
+
+	var names []string
+
+	for i := 0; i+1 < len(definitions.Content); i += 2 {
+		names = append(names, definitions.Content[i].Value)
+	}
+
+	for i := 0; i+1 < len(definitions.Content); i += 2 {
+		keyNode := definitions.Content[i]
+
+		if sanitizeDefinitionName(keyNode.Value) == keyNode.Value {
+			continue
+		}
+
+		name := applyNameCase(sanitizeDefinitionName(keyNode.Value), nameCase)
+
+		for suffix := 2; slices.Contains(names, name); suffix++ {
+			name = applyNameCase(fmt.Sprintf("%s_%d", sanitizeDefinitionName(keyNode.Value), suffix), nameCase)
+		}
+
+		renames[keyNode.Value] = name
+		names = append(names, name)
+		keyNode.Value = name
+	}
+
+	if len(renames) > 0 {
+		renameDefinitionRefs(root, renames)
+	}
+
+	return renames
+}
+
+// renameDefinitionRefs 将文档中指向 renames 中旧名称的 $ref 改为指向新名称，
+// 例如 "#/definitions/Pet Summary/properties/name" -> "#/definitions/Pet_Summary/properties/name"。
+// 注意：example、default 等任意数据不会被遍历
+func renameDefinitionRefs(node *yaml.Node, renames map[string]string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+
+			switch {
+			case slices.Contains(skippedDocumentKeys, key):
+			case key == "$ref" && value.Kind == yaml.ScalarNode:
+				value.Value = renameDefinitionRef(value.Value, renames)
+			default:
+				renameDefinitionRefs(value, renames)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			renameDefinitionRefs(child, renames)
+		}
+	}
+}
+
+// renameDefinitionRef 返回 ref 指向新名称后的值，ref 不指向 renames 中的 definitions 时原样返回。
+func renameDefinitionRef(ref string, renames map[string]string) string {
+	const prefix = "#/definitions/"
+
+	if !strings.HasPrefix(ref, prefix) {
+		return ref
+	}
+
+	token, rest, found := strings.Cut(strings.TrimPrefix(ref, prefix), "/")
+	name := strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+	if newName, ok := renames[name]; ok {
+		ref = prefix + escapeJSONPointerToken(newName)
+
+		if found {
+			ref += "/" + rest
+		}
+	}
+
+	return ref
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaNodeVisitor 在遍历 schema 节点时被调用。
+// 参数：
+//   - schema: schema 的 YAML 映射节点
+//   - component: schema 是否直接定义在 components.schemas（OpenAPI 3.x）或 definitions（Swagger 2.0）中
+//
+// 返回：false 表示不再遍历该 schema 的子 schema
+type schemaNodeVisitor func(schema *yaml.Node, component bool) bool
+
+// schemaMapKeywords 是值为 {名称: schema} 映射的 schema 关键字
+var schemaMapKeywords = []string{
+	"properties",
+	"patternProperties",
+	"dependentSchemas",
+	"$defs",
+	"definitions",
+}
+
+// schemaListKeywords 是值为 schema 数组的 schema 关键字
+var schemaListKeywords = []string{
+	"allOf",
+	"oneOf",
+	"anyOf",
+	"prefixItems",
+}
+
+// schemaValueKeywords 是值为单个 schema 的 schema 关键字
+var schemaValueKeywords = []string{
+	"items",
+	"additionalItems",
+	"additionalProperties",
+	"not",
+	"if",
+	"then",
+	"else",
+	"contains",
+	"propertyNames",
+	"unevaluatedItems",
+	"unevaluatedProperties",
+	"contentSchema",
+}
+
+// skippedDocumentKeys 是遍历文档时不会进入的键，它们的值是任意数据而不是 OpenAPI 对象
+var skippedDocumentKeys = []string{
+	"example",
+	"examples",
+	"default",
+	"enum",
+	"const",
+	"links",
+}
+
+// parseDocumentNode 将 JSON 或 YAML 数据解析为 YAML 节点树，返回文档根映射节点。
+// 文档开头和结尾的注释被移动到根映射节点上，因此渲染根节点时它们会被保留。
+func parseDocumentNode(data []byte) (*yaml.Node, error) {
+	var document yaml.Node
+
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("Document is not a mapping")
+	}
+
+	// Keep comments at the top and bottom of the file, as only the root is rendered.
+	root := document.Content[0]
+	root.HeadComment = joinComments(document.HeadComment, root.HeadComment)
+	root.FootComment = joinComments(root.FootComment, document.FootComment)
+
+	return root, nil
+}
+
+// joinComments 用空行连接两个 YAML 注释，忽略空的注释。
+func joinComments(first string, second string) string {
+	if first == "" || second == "" {
+		return first + second
+	}
+
+	return first + "\n\n" + second
+}
+
+// renderDocumentNode 将文档根映射节点渲染为块风格的 YAML 数据。
+// 从 JSON 解析得到的节点带有流风格（{...}），渲染前会被重置为块风格。
+func renderDocumentNode(root *yaml.Node) ([]byte, error) {
+	resetNodeStyle(root)
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// jsonToYAML 将 JSON 数据转换为 YAML 数据。
+// 与 ghodssYaml.JSONToYAML 不同，该函数通过 yaml.v3 节点树转换，因此：
+//   - 保留 JSON 中键的顺序（ghodssYaml 会对键排序）
+//   - 保留标量的原始表示，例如数字不会经过 float64 转换，字符串 "2.0" 仍然输出为带引号的字符串
+func jsonToYAML(data []byte) ([]byte, error) {
+	root, err := parseDocumentNode(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return renderDocumentNode(root)
+}
+
+// resetNodeStyle 递归地清除节点的流风格和引号风格，保留字面量（|）和折叠（>）风格。
+func resetNodeStyle(node *yaml.Node) {
+	if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+		node.Style = 0
+	}
+
+	for _, child := range node.Content {
+		resetNodeStyle(child)
+	}
+}
+
+// mappingValue 返回映射节点中 key 对应的值节点，不存在时返回 nil。
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// setMappingValue 设置映射节点中 key 对应的值节点，不存在时追加到末尾。
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+
+	node.Content = append(node.Content, newStringNode(key), value)
+}
+
+// deleteMappingKey 从映射节点中删除 key，返回 key 是否存在。
+func deleteMappingKey(node *yaml.Node, key string) bool {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return false
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// newStringNode 创建一个字符串标量节点。
+func newStringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// newMappingNode 创建一个空的映射节点。
+func newMappingNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// newRefNode 创建一个 {$ref: ref} 映射节点。
+func newRefNode(ref string) *yaml.Node {
+	node := newMappingNode()
+	setMappingValue(node, "$ref", newStringNode(ref))
+
+	return node
+}
+
+// copyNode 深拷贝一个 YAML 节点。
+func copyNode(node *yaml.Node) *yaml.Node {
+	nodeCopy := *node
+	nodeCopy.Content = make([]*yaml.Node, len(node.Content))
+
+	for i, child := range node.Content {
+		nodeCopy.Content[i] = copyNode(child)
+	}
+
+	return &nodeCopy
+}
+
+// canonicalNodeKey 返回节点的规范化 JSON 表示（映射的键被排序），用于比较两个节点在结构上是否相同。
+func canonicalNodeKey(node *yaml.Node) (string, error) {
+	var value any
+
+	if err := node.Decode(&value); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// isSwaggerDocumentNode 判断文档根节点是否为 Swagger 2.0 文档。
+func isSwaggerDocumentNode(root *yaml.Node) bool {
+	return mappingValue(root, "swagger") != nil
+}
+
+// schemaComponentsNode 返回存放可复用 schema 的映射节点，以及引用这些 schema 时使用的前缀。
+//   - Swagger 2.0: definitions，前缀 "#/definitions/"
+//   - OpenAPI 3.x: components.schemas，前缀 "#/components/schemas/"
+//
+// 如果 create 为 true，则在节点不存在时创建它。
+func schemaComponentsNode(root *yaml.Node, create bool) (*yaml.Node, string) {
+	if isSwaggerDocumentNode(root) {
+		definitions := mappingValue(root, "definitions")
+
+		if definitions == nil && create {
+			definitions = newMappingNode()
+			setMappingValue(root, "definitions", definitions)
+		}
+
+		return definitions, "#/definitions/"
+	}
+
+	components := mappingValue(root, "components")
+
+	if components == nil && create {
+		components = newMappingNode()
+		setMappingValue(root, "components", components)
+	}
+
+	schemas := mappingValue(components, "schemas")
+
+	if schemas == nil && components != nil && create {
+		schemas = newMappingNode()
+		setMappingValue(components, "schemas", schemas)
+	}
+
+	return schemas, "#/components/schemas/"
+}
+
+// escapeJSONPointerToken 按 JSON Pointer（RFC 6901）规则转义引用中的一段名称："~" -> "~0"，"/" -> "~1"
+//
+// 注意：libopenapi 只能解析包含 "~1" 的引用，名称中包含 "~" 的组件在 3.0 -> 3.1 等使用 libopenapi 的转换中无法被解析
+func escapeJSONPointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// swaggerRootKeyOrder 是 Swagger 2.0 规范中根对象字段的书写顺序
+var swaggerRootKeyOrder = []string{
+	"swagger",
+	"info",
+	"host",
+	"basePath",
+	"schemes",
+	"consumes",
+	"produces",
+	"paths",
+	"definitions",
+	"parameters",
+	"responses",
+	"securityDefinitions",
+	"security",
+	"tags",
+	"externalDocs",
+}
+
+// orderSwaggerRootKeys 按 Swagger 2.0 规范的书写顺序重新排列根对象的字段，扩展字段（x-）等未知字段保持原有顺序并排在最后。
+// 原因：kin-openapi 输出的 Swagger JSON 按字母顺序排列字段，"swagger" 版本号会出现在文档末尾
+func orderSwaggerRootKeys(root *yaml.Node) {
+	orderRootKeys(root, swaggerRootKeyOrder)
+}
+
+// orderRootKeys 按 order 中的顺序重新排列映射节点的字段，不在 order 中的字段保持原有顺序并排在最后。
+func orderRootKeys(root *yaml.Node, order []string) {
+	rank := func(key string) int {
+		if index := slices.Index(order, key); index >= 0 {
+			return index
+		}
+
+		return len(order)
+	}
+
+	pairs := make([][2]*yaml.Node, 0, len(root.Content)/2)
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{root.Content[i], root.Content[i+1]})
+	}
+
+	slices.SortStableFunc(pairs, func(a, b [2]*yaml.Node) int {
+		return rank(a[0].Value) - rank(b[0].Value)
+	})
+
+	root.Content = root.Content[:0]
+
+	for _, pair := range pairs {
+		root.Content = append(root.Content, pair[0], pair[1])
+	}
+}
+
+// jsonPointer 将一组未转义的名称拼接为文档内的 JSON Pointer，例如 ("paths", "/pets") -> "#/paths/~1pets"
+func jsonPointer(tokens ...string) string {
+	escaped := make([]string, len(tokens))
+
+	for i, token := range tokens {
+		escaped[i] = escapeJSONPointerToken(token)
+	}
+
+	return "#/" + strings.Join(escaped, "/")
+}
+
+// nodeJSONPointers 返回文档中每个映射和序列节点对应的 JSON Pointer，用于为 libopenapi 模型中的节点生成警告路径。
+// 映射关系：{paths: {"/pets": {...}}} -> {root: "#", paths 的值: "#/paths", "/pets" 的值: "#/paths/~1pets"}
+func nodeJSONPointers(root *yaml.Node) map[*yaml.Node]string {
+	pointers := map[*yaml.Node]string{}
+
+	var walk func(node *yaml.Node, pointer string)
+	walk = func(node *yaml.Node, pointer string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, pointer)
+			}
+		case yaml.MappingNode:
+			pointers[node] = pointer
+
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], pointer+"/"+escapeJSONPointerToken(node.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			pointers[node] = pointer
+
+			for i, child := range node.Content {
+				walk(child, pointer+"/"+strconv.Itoa(i))
+			}
+		}
+	}
+
+	walk(root, "#")
+
+	return pointers
+}
+
+// walkDocumentSchemaNodes 遍历文档中的所有 schema 节点，对每个 schema 调用 visitor。
+// 查找位置：
+//  1. components.schemas（OpenAPI 3.x）和 definitions（Swagger 2.0）中的每个 schema（component 为 true）
+//  2. 文档中任何 "schema" 键对应的值（参数、请求体、响应、header 中的 schema）
+//  3. 每个 schema 中的子 schema（properties、items、allOf/oneOf/anyOf、additionalProperties 等）
+//
+// 注意：example、examples、default、enum、const、links 以及 x- 扩展的值是任意数据，不会被遍历
+func walkDocumentSchemaNodes(root *yaml.Node, visitor schemaNodeVisitor) {
+	swagger := isSwaggerDocumentNode(root)
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+
+		switch {
+		case swagger && key == "definitions":
+			walkSchemaMapNode(value, visitor, true)
+		case !swagger && key == "components":
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				if value.Content[j].Value == "schemas" {
+					walkSchemaMapNode(value.Content[j+1], visitor, true)
+				} else {
+					walkDocumentNode(value.Content[j+1], visitor)
+				}
+			}
+		default:
+			walkDocumentNode(value, visitor)
+		}
+	}
+}
+
+// walkDocumentNode 在文档的非 schema 部分中查找 "schema" 键并遍历对应的 schema。
+func walkDocumentNode(node *yaml.Node, visitor schemaNodeVisitor) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+
+			switch {
+			case strings.HasPrefix(key, "x-"):
+			case slices.Contains(skippedDocumentKeys, key):
+			case key == "schema":
+				walkSchemaNode(value, visitor, false)
+			default:
+				walkDocumentNode(value, visitor)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			walkDocumentNode(child, visitor)
+		}
+	}
+}
+
+// walkSchemaMapNode 遍历 {名称: schema} 映射中的每个 schema。
+func walkSchemaMapNode(node *yaml.Node, visitor schemaNodeVisitor, component bool) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		walkSchemaNode(node.Content[i+1], visitor, component)
+	}
+}
+
+// walkSchemaNode 对 schema 调用 visitor，然后递归遍历它的子 schema。
+func walkSchemaNode(schema *yaml.Node, visitor schemaNodeVisitor, component bool) {
+	if schema == nil || schema.Kind != yaml.MappingNode {
+		// Boolean schemas and malformed values have no sub-schemas.
+		return
+	}
+
+	if !visitor(schema, component) {
+		return
+	}
+
+	for i := 0; i+1 < len(schema.Content); i += 2 {
+		key, value := schema.Content[i].Value, schema.Content[i+1]
+
+		switch {
+		case slices.Contains(schemaMapKeywords, key):
+			walkSchemaMapNode(value, visitor, false)
+		case slices.Contains(schemaListKeywords, key):
+			if value.Kind == yaml.SequenceNode {
+				for _, subSchema := range value.Content {
+					walkSchemaNode(subSchema, visitor, false)
+				}
+			}
+		case slices.Contains(schemaValueKeywords, key):
+			if value.Kind == yaml.SequenceNode {
+				// Draft 4 style tuple `items` arrays.
+				for _, subSchema := range value.Content {
+					walkSchemaNode(subSchema, visitor, false)
+				}
+			} else {
+				walkSchemaNode(value, visitor, false)
+			}
+		}
+	}
+}
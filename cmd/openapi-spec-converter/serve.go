@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pborman/getopt/v2"
+)
+
+// serveCacheSize 是 serve 模式下缓存的转换结果数量
+const serveCacheSize = 64
+
+// serveMaxBytes 是 serve 模式下一个请求体的最大字节数
+const serveMaxBytes = 10 << 20
+
+// serveArguments 解析 serve 子命令的参数，args 从 "serve" 开始。
+// 支持的参数：
+//   - --help, -h: 显示帮助信息
+//   - --listen, -l: 监听的地址（默认为 :8080）
+//
+// 返回：监听的地址
+func serveArguments(args []string) string {
+	set := getopt.New()
+	set.SetProgram(filepath.Base(os.Args[0]) + " serve")
+
+	showHelp := set.BoolLong("help", 'h', "Print this help message")
+	listen := set.StringLong("listen", 'l', ":8080", "Address to listen on")
+
+	if err := set.Getopt(args, nil); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		set.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if set.NArgs() > 0 {
+		fmt.Fprintln(os.Stderr, "Invalid number of arguments")
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	return *listen
+}
+
+// serveConvertOptions 根据 POST /convert 的查询参数返回 Convert 使用的 options，其余参数使用与命令行相同的默认值。
+// 查询参数：
+//   - target: 目标版本，可选值：swagger, 3.0, 3.1, postman（默认为 3.1）
+//   - format: 输出格式，可选值：json, yaml（默认为 json，postman 只能输出 json）
+func serveConvertOptions(query map[string][]string) ([]Option, error) {
+	var options []Option
+
+	if values := query["target"]; len(values) > 0 {
+		if strings.EqualFold(values[0], "postman") {
+			options = append(options, WithPostman())
+		} else if target, ok := targetSpecVersion(values[0]); ok {
+			options = append(options, WithTarget(target))
+		} else {
+			return nil, fmt.Errorf("Invalid target version %s", values[0])
+		}
+	}
+
+	if values := query["format"]; len(values) > 0 {
+		switch strings.ToLower(values[0]) {
+		case "json":
+			options = append(options, WithFormat(JSON))
+		case "yaml":
+			options = append(options, WithFormat(YAML))
+		default:
+			return nil, fmt.Errorf("Invalid format: %s", values[0])
+		}
+	}
+
+	return options, nil
+}
+
+// newServeHandler 创建 serve 模式的 HTTP handler。
+// 路由：
+//   - GET /healthz: 返回 200 和 "ok"
+//   - POST /convert?target=3.1&format=yaml: 将请求体作为文档转换，返回转换后的文档
+//
+// 错误处理：
+//   - 查询参数无效时返回 400
+//   - 请求体超过 serveMaxBytes 时返回 413
+//   - 文档无法转换时返回 422 和错误信息
+//
+// 注意：文档使用与 Convert 相同的默认参数和转换流程转换（见 Options）；有损转换的警告数量在 X-Conversion-Warnings 响应头中返回；相同的文档使用 cache 中的结果，
+// 这时 X-Conversion-Cache 响应头为 hit，否则为 miss
+func newServeHandler(cache *CachingConverter) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.HandleFunc("POST /convert", func(w http.ResponseWriter, r *http.Request) {
+		var warnings int
+
+		options, err := serveConvertOptions(r.URL.Query())
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		opts, err := newOptions(append(options, WithWarningsSink(func(Warning) { warnings++ })))
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, serveMaxBytes))
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		data, cached, err := opts.convert(data, cache)
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error converting document: %v", err), http.StatusUnprocessableEntity)
+			return
+		}
+
+		if opts.arguments.outputFormat == YAML {
+			w.Header().Set("Content-Type", "application/yaml")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+
+		w.Header().Set("X-Conversion-Warnings", fmt.Sprint(warnings))
+
+		if cached {
+			w.Header().Set("X-Conversion-Cache", "hit")
+		} else {
+			w.Header().Set("X-Conversion-Cache", "miss")
+		}
+
+		w.Write(data)
+	})
+
+	return mux
+}
+
+// serve 执行 serve 子命令，启动将文档转换为请求的版本的 HTTP 服务，args 从 "serve" 开始。
+// 用法：openapi-spec-converter serve [--listen :8080]
+func serve(args []string) {
+	server := &http.Server{
+		Addr:              serveArguments(args),
+		Handler:           newServeHandler(NewCachingConverter(serveCacheSize)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("Listening on %s\n", server.Addr)
+	log.Fatal(server.ListenAndServe())
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// postConvert sends document to POST /convert with query and returns the response.
+func postConvert(t *testing.T, server *httptest.Server, query string, document string) *http.Response {
+	t.Helper()
+
+	response, err := http.Post(server.URL+"/convert"+query, "application/yaml", strings.NewReader(document))
+
+	if err != nil {
+		t.Fatalf("POST /convert%s error = %v", query, err)
+	}
+
+	t.Cleanup(func() { response.Body.Close() })
+
+	return response
+}
+
+func TestServeHealthz(t *testing.T) {
+	server := httptest.NewServer(newServeHandler(NewCachingConverter(serveCacheSize)))
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/healthz")
+
+	if err != nil {
+		t.Fatalf("GET /healthz error = %v", err)
+	}
+
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+
+	if response.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != "ok" {
+		t.Errorf("GET /healthz = %d %q, want 200 \"ok\"", response.StatusCode, body)
+	}
+}
+
+func TestServeConvert(t *testing.T) {
+	server := httptest.NewServer(newServeHandler(NewCachingConverter(serveCacheSize)))
+	defer server.Close()
+
+	response := postConvert(t, server, "?target=swagger&format=yaml", optionsTestDocument)
+	body, _ := io.ReadAll(response.Body)
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("POST /convert status = %d, want 200: %s", response.StatusCode, body)
+	}
+
+	if !strings.HasPrefix(string(body), "swagger: \"2.0\"") {
+		t.Errorf("POST /convert body doesn't start with the swagger version:\n%s", body)
+	}
+
+	if contentType := response.Header.Get("Content-Type"); contentType != "application/yaml" {
+		t.Errorf("POST /convert Content-Type = %q, want application/yaml", contentType)
+	}
+
+	if warnings := response.Header.Get("X-Conversion-Warnings"); warnings != "1" {
+		t.Errorf("POST /convert X-Conversion-Warnings = %q, want 1", warnings)
+	}
+
+	if cache := response.Header.Get("X-Conversion-Cache"); cache != "miss" {
+		t.Errorf("POST /convert X-Conversion-Cache = %q, want miss", cache)
+	}
+
+	response = postConvert(t, server, "?target=swagger&format=yaml", optionsTestDocument)
+
+	if cache := response.Header.Get("X-Conversion-Cache"); cache != "hit" {
+		t.Errorf("second POST /convert X-Conversion-Cache = %q, want hit", cache)
+	}
+
+	if warnings := response.Header.Get("X-Conversion-Warnings"); warnings != "1" {
+		t.Errorf("second POST /convert X-Conversion-Warnings = %q, want 1", warnings)
+	}
+}
+
+func TestServeConvertDefaults(t *testing.T) {
+	server := httptest.NewServer(newServeHandler(NewCachingConverter(serveCacheSize)))
+	defer server.Close()
+
+	response := postConvert(t, server, "", optionsTestDocument)
+	body, _ := io.ReadAll(response.Body)
+	expected, err := Convert([]byte(optionsTestDocument))
+
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if response.StatusCode != http.StatusOK || string(body) != string(expected) {
+		t.Errorf("POST /convert = %d, want 200 with the output of Convert()", response.StatusCode)
+	}
+}
+
+func TestServeConvertErrors(t *testing.T) {
+	server := httptest.NewServer(newServeHandler(NewCachingConverter(serveCacheSize)))
+	defer server.Close()
+
+	for _, test := range []struct {
+		query    string
+		document string
+		status   int
+	}{
+		{"?target=4.0", optionsTestDocument, http.StatusBadRequest},
+		{"?format=xml", optionsTestDocument, http.StatusBadRequest},
+		{"?target=postman&format=yaml", optionsTestDocument, http.StatusBadRequest},
+		{"?target=3.0", "openapi: [", http.StatusUnprocessableEntity},
+	} {
+		if response := postConvert(t, server, test.query, test.document); response.StatusCode != test.status {
+			t.Errorf("POST /convert%s status = %d, want %d", test.query, response.StatusCode, test.status)
+		}
+	}
+}
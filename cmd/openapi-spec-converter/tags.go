@@ -0,0 +1,93 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// operationTags 按第一次出现的顺序返回文档 paths 中操作使用的标签名称。
+// 注意：x- 扩展和引用其他路径项的 $ref 不会被检查
+func operationTags(root *yaml.Node) []string {
+	var tags []string
+	paths := mappingValue(root, "paths")
+
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		path, pathItem := paths.Content[i].Value, paths.Content[i+1]
+
+		if strings.HasPrefix(path, "x-") || pathItem.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for _, method := range operationMethods {
+			operationTags := mappingValue(mappingValue(pathItem, method), "tags")
+
+			if operationTags == nil || operationTags.Kind != yaml.SequenceNode {
+				continue
+			}
+
+			for _, tag := range operationTags.Content {
+				if tag.Kind == yaml.ScalarNode && !slices.Contains(tags, tag.Value) {
+					tags = append(tags, tag.Value)
+				}
+			}
+		}
+	}
+
+	return tags
+}
+
+// declareOperationTags 在根对象的 tags 中为操作使用但没有声明的标签添加条目（--declare-tags）。
+// 映射关系：
+//   - {paths: {/pets: {get: {tags: ["pets"]}}}} -> {paths: {...}, tags: [{name: "pets"}]}
+//   - {tags: [{name: "pets", description: "..."}]} 中已经声明的标签保持不变，新的标签按使用顺序添加到末尾
+//
+// 原因：规范不要求声明操作使用的标签，但一些工具（例如文档生成器和 linter）要求每个标签都在根对象的 tags 中声明
+//
+// 返回：添加的标签名称
+func declareOperationTags(root *yaml.Node) []string {
+	var added []string
+	tags := mappingValue(root, "tags")
+
+	if tags != nil && tags.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var declared []string
+
+	if tags != nil {
+		for _, tag := range tags.Content {
+			if name := mappingValue(tag, "name"); name != nil {
+				declared = append(declared, name.Value)
+			}
+		}
+	}
+
+	for _, name := range operationTags(root) {
+		if !slices.Contains(declared, name) {
+			added = append(added, name)
+		}
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	if tags == nil {
+		tags = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setMappingValue(root, "tags", tags)
+	}
+
+	for _, name := range added {
+		tag := newMappingNode()
+		setMappingValue(tag, "name", newStringNode(name))
+		tags.Content = append(tags.Content, tag)
+	}
+
+	return added
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	ghodssYaml "github.com/ghodss/yaml"
+	"github.com/pb33f/libopenapi"
+)
+
+// validateWithLibOpenAPI 使用 libopenapi 构建文档模型，返回构建时发现的错误（例如无法解析的 $ref）。
+func validateWithLibOpenAPI(data []byte, version SpecVersion) []error {
+	doc, err := libopenapi.NewDocument(data)
+
+	if err != nil {
+		return []error{err}
+	}
+
+	if version == Swagger {
+		_, errs := doc.BuildV2Model()
+
+		return errs
+	}
+
+	_, errs := doc.BuildV3Model()
+
+	return errs
+}
+
+// validateWithKinOpenAPI 使用 kin-openapi 加载并验证文档，返回验证错误。
+// 注意：
+//   - Swagger 2.0 文档先使用 openapi2conv.ToV3 转换为 OpenAPI 3.0，然后验证转换后的文档
+//   - kin-openapi 不支持 OpenAPI 3.1，OpenAPI 3.1 文档不会被验证
+func validateWithKinOpenAPI(data []byte, version SpecVersion) error {
+	var kinOpenAPIDoc *openapi3.T
+
+	switch version {
+	case OpenAPI31:
+		return nil
+	case Swagger:
+		var kinSwaggerDoc openapi2.T
+
+		// Unmarshal through JSON, as openapi2.T only has JSON tags.
+		jsonData, err := ghodssYaml.YAMLToJSON(data)
+
+		if err != nil {
+			return err
+		}
+
+		if err = kinSwaggerDoc.UnmarshalJSON(jsonData); err != nil {
+			return err
+		}
+
+		if kinOpenAPIDoc, err = openapi2conv.ToV3(&kinSwaggerDoc); err != nil {
+			return err
+		}
+	default:
+		var err error
+
+		if kinOpenAPIDoc, err = openapi3.NewLoader().LoadFromData(data); err != nil {
+			return err
+		}
+	}
+
+	return kinOpenAPIDoc.Validate(context.Background())
+}
+
+// validateDocument 使用 libopenapi 和 kin-openapi 验证转换后的文档，返回两者发现的所有错误（--validate-strict）。
+// 操作：
+//  1. libopenapi: 构建文档模型，检查无法解析的 $ref 等结构错误
+//  2. kin-openapi: 加载并验证文档（Swagger 2.0 先转换为 OpenAPI 3.0），检查缺少的路径参数、无效的 schema 等错误
+//
+// 原因：两个库检查的问题不同，只通过其中一个的文档仍然可能被其他工具拒绝，发布文档之前同时使用两者可以发现更多问题
+//
+// 注意：kin-openapi 不支持 OpenAPI 3.1，OpenAPI 3.1 文档只使用 libopenapi 验证
+//
+// 返回：列出所有验证错误的错误，文档有效时返回 nil
+func validateDocument(data []byte, version SpecVersion) error {
+	var errs []error
+
+	for _, err := range validateWithLibOpenAPI(data, version) {
+		errs = append(errs, fmt.Errorf("libopenapi: %w", err))
+	}
+
+	if err := validateWithKinOpenAPI(data, version); err != nil {
+		errs = append(errs, fmt.Errorf("kin-openapi: %w", err))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("The converted document is invalid:\n%w", errors.Join(errs...))
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// specVersionInfo 描述一个支持的规范版本，是版本名称和版本字符串的唯一来源。
+type specVersionInfo struct {
+	Version       SpecVersion `json:"-"`
+	Target        string      `json:"target"`        // --target 使用的名称，例如 "3.1"
+	Name          string      `json:"name"`          // 规范名称，例如 "OpenAPI 3.1"
+	InputVersions []string    `json:"inputVersions"` // 可以读取的 swagger/openapi 字段值
+	OutputVersion string      `json:"outputVersion"` // 转换为该版本时输出的 swagger/openapi 字段值
+}
+
+// specVersions 按转换链的顺序列出支持的规范版本（Swagger 2.0 <-> OpenAPI 3.0 <-> OpenAPI 3.1）。
+// 注意：Swagger 2.0 转换为 OpenAPI 3.0 时使用 kin-openapi 输出的 3.0.3，而不是 OutputVersion
+var specVersions = []specVersionInfo{
+	{
+		Version:       Swagger,
+		Target:        "swagger",
+		Name:          "Swagger 2.0",
+		InputVersions: []string{"2.0"},
+		OutputVersion: "2.0",
+	},
+	{
+		Version:       OpenAPI30,
+		Target:        "3.0",
+		Name:          "OpenAPI 3.0",
+		InputVersions: []string{"3.0.0", "3.0.1", "3.0.2", "3.0.3", "3.0.4"},
+		OutputVersion: "3.0.4",
+	},
+	{
+		Version:       OpenAPI31,
+		Target:        "3.1",
+		Name:          "OpenAPI 3.1",
+		InputVersions: []string{"3.1.0", "3.1.1"},
+		OutputVersion: "3.1.1",
+	},
+}
+
+// targetSpecVersion 根据 --target 的值（不区分大小写）查找目标版本。
+func targetSpecVersion(target string) (SpecVersion, bool) {
+	for _, info := range specVersions {
+		if strings.EqualFold(info.Target, target) {
+			return info.Version, true
+		}
+	}
+
+	return 0, false
+}
+
+// inputSpecVersion 根据文档的 swagger 或 openapi 字段查找输入版本。
+func inputSpecVersion(version string) (SpecVersion, bool) {
+	for _, info := range specVersions {
+		if slices.Contains(info.InputVersions, version) {
+			return info.Version, true
+		}
+	}
+
+	return 0, false
+}
+
+// lookupSpecVersion 返回指定版本的 specVersionInfo。
+func lookupSpecVersion(version SpecVersion) (specVersionInfo, bool) {
+	for _, info := range specVersions {
+		if info.Version == version {
+			return info, true
+		}
+	}
+
+	return specVersionInfo{}, false
+}
+
+// outputVersionString 返回转换为指定版本时输出的 swagger 或 openapi 字段值，例如 OpenAPI31 -> "3.1.1"。
+func outputVersionString(version SpecVersion) string {
+	info, _ := lookupSpecVersion(version)
+
+	return info.OutputVersion
+}
+
+// setDocumentVersion 将文档根节点的 swagger 或 openapi 字段设置为 version（--output-openapi-version）。
+// 映射关系：
+//   - --target 3.1 --output-openapi-version 3.1.0: {openapi: "3.1.1"} -> {openapi: "3.1.0"}
+//
+// 注意：只修改版本字符串，version 必须是转换目标版本可以读取的版本（见 parseArgs 中的检查）
+func setDocumentVersion(root *yaml.Node, version string) {
+	key := "openapi"
+
+	if isSwaggerDocumentNode(root) {
+		key = "swagger"
+	}
+
+	setMappingValue(root, key, newStringNode(version))
+}
+
+// assumeDocumentVersion 在文档没有 swagger 或 openapi 字段时，在根对象开头添加 version 的版本字段（--assume-version）。
+// 映射关系：
+//   - --assume-version 3.0: {info: {...}, paths: {...}} -> {openapi: "3.0.4", info: {...}, paths: {...}}
+//   - --assume-version swagger: {info: {...}, paths: {...}} -> {swagger: "2.0", info: {...}, paths: {...}}
+//
+// 返回：是否添加了版本字段，文档已经有版本字段时（即使是不支持的版本）不做任何修改并返回 false
+func assumeDocumentVersion(root *yaml.Node, version SpecVersion) bool {
+	if mappingValue(root, "swagger") != nil || mappingValue(root, "openapi") != nil {
+		return false
+	}
+
+	key := "openapi"
+
+	if version == Swagger {
+		key = "swagger"
+	}
+
+	keyNode := newStringNode(key)
+
+	// A comment at the top of the file belongs to the first key, keep it at the top.
+	if len(root.Content) > 0 {
+		keyNode.HeadComment = root.Content[0].HeadComment
+		root.Content[0].HeadComment = ""
+	}
+
+	root.Content = append([]*yaml.Node{keyNode, newStringNode(outputVersionString(version))}, root.Content...)
+
+	return true
+}
+
+// versionsReport 生成 --list-versions 输出的 JSON 数据，列出支持的输入和输出版本。
+// 输出格式：{"versions": [{"target": "swagger", "name": "Swagger 2.0", "inputVersions": ["2.0"], "outputVersion": "2.0"}, ...]}
+func versionsReport() ([]byte, error) {
+	report := struct {
+		Versions []specVersionInfo `json:"versions"`
+	}{specVersions}
+
+	return json.MarshalIndent(report, "", "  ")
+}
@@ -6,25 +6,39 @@ if ! [ -d output ]; then
     mkdir output
 fi
 
-echo 'Converting 3.1 spec to 3.0'
-docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
-    < specs/31-spec-with-differences-from-30.yaml \
-    > output/31-spec-with-differences-from-30.converted-30.yaml
-
 exit_code=0
 
-echo 'Validating 3.1 spec converted to 3.0'
-if ! node_modules/.bin/swagger-cli validate output/31-spec-with-differences-from-30.converted-30.yaml; then
-    exit_code=1
-fi
+# convert_and_validate <spec name> <target> [converter arguments...]
+#
+# Converts specs/<spec name>.yaml to the target version as YAML and validates
+# the result, recording a failure in exit_code.
+convert_and_validate() {
+    local name="$1"
+    local target="$2"
+    shift 2
+    local output="output/$name.converted-${target//./}.yaml"
 
-echo 'Converting 3.1 spec to Swagger'
-docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
-    < specs/31-spec-with-differences-from-30.yaml \
-    > output/31-spec-with-differences-from-30.converted-swagger.yaml
+    echo "Converting $name to $target"
+    docker run --rm -i openapi-spec-converter:latest -t "$target" -f yaml "$@" \
+        < "specs/$name.yaml" \
+        > "$output"
+
+    echo "Validating $name converted to $target"
+    if [ "$target" = 3.1 ]; then
+        if ! node_modules/.bin/redocly lint "$output" 2>&1; then
+            exit_code=1
+        fi
+    elif ! node_modules/.bin/swagger-cli validate "$output"; then
+        exit_code=1
+    fi
+}
 
-echo 'Validating 3.1 spec converted to Swagger'
-if ! node_modules/.bin/swagger-cli validate output/31-spec-with-differences-from-30.converted-swagger.yaml; then
+convert_and_validate 31-spec-with-differences-from-30 3.0
+convert_and_validate 31-spec-with-differences-from-30 swagger
+
+# Swagger YAML starts with the quoted version, as the version must be a string.
+if [ "$(head -n 1 output/31-spec-with-differences-from-30.converted-swagger.yaml)" != 'swagger: "2.0"' ]; then
+    echo 'Expected Swagger YAML output to start with swagger: "2.0"'
     exit_code=1
 fi
 
@@ -39,4 +53,1427 @@ if ! node_modules/.bin/redocly lint output/31-spec-with-differences-from-30.back
     exit_code=1
 fi
 
+convert_and_validate 31-referenced-path-items 3.0
+
+# 3.0 has no components.pathItems, so references in paths and callbacks are inlined.
+if grep -q 'pathItems' output/31-referenced-path-items.converted-30.yaml \
+    || [ "$(grep -c 'operationId: listPets\|description: The event was received.' output/31-referenced-path-items.converted-30.yaml)" -ne 2 ]; then
+    echo 'Expected referenced path items to be inlined for 3.0'
+    exit_code=1
+fi
+
+convert_and_validate 31-referenced-path-items swagger
+convert_and_validate 30-referenced-path-items swagger
+docker run --rm -i openapi-spec-converter:latest -t swagger \
+    < specs/30-referenced-path-items.yaml \
+    > /dev/null 2> output/30-referenced-path-items.warnings.txt
+
+if grep -q 'x-path-items' output/30-referenced-path-items.converted-swagger.yaml \
+    || ! grep -q 'operationId: listPets' output/30-referenced-path-items.converted-swagger.yaml \
+    || ! grep -q 'operationId: listOwners' output/30-referenced-path-items.converted-swagger.yaml; then
+    echo 'Expected referenced path items to be inlined for Swagger'
+    exit_code=1
+fi
+
+# The extension holds two referenced path items, but is only dropped once.
+if [ "$(grep -c '#/x-path-items: dropped the extension' output/30-referenced-path-items.warnings.txt)" -ne 1 ]; then
+    echo 'Expected one warning for the dropped x-path-items extension'
+    exit_code=1
+fi
+
+convert_and_validate swagger-base-path-without-host 3.0
+convert_and_validate swagger-base-path-without-host 3.1
+convert_and_validate swagger-grpc-operation-ids 3.0
+
+# gRPC tooling relies on `Service_Method` operation IDs, so they must be kept
+# verbatim from Swagger to 3.0 and back again.
+echo 'Converting swagger-grpc-operation-ids back from 3.0 to swagger'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    < output/swagger-grpc-operation-ids.converted-30.yaml \
+    > output/swagger-grpc-operation-ids.back-to-swagger.yaml
+
+for output in output/swagger-grpc-operation-ids.converted-30.yaml output/swagger-grpc-operation-ids.back-to-swagger.yaml; do
+    if ! grep -q 'operationId: PetService_ListPets$' "$output" \
+        || ! grep -q 'operationId: PetService_CreatePet$' "$output"; then
+        echo "Expected operation IDs to be unchanged in $output"
+        exit_code=1
+    fi
+done
+
+# CreatePet has no summary or description, so its summary and annotation can
+# only come from the gRPC operation ID.
+for flags in '' --no-grpc-summary --no-grpc-annotation '--no-grpc-summary --no-grpc-annotation'; do
+    name=$(echo "grpc$flags" | tr -d ' ')
+    echo "Converting swagger-grpc-operation-ids to swagger with '$flags'"
+    docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml $flags \
+        < output/swagger-grpc-operation-ids.converted-30.yaml \
+        > "output/swagger-grpc-operation-ids.$name.yaml"
+
+    has_summary=yes
+    has_annotation=yes
+
+    case "$flags" in
+        *--no-grpc-summary*) has_summary=no ;;
+    esac
+
+    case "$flags" in
+        *--no-grpc-annotation*) has_annotation=no ;;
+    esac
+
+    if [ "$(grep -q 'summary: CreatePet$' "output/swagger-grpc-operation-ids.$name.yaml" && echo yes || echo no)" != "$has_summary" ] \
+        || [ "$(grep -q '接口方法名称.*CreatePet' "output/swagger-grpc-operation-ids.$name.yaml" && echo yes || echo no)" != "$has_annotation" ]; then
+        echo "Expected the gRPC summary ($has_summary) and annotation ($has_annotation) with '$flags'"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 30-explicit-error-responses swagger
+
+# Explicit error responses and defaults must not be replaced by the gRPC
+# default error response.
+if ! grep -qE "^ +['\"]404['\"]:" output/30-explicit-error-responses.converted-swagger.yaml \
+    || ! grep -q '#/definitions/NotFound' output/30-explicit-error-responses.converted-swagger.yaml \
+    || ! grep -q '#/definitions/Error' output/30-explicit-error-responses.converted-swagger.yaml; then
+    echo 'Expected explicit error responses to be kept for Swagger'
+    exit_code=1
+fi
+
+# GET has no default, so it gains the gRPC one, while DELETE keeps its own.
+if [ "$(grep -c '^ *default:$' output/30-explicit-error-responses.converted-swagger.yaml)" -ne 2 ] \
+    || [ "$(grep -c "\$ref: '#/definitions/rpcStatus'$" output/30-explicit-error-responses.converted-swagger.yaml)" -ne 1 ] \
+    || [ "$(grep -c "\$ref: '#/definitions/Error'$" output/30-explicit-error-responses.converted-swagger.yaml)" -ne 1 ] \
+    || ! sed -n '/^    delete:$/,/^    get:$/p' output/30-explicit-error-responses.converted-swagger.yaml \
+        | grep -q "\$ref: '#/definitions/Error'$"; then
+    echo 'Expected GET to gain the gRPC default error response and DELETE to keep its default'
+    exit_code=1
+fi
+
+convert_and_validate 30-openid-connect swagger
+
+# Swagger has no OpenID Connect, so the scheme and requirements using it are dropped.
+if grep -q 'oidc' output/30-openid-connect.converted-swagger.yaml \
+    || [ "$(grep -c -- '- apiKey: \[\]' output/30-openid-connect.converted-swagger.yaml)" -ne 2 ]; then
+    echo 'Expected the openIdConnect security scheme to be dropped for Swagger'
+    exit_code=1
+fi
+
+convert_and_validate 30-openid-connect-only swagger
+docker run --rm -i openapi-spec-converter:latest -t swagger \
+    < specs/30-openid-connect-only.yaml \
+    > /dev/null 2> output/30-openid-connect-only.warnings.txt
+
+# An operation whose only requirement used OpenID Connect inherits the root
+# security instead of becoming public with `security: []`.
+if [ "$(grep -c '^ *security:' output/30-openid-connect-only.converted-swagger.yaml)" -ne 2 ] \
+    || [ "$(grep -c 'security: \[\]$' output/30-openid-connect-only.converted-swagger.yaml)" -ne 1 ] \
+    || ! grep -q '#/paths/~1pets/get/security: dropped every security requirement' output/30-openid-connect-only.warnings.txt; then
+    echo 'Expected operations that only required OpenID Connect to inherit the root security'
+    exit_code=1
+fi
+
+convert_and_validate 30-readonly-and-writeonly swagger
+
+# A property can't be both, so readOnly is kept and it is no longer required.
+if ! grep -q 'readOnly: true' output/30-readonly-and-writeonly.converted-swagger.yaml \
+    || grep -q '^ *writeOnly:' output/30-readonly-and-writeonly.converted-swagger.yaml \
+    || grep -q -- '- token' output/30-readonly-and-writeonly.converted-swagger.yaml; then
+    echo 'Expected readOnly to win over writeOnly for Swagger'
+    exit_code=1
+fi
+
+convert_and_validate 30-responses-without-content swagger --produces-default application/json
+
+# Operations without response content get the default, others keep their own.
+if [ "$(grep -c -- '- application/json' output/30-responses-without-content.converted-swagger.yaml)" -ne 1 ] \
+    || [ "$(grep -c -- '- application/xml' output/30-responses-without-content.converted-swagger.yaml)" -ne 1 ]; then
+    echo 'Expected --produces-default to only set produces where it is missing'
+    exit_code=1
+fi
+
+convert_and_validate 31-duplicate-generated-schemas 3.0 --dedupe-schemas
+convert_and_validate 31-discriminator-mapping-with-duplicates 3.0 --dedupe-schemas
+
+# Discriminator mappings must still point at existing components after dedupe.
+for name in Cat Dog; do
+    if ! grep -q "'#/components/schemas/$name'" output/31-discriminator-mapping-with-duplicates.converted-30.yaml \
+        || ! grep -q "^    $name:" output/31-discriminator-mapping-with-duplicates.converted-30.yaml; then
+        echo "Expected the discriminator mapping for $name to resolve after dedupe"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 30-generated-component-name-collision 3.1 --dedupe-schemas --components-prefix Pets
+
+# The author defined Generated1 must be kept, and the hoisted schema namespaced.
+if ! grep -q 'An author defined schema' output/30-generated-component-name-collision.converted-31.yaml \
+    || ! grep -q '^    Pets_Generated1:' output/30-generated-component-name-collision.converted-31.yaml; then
+    echo 'Expected hoisted schemas to use the --components-prefix'
+    exit_code=1
+fi
+
+convert_and_validate swagger-escaped-component-names 3.0 --dedupe-schemas
+convert_and_validate swagger-escaped-component-names swagger --dedupe-schemas --flatten-allof
+
+# The inline schema is replaced with a $ref to `pets.v1/Pet`, which must be escaped.
+if [ "$(grep -c "\$ref: '#/components/schemas/pets.v1~1Pet'" output/swagger-escaped-component-names.converted-30.yaml)" -ne 2 ] \
+    || ! grep -q "\$ref: '#/definitions/pets.v1~1Pet'" output/swagger-escaped-component-names.converted-swagger.yaml; then
+    echo 'Expected $refs to component names with a slash to be escaped'
+    exit_code=1
+fi
+
+convert_and_validate 30-multi-line-examples 3.1 --preserve-examples-format
+convert_and_validate 30-multi-line-examples swagger --preserve-examples-format
+
+# Swagger is converted through JSON, which loses the YAML style of examples.
+if ! grep -q 'example: >$' output/30-multi-line-examples.converted-swagger.yaml \
+    || ! grep -q 'example: 2024-01-02$' output/30-multi-line-examples.converted-swagger.yaml; then
+    echo 'Expected --preserve-examples-format to keep folded strings and dates'
+    exit_code=1
+fi
+
+convert_and_validate 30-schema-names-with-spaces swagger --sanitize-names
+
+# `Owner (v2)` sanitizes to `Owner__v2_`, which is already used by another schema.
+if grep -q 'Pet Summary\|Owner (v2)' output/30-schema-names-with-spaces.converted-swagger.yaml \
+    || [ "$(grep -c "\$ref: '#/definitions/Owner__v2__2'" output/30-schema-names-with-spaces.converted-swagger.yaml)" -ne 2 ]; then
+    echo 'Expected --sanitize-names to rename definitions and update $refs'
+    exit_code=1
+fi
+
+convert_and_validate 30-multipart-encoding-headers 3.1
+convert_and_validate 30-multipart-encoding-headers swagger
+
+if grep -q 'nullable' output/30-multipart-encoding-headers.converted-31.yaml; then
+    echo 'Expected the schemas of multipart encoding headers to be converted to 3.1'
+    exit_code=1
+fi
+
+convert_and_validate 30-mapped-formats 3.1 --map-format int64=long
+convert_and_validate 30-mapped-formats swagger --map-format int64=long
+
+for output in output/30-mapped-formats.converted-31.yaml output/30-mapped-formats.converted-swagger.yaml; do
+    if grep -q 'int64' "$output" \
+        || [ "$(grep -c 'format: long' "$output")" -ne 3 ] \
+        || ! grep -q 'format: int32' "$output"; then
+        echo "Expected --map-format to rewrite int64 to long in $output"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 31-info-summary 3.0 --preserve-info-summary
+convert_and_validate 31-info-summary swagger --preserve-info-summary
+
+for output in output/31-info-summary.converted-30.yaml output/31-info-summary.converted-swagger.yaml; do
+    if ! grep -q 'A short summary of the pet store API' "$output" \
+        || ! grep -q 'Manage the pets in the store.' "$output"; then
+        echo "Expected --preserve-info-summary to keep the summary in the description in $output"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 30-templated-servers 3.1
+convert_and_validate 31-templated-servers 3.0
+
+for output in output/30-templated-servers.converted-31.yaml output/31-templated-servers.converted-30.yaml; do
+    if ! grep -q 'url: https://{region}.api.example.com/{basePath}' "$output" \
+        || ! grep -q 'default: eu-west' "$output" \
+        || ! grep -q -- '- ap-south' "$output" \
+        || ! grep -q 'description: The region the API is served from.' "$output"; then
+        echo "Expected the server variables to be kept in $output"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 30-large-integers 3.1
+convert_and_validate 30-large-integers swagger
+
+# Convert 3.1 back to 3.0 to check integers survive the round trip.
+echo 'Converting 30-large-integers back from 3.1 to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < output/30-large-integers.converted-31.yaml \
+    > output/30-large-integers.back-to-30.yaml
+
+for output in output/30-large-integers.converted-31.yaml output/30-large-integers.converted-swagger.yaml output/30-large-integers.back-to-30.yaml; do
+    if [ "$(grep -c '9007199254740993$' "$output")" -lt 3 ] \
+        || [ "$(grep -c '9223372036854775807$' "$output")" -ne 3 ] \
+        || ! grep -q -- '-9223372036854775808$' "$output"; then
+        echo "Expected large integers to keep their precision in $output"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 30-ambiguous-large-integers 3.1
+docker run --rm -i openapi-spec-converter:latest -t 3.1 \
+    < specs/30-ambiguous-large-integers.yaml \
+    > /dev/null 2> output/30-ambiguous-large-integers.warnings.txt
+
+# 2^53 and 2^53 + 1 can't be told apart after float64, so the bound keeps an
+# integer notation and is reported.
+if grep -q 'e+15' output/30-ambiguous-large-integers.converted-31.yaml \
+    || ! grep -q 'exclusiveMaximum: 9007199254740992$' output/30-ambiguous-large-integers.converted-31.yaml \
+    || ! grep -q 'Id/exclusiveMaximum: 9007199254740992 may have lost precision' output/30-ambiguous-large-integers.warnings.txt; then
+    echo 'Expected ambiguous large integers to keep an integer notation with a warning'
+    exit_code=1
+fi
+
+# Integers must stay integers through conversion and the change to JSON.
+for target in 3.1 swagger; do
+    echo "Converting 30-integer-defaults to $target as JSON"
+    docker run --rm -i openapi-spec-converter:latest -t "$target" -f json \
+        < specs/30-integer-defaults.yaml \
+        > "output/30-integer-defaults.converted-${target//./}.json"
+
+    if ! grep -Eq '"default": ?5($|[,}])' "output/30-integer-defaults.converted-${target//./}.json" \
+        || ! grep -Eq '"default": ?1($|[,}])' "output/30-integer-defaults.converted-${target//./}.json" \
+        || grep -Eq '"(default|maximum|exclusiveMaximum)": ?(5|1|100)\.' "output/30-integer-defaults.converted-${target//./}.json"; then
+        echo "Expected integer defaults to stay integers for $target"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 30-range-response-codes swagger
+
+if grep -q '[0-9][xX][xX]' output/30-range-response-codes.converted-swagger.yaml \
+    || [ "$(grep -c '"200":' output/30-range-response-codes.converted-swagger.yaml)" -ne 3 ] \
+    || ! grep -q 'description: The pet.' output/30-range-response-codes.converted-swagger.yaml; then
+    echo 'Expected range response codes to be converted to status codes for Swagger'
+    exit_code=1
+fi
+
+convert_and_validate 30-undeclared-tags 3.1 --declare-tags
+convert_and_validate 30-undeclared-tags swagger --declare-tags
+
+for output in output/30-undeclared-tags.converted-31.yaml output/30-undeclared-tags.converted-swagger.yaml; do
+    if [ "$(grep -c 'name: pets$' "$output")" -ne 1 ] \
+        || ! grep -q 'name: owners$' "$output" \
+        || ! grep -q 'name: admin$' "$output" \
+        || ! grep -q 'description: Everything about the pets.' "$output"; then
+        echo "Expected --declare-tags to add the undeclared operation tags in $output"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 30-parameter-flags 3.1
+convert_and_validate 30-parameter-flags swagger
+
+if [ "$(grep -c 'allowEmptyValue: true' output/30-parameter-flags.converted-31.yaml)" -ne 2 ] \
+    || [ "$(grep -c 'allowReserved: true' output/30-parameter-flags.converted-31.yaml)" -ne 2 ]; then
+    echo 'Expected allowEmptyValue and allowReserved to be kept for 3.1'
+    exit_code=1
+fi
+
+if [ "$(grep -c 'allowEmptyValue: true' output/30-parameter-flags.converted-swagger.yaml)" -ne 2 ] \
+    || grep -q 'allowReserved' output/30-parameter-flags.converted-swagger.yaml; then
+    echo 'Expected allowEmptyValue to be kept and allowReserved to be dropped for Swagger'
+    exit_code=1
+fi
+
+convert_and_validate 30-referenced-examples 3.1
+convert_and_validate 30-referenced-examples swagger
+
+# Swagger has no components.examples, so referenced examples must be inlined.
+if grep -q 'components/examples' output/30-referenced-examples.converted-swagger.yaml \
+    || ! grep -q 'name: Rex' output/30-referenced-examples.converted-swagger.yaml; then
+    echo 'Expected referenced response examples to be inlined for Swagger'
+    exit_code=1
+fi
+
+convert_and_validate 30-nullable-anyof 3.1 --modern-nullable-anyof
+
+# Every nullable schema with a type becomes anyOf: [schema, {type: "null"}].
+if grep -q 'nullable\|^ *- "null"$' output/30-nullable-anyof.converted-31.yaml \
+    || [ "$(grep -c -- '- type: "null"$' output/30-nullable-anyof.converted-31.yaml)" -ne 6 ]; then
+    echo 'Expected --modern-nullable-anyof to convert nullable schemas to anyOf'
+    exit_code=1
+fi
+
+convert_and_validate swagger-x-nullable 3.0
+convert_and_validate swagger-x-nullable 3.1
+
+# x-nullable on schemas, $ref siblings and parameters becomes "null" in 3.1.
+if grep -q 'x-nullable\|nullable:' output/swagger-x-nullable.converted-31.yaml \
+    || [ "$(grep -c '^ *- "null"$' output/swagger-x-nullable.converted-31.yaml)" -ne 3 ] \
+    || [ "$(grep -c -- '- type: "null"$' output/swagger-x-nullable.converted-31.yaml)" -ne 1 ]; then
+    echo 'Expected x-nullable to be converted to 3.1 type arrays and anyOf'
+    exit_code=1
+fi
+
+convert_and_validate 30-path-level-parameters 3.1
+convert_and_validate 30-path-level-parameters swagger
+
+# Shared parameters stay on the path item, and their schemas are converted.
+if ! grep -q '^    parameters:$' output/30-path-level-parameters.converted-31.yaml \
+    || ! grep -q 'exclusiveMinimum: 1$' output/30-path-level-parameters.converted-31.yaml \
+    || ! grep -q '^ *- "null"$' output/30-path-level-parameters.converted-31.yaml \
+    || ! grep -q '^    parameters:$' output/30-path-level-parameters.converted-swagger.yaml \
+    || [ "$(grep -c 'name: petId$' output/30-path-level-parameters.converted-swagger.yaml)" -ne 1 ]; then
+    echo 'Expected path level parameters to be kept and converted'
+    exit_code=1
+fi
+
+convert_and_validate 30-multiple-consumes swagger --single-consumes
+
+if grep -q 'application/xml\|text/plain' output/30-multiple-consumes.converted-swagger.yaml \
+    || [ "$(grep -c -- '- application/json$' output/30-multiple-consumes.converted-swagger.yaml)" -ne 2 ] \
+    || [ "$(grep -c "\$ref: '#/definitions/Pet'$" output/30-multiple-consumes.converted-swagger.yaml)" -ne 2 ]; then
+    echo 'Expected --single-consumes to keep one consumes and produces media type, and the JSON body schema'
+    exit_code=1
+fi
+
+convert_and_validate 30-binary-request-bodies swagger
+
+# Binary request bodies with several media types keep a binary body schema.
+if [ "$(grep -c 'format: binary$' output/30-binary-request-bodies.converted-swagger.yaml)" -ne 2 ] \
+    || grep -q 'in: formData' output/30-binary-request-bodies.converted-swagger.yaml; then
+    echo 'Expected binary request bodies with several media types to keep a binary body schema'
+    exit_code=1
+fi
+
+convert_and_validate 30-binary-additional-properties 3.1
+
+# Binary map values lose their format like binary properties do.
+if grep -q 'format: binary\|format: byte' output/30-binary-additional-properties.converted-31.yaml; then
+    echo 'Expected binary additionalProperties schemas to be converted'
+    exit_code=1
+fi
+
+convert_and_validate 30-implicit-discriminator-mapping 3.1 --dedupe-schemas
+
+# The hoisted Generated1 schema extends Pet, but isn't one of the original choices.
+if [ "$(grep -c "Cat: '#/components/schemas/Cat'$" output/30-implicit-discriminator-mapping.converted-31.yaml)" -ne 2 ] \
+    || [ "$(grep -c "Dog: '#/components/schemas/Dog'$" output/30-implicit-discriminator-mapping.converted-31.yaml)" -ne 2 ] \
+    || grep -q "Generated1: '#" output/30-implicit-discriminator-mapping.converted-31.yaml; then
+    echo 'Expected implicit discriminator mappings to be made explicit before dedupe'
+    exit_code=1
+fi
+
+convert_and_validate 31-referenced-schemas 3.0
+
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < specs/31-referenced-schemas.yaml \
+    > /dev/null \
+    2> output/31-referenced-schemas.warnings.txt
+
+# Schemas referenced from parameters and content are converted once, where they are defined.
+if [ "$(grep -c 'schemas/Tag/examples' output/31-referenced-schemas.warnings.txt)" -ne 1 ] \
+    || [ "$(grep -c 'schemas/Pet/examples' output/31-referenced-schemas.warnings.txt)" -ne 1 ]; then
+    echo 'Expected schemas referenced from parameters and content to be converted once'
+    exit_code=1
+fi
+
+convert_and_validate 31-conditional-schemas 3.0
+convert_and_validate 31-conditional-schemas swagger
+
+# Conditionals are rewritten with oneOf and not, and their schemas converted too.
+if grep -q '^ *\(if\|then\|else\|examples\):' output/31-conditional-schemas.converted-30.yaml \
+    || [ "$(grep -c 'not:$' output/31-conditional-schemas.converted-30.yaml)" -ne 2 ] \
+    || [ "$(grep -c 'nullable: true$' output/31-conditional-schemas.converted-30.yaml)" -ne 2 ] \
+    || grep -q '^ *\(if\|then\|else\):' output/31-conditional-schemas.converted-swagger.yaml; then
+    echo 'Expected if/then/else to be rewritten for 3.0 and Swagger'
+    exit_code=1
+fi
+
+convert_and_validate 30-trailing-slash-paths 3.1 --merge-trailing-slash
+
+# /owners/ has no /owners to merge into, so it is kept.
+if grep -q '^  /pets/:$\|listPetsWithSlash' output/30-trailing-slash-paths.converted-31.yaml \
+    || ! grep -q 'operationId: createPet$' output/30-trailing-slash-paths.converted-31.yaml \
+    || ! grep -q 'name: X-Request-Id$' output/30-trailing-slash-paths.converted-31.yaml \
+    || [ "$(grep -c 'name: limit$' output/30-trailing-slash-paths.converted-31.yaml)" -ne 1 ] \
+    || ! grep -q '^  /owners/:$' output/30-trailing-slash-paths.converted-31.yaml; then
+    echo 'Expected --merge-trailing-slash to merge /pets/ into /pets'
+    exit_code=1
+fi
+
+convert_and_validate 30-templated-servers 3.0 --yaml-explicit
+
+if [ "$(head -n 1 output/30-templated-servers.converted-30.yaml)" != '---' ]; then
+    echo 'Expected --yaml-explicit to start the YAML output with a document marker'
+    exit_code=1
+fi
+
+convert_and_validate 31-array-contains 3.0
+convert_and_validate 31-array-contains swagger
+
+for output in output/31-array-contains.converted-30.yaml output/31-array-contains.converted-swagger.yaml; do
+    if grep -q 'contains:\|minContains\|maxContains' "$output"; then
+        echo "Expected contains, minContains and maxContains to be removed in $output"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 31-boolean-response-schemas 3.0
+convert_and_validate 31-boolean-response-schemas swagger
+
+# `true` and missing response schemas both allow anything, and become `{}`.
+for output in output/31-boolean-response-schemas.converted-30.yaml output/31-boolean-response-schemas.converted-swagger.yaml; do
+    if grep -q 'schema: true' "$output" || [ "$(grep -c 'schema: {}$' "$output")" -lt 3 ]; then
+        echo "Expected boolean response schemas to become empty schemas in $output"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 30-templated-servers swagger --keep-server-description
+
+if ! grep -q '^x-server-description: Regional API$' output/30-templated-servers.converted-swagger.yaml; then
+    echo 'Expected --keep-server-description to keep the server description as x-server-description'
+    exit_code=1
+fi
+
+convert_and_validate 30-missing-operation-id swagger --host api.example.com --base-path /v1 --schemes https
+
+# The input has no servers, so the location only comes from the options.
+if ! grep -q '^host: api.example.com$' output/30-missing-operation-id.converted-swagger.yaml \
+    || ! grep -q '^basePath: /v1$' output/30-missing-operation-id.converted-swagger.yaml \
+    || ! grep -q '^schemes:$' output/30-missing-operation-id.converted-swagger.yaml \
+    || ! grep -q '^  - https$' output/30-missing-operation-id.converted-swagger.yaml; then
+    echo 'Expected --host, --base-path and --schemes to set the Swagger location'
+    exit_code=1
+fi
+
+convert_and_validate 31-referenced-content-parameters 3.0
+
+if grep -q "contains:\|- 'null'" output/31-referenced-content-parameters.converted-30.yaml \
+    || [ "$(grep -c 'nullable: true$' output/31-referenced-content-parameters.converted-30.yaml)" -ne 2 ]; then
+    echo 'Expected the schemas of content parameters to be converted'
+    exit_code=1
+fi
+
+# Tags is referenced by a component parameter, but is only converted once.
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < specs/31-referenced-content-parameters.yaml \
+    > /dev/null \
+    2> output/31-referenced-content-parameters.warnings.txt
+
+if [ "$(grep -c 'schemas/Tags/contains' output/31-referenced-content-parameters.warnings.txt)" -ne 1 ]; then
+    echo 'Expected schemas referenced by parameters to be converted once'
+    exit_code=1
+fi
+
+convert_and_validate 30-nullable-type-arrays 3.1
+
+# Each hybrid type array must end up with exactly one null.
+if [ "$(grep -cE "^ *- [\"']null[\"']$" output/30-nullable-type-arrays.converted-31.yaml)" -ne 2 ] \
+    || grep -q 'nullable:' output/30-nullable-type-arrays.converted-31.yaml; then
+    echo 'Expected nullable type arrays to get a single null type'
+    exit_code=1
+fi
+
+# Documents without info fail validation unless --ensure-info adds one.
+convert_and_validate 30-without-info 3.1 --ensure-info
+convert_and_validate 30-without-info swagger --ensure-info
+
+if ! grep -q 'title: Converted API' output/30-without-info.converted-swagger.yaml; then
+    echo 'Expected --ensure-info to add an info title'
+    exit_code=1
+fi
+
+echo 'Converting 30-without-info to Swagger without --ensure-info'
+if docker run --rm -i openapi-spec-converter:latest -t swagger \
+    < specs/30-without-info.yaml \
+    > /dev/null \
+    2> output/30-without-info.errors.txt \
+    || ! grep -q 'use --ensure-info' output/30-without-info.errors.txt; then
+    echo 'Expected a missing info to be reported for Swagger instead of crashing'
+    exit_code=1
+fi
+
+convert_and_validate 30-integer-formats 3.1 --upgrade-int-formats
+
+# The limit parameter keeps its own minimum, so only age gets the int32 minimum.
+if [ "$(grep -c 'minimum: -2147483648$' output/30-integer-formats.converted-31.yaml)" -ne 1 ] \
+    || [ "$(grep -c 'maximum: 2147483647$' output/30-integer-formats.converted-31.yaml)" -ne 2 ] \
+    || ! grep -q 'maximum: 9223372036854775807$' output/30-integer-formats.converted-31.yaml; then
+    echo 'Expected --upgrade-int-formats to add int32 and int64 bounds'
+    exit_code=1
+fi
+
+# Parameters with both schema and content are invalid, so the content is dropped.
+convert_and_validate 30-parameter-schema-and-content 3.1
+convert_and_validate 30-parameter-schema-and-content swagger
+
+if grep -q 'content:' output/30-parameter-schema-and-content.converted-31.yaml; then
+    echo 'Expected the content of parameters with a schema to be dropped'
+    exit_code=1
+fi
+
+docker run --rm -i openapi-spec-converter:latest -t 3.1 -f yaml \
+    < specs/30-parameter-schema-and-content.yaml \
+    > /dev/null \
+    2> output/30-parameter-schema-and-content.warnings.txt
+
+if ! grep -q 'paths/~1pets/get/parameters/0/content: dropped the content' output/30-parameter-schema-and-content.warnings.txt \
+    || ! grep -q 'components/parameters/Limit/content: dropped the content' output/30-parameter-schema-and-content.warnings.txt; then
+    echo 'Expected warnings for the dropped parameter content'
+    exit_code=1
+fi
+
+convert_and_validate 30-empty-component-schemas 3.1
+convert_and_validate 30-empty-component-schemas swagger --clean
+
+# Only the gRPC error definitions are left.
+if grep -q '^definitions: {}' output/30-empty-component-schemas.converted-swagger.yaml \
+    || [ "$(sed -n '/^definitions:$/,$p' output/30-empty-component-schemas.converted-swagger.yaml | grep -c '^  [^ ]')" -ne 2 ]; then
+    echo 'Expected no definitions for empty components.schemas'
+    exit_code=1
+fi
+
+convert_and_validate 30-empty-component-schemas 3.0 --clean
+
+if grep -q '^components:\|schemas:' output/30-empty-component-schemas.converted-30.yaml; then
+    echo 'Expected --clean to remove empty components.schemas'
+    exit_code=1
+fi
+
+convert_and_validate 31-primitive-type-arrays 3.0 --flatten-nullable-oneof
+
+# boolean and integer have no common type, and format makes a branch more than a type.
+if [ "$(grep -c 'oneOf:$' output/31-primitive-type-arrays.converted-30.yaml)" -ne 2 ] \
+    || [ "$(grep -c 'type: string$' output/31-primitive-type-arrays.converted-30.yaml)" -ne 3 ] \
+    || [ "$(grep -c 'nullable: true$' output/31-primitive-type-arrays.converted-30.yaml)" -ne 2 ]; then
+    echo 'Expected --flatten-nullable-oneof to merge oneOf of string and integer into string'
+    exit_code=1
+fi
+
+convert_and_validate 30-null-examples 3.1
+
+# Explicit null examples are kept, and tag, which has no example, doesn't get one.
+echo 'Converting 30-null-examples back from 3.1 to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < output/30-null-examples.converted-31.yaml \
+    > output/30-null-examples.back-to-30.yaml
+
+if [ "$(grep -c -- '- null$' output/30-null-examples.converted-31.yaml)" -ne 2 ] \
+    || [ "$(grep -c 'example: null$' output/30-null-examples.back-to-30.yaml)" -ne 2 ] \
+    || [ "$(grep -c ' example:' output/30-null-examples.back-to-30.yaml)" -ne 3 ]; then
+    echo 'Expected explicit null examples to be converted to 3.1 and back'
+    exit_code=1
+fi
+
+convert_and_validate 30-shared-responses swagger --inline-response-refs
+
+# Error is used twice, and is still defined in the root responses.
+if grep -q "\$ref: '#/responses/" output/30-shared-responses.converted-swagger.yaml \
+    || [ "$(grep -c 'description: An unexpected error.$' output/30-shared-responses.converted-swagger.yaml)" -ne 3 ] \
+    || [ "$(grep -c "\$ref: '#/definitions/Error'$" output/30-shared-responses.converted-swagger.yaml)" -ne 3 ]; then
+    echo 'Expected --inline-response-refs to inline the shared responses'
+    exit_code=1
+fi
+
+convert_and_validate 30-read-and-write-only-properties 3.1 --strip-readonly
+convert_and_validate 30-read-and-write-only-properties swagger --strip-writeonly
+
+if grep -q '^        id:\|- id$\|audit:' output/30-read-and-write-only-properties.converted-31.yaml \
+    || ! grep -q 'password:' output/30-read-and-write-only-properties.converted-31.yaml; then
+    echo 'Expected --strip-readonly to remove only readOnly properties'
+    exit_code=1
+fi
+
+if grep -q 'writeOnly\|password' output/30-read-and-write-only-properties.converted-swagger.yaml \
+    || ! grep -q 'id:' output/30-read-and-write-only-properties.converted-swagger.yaml; then
+    echo 'Expected --strip-writeonly to remove only writeOnly properties'
+    exit_code=1
+fi
+
+convert_and_validate 31-content-encoding-with-format 3.0
+
+# occurredAt keeps its date-time format, the others get a format from their content fields.
+if grep -q 'contentEncoding\|contentMediaType' output/31-content-encoding-with-format.converted-30.yaml \
+    || ! grep -q 'format: date-time$' output/31-content-encoding-with-format.converted-30.yaml \
+    || ! grep -q 'format: base64$' output/31-content-encoding-with-format.converted-30.yaml \
+    || ! grep -q 'format: binary$' output/31-content-encoding-with-format.converted-30.yaml; then
+    echo 'Expected content fields to become formats without replacing an existing format'
+    exit_code=1
+fi
+
+convert_and_validate 30-multiple-response-media-types swagger
+
+# Each response keeps one media type, which is listed in the operation's produces.
+if grep -q -- '- application/xml$' output/30-multiple-response-media-types.converted-swagger.yaml \
+    || ! grep -q -- '- image/png$' output/30-multiple-response-media-types.converted-swagger.yaml; then
+    echo 'Expected one media type per response in produces'
+    exit_code=1
+fi
+
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    < specs/30-multiple-response-media-types.yaml \
+    > /dev/null \
+    2> output/30-multiple-response-media-types.warnings.txt
+
+if ! grep -q '~1pets/get/responses/200/content/application~1xml: dropped response media type application/xml, Swagger responses use application/json$' \
+        output/30-multiple-response-media-types.warnings.txt \
+    || ! grep -q 'components/responses/NotFound/content/text~1plain: dropped response media type text/plain, Swagger responses use application/json$' \
+        output/30-multiple-response-media-types.warnings.txt; then
+    echo 'Expected warnings for the dropped response media types'
+    exit_code=1
+fi
+
+convert_and_validate 30-multiple-response-media-types swagger --response-media application/xml
+
+# listPets returns XML, but NotFound has no XML, so it keeps JSON.
+if [ "$(grep -A1 'produces:' output/30-multiple-response-media-types.converted-swagger.yaml | grep -c -- '- application/xml$')" -ne 1 ]; then
+    echo 'Expected --response-media to list application/xml first in produces'
+    exit_code=1
+fi
+
+convert_and_validate 30-path-and-operation-servers 3.1
+
+# 3.1 has path and operation servers, so nothing is dropped.
+if ! grep -q 'url: https://pets.example.com/v1$' output/30-path-and-operation-servers.converted-31.yaml \
+    || ! grep -q 'url: https://pets-write.example.com/v1$' output/30-path-and-operation-servers.converted-31.yaml; then
+    echo 'Expected path and operation servers to be kept for 3.1'
+    exit_code=1
+fi
+
+convert_and_validate 30-path-and-operation-servers swagger
+
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    < specs/30-path-and-operation-servers.yaml \
+    > /dev/null \
+    2> output/30-path-and-operation-servers.warnings.txt
+
+if grep -q 'pets.example.com\|pets-write.example.com' output/30-path-and-operation-servers.converted-swagger.yaml \
+    || ! grep -q '#/paths/~1pets/servers: ' output/30-path-and-operation-servers.warnings.txt \
+    || ! grep -q '#/paths/~1pets/post/servers: ' output/30-path-and-operation-servers.warnings.txt; then
+    echo 'Expected path and operation servers to be dropped for Swagger with warnings'
+    exit_code=1
+fi
+
+convert_and_validate 30-nested-nullable-properties 3.1
+
+if grep -q '^ *nullable:' output/30-nested-nullable-properties.converted-31.yaml; then
+    echo 'Expected nested schemas to be converted to 3.1'
+    exit_code=1
+fi
+
+convert_and_validate 30-items-without-array-type 3.1
+convert_and_validate 30-parameterized-media-types 3.1
+convert_and_validate 30-parameterized-media-types swagger
+
+echo 'Converting 30-parameterized-media-types back from 3.1 to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < output/30-parameterized-media-types.converted-31.yaml \
+    > output/30-parameterized-media-types.back-to-30.yaml
+
+# The upload schema is implied in 3.1 and added back for 3.0, even with a charset parameter.
+if grep -q 'format: binary' output/30-parameterized-media-types.converted-31.yaml \
+    || ! grep -A3 'application/octet-stream; charset=binary:' output/30-parameterized-media-types.back-to-30.yaml | grep -q 'format: binary$' \
+    || ! grep -q 'format: binary$' output/30-parameterized-media-types.converted-swagger.yaml \
+    || [ "$(grep -c "\$ref: '#/definitions/Pet'$" output/30-parameterized-media-types.converted-swagger.yaml)" -ne 2 ]; then
+    echo 'Expected media types with parameters to be matched by the upload and response fixups'
+    exit_code=1
+fi
+
+convert_and_validate 30-duplicate-required-properties 3.1
+convert_and_validate 30-duplicate-required-properties swagger
+convert_and_validate swagger-duplicate-required-properties 3.0
+
+# Each property name is listed once; id is readOnly, so it isn't required for Swagger at all.
+for output in output/30-duplicate-required-properties.converted-31.yaml \
+    output/30-duplicate-required-properties.converted-swagger.yaml \
+    output/swagger-duplicate-required-properties.converted-30.yaml; do
+    expected_ids=1
+
+    if [ "$output" = output/30-duplicate-required-properties.converted-swagger.yaml ]; then
+        expected_ids=0
+    fi
+
+    if [ "$(grep -c -- '- name$' "$output")" -ne 2 ] \
+        || [ "$(grep -c -- '- id$' "$output")" -ne "$expected_ids" ]; then
+        echo "Expected duplicate required properties to be removed in $output"
+        exit_code=1
+    fi
+done
+
+convert_and_validate 31-keyed-schema-examples 3.0
+
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < specs/31-keyed-schema-examples.yaml \
+    > /dev/null \
+    2> output/31-keyed-schema-examples.warnings.txt
+
+# The examples of name are keyed like media type examples, so the first one is used.
+if grep -q 'examples:\|Rex' output/31-keyed-schema-examples.converted-30.yaml \
+    || ! grep -q 'example: Whiskers$' output/31-keyed-schema-examples.converted-30.yaml \
+    || ! grep -q 'example: 3$' output/31-keyed-schema-examples.converted-30.yaml \
+    || ! grep -q 'schemas/Pet/properties/name/examples: schema examples should be an array, using the "cat" example$' \
+        output/31-keyed-schema-examples.warnings.txt; then
+    echo 'Expected the first keyed schema example to be used, with a warning'
+    exit_code=1
+fi
+
+convert_and_validate 30-allof-object-schemas swagger --flatten-allof
+
+# Pet gets the properties and required names of Named and its own object schema.
+pet=$(awk '/^  Pet:$/ { found = 1; next } found && /^  [^ ]/ { exit } found' output/30-allof-object-schemas.converted-swagger.yaml)
+
+if grep -q '^ *allOf:' output/30-allof-object-schemas.converted-swagger.yaml \
+    || [ "$(echo "$pet" | grep -cE '^ {6}(name|id|tag):$')" -ne 3 ] \
+    || [ "$(echo "$pet" | grep -cE -- '- (name|id)$')" -ne 2 ]; then
+    echo 'Expected --flatten-allof to merge the allOf properties and required names'
+    exit_code=1
+fi
+
+convert_and_validate 31-null-type-branches 3.0
+
+# null must match exactly one oneOf branch, so only one branch of age, previousOwner, contact and
+# the weight type array is nullable.
+if grep -q "type: 'null'\|type: \"null\"" output/31-null-type-branches.converted-30.yaml \
+    || [ "$(grep -c 'nullable: true$' output/31-null-type-branches.converted-30.yaml)" -ne 7 ] \
+    || [ "$(sed -n '/^ *weight:$/,$p' output/31-null-type-branches.converted-30.yaml | grep -c 'nullable: true$')" -ne 1 ]; then
+    echo 'Expected null type branches to become nullable, on one branch of each oneOf'
+    exit_code=1
+fi
+
+convert_and_validate 30-nullable-references 3.1
+
+if grep -q 'nullable' output/30-nullable-references.converted-31.yaml; then
+    echo 'Expected nullable references to become anyOf with {type: "null"}'
+    exit_code=1
+fi
+
+convert_and_validate swagger-array-response-headers 3.0
+convert_and_validate swagger-array-response-headers 3.1
+
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < specs/swagger-array-response-headers.yaml \
+    > /dev/null \
+    2> output/swagger-array-response-headers.warnings.txt
+
+# Both array headers use comma separated values, and only pipes has to be warned about.
+# explode: false is the default for the simple style, so the 3.1 output leaves it out.
+if [ "$(grep -c 'style: simple$' output/swagger-array-response-headers.converted-30.yaml)" -ne 2 ] \
+    || [ "$(grep -c 'explode: false$' output/swagger-array-response-headers.converted-30.yaml)" -ne 2 ] \
+    || [ "$(grep -c 'style: simple$' output/swagger-array-response-headers.converted-31.yaml)" -ne 2 ] \
+    || grep -q 'explode: true$' output/swagger-array-response-headers.converted-31.yaml; then
+    echo 'Expected style: simple and explode: false on the array headers'
+    exit_code=1
+fi
+
+if [ "$(grep -c 'collectionFormat' output/swagger-array-response-headers.warnings.txt)" -ne 1 ] \
+    || ! grep -q 'headers/X-Pet-Ids: collectionFormat pipes has no OpenAPI 3.0 header style' \
+        output/swagger-array-response-headers.warnings.txt; then
+    echo 'Expected a warning for the pipes collectionFormat'
+    exit_code=1
+fi
+
+convert_and_validate 30-response-examples swagger
+
+# Every response has an example, which Swagger keeps under the media type in `examples`.
+if [ "$(grep -A1 '^ *examples:$' output/30-response-examples.converted-swagger.yaml | grep -c 'application/json:$')" -ne 3 ] \
+    || ! grep -q 'message: Not found$' output/30-response-examples.converted-swagger.yaml \
+    || ! grep -q 'name: Whiskers$' output/30-response-examples.converted-swagger.yaml; then
+    echo 'Expected response examples to be kept under examples for Swagger'
+    exit_code=1
+fi
+
+convert_and_validate 31-prefix-and-additional-items 3.0
+convert_and_validate 31-duplicate-type-entries 3.0
+
+# Each repeated type is kept once: name and tag stay single types, and age has one branch per type.
+if [ "$(grep -c 'oneOf:$' output/31-duplicate-type-entries.converted-30.yaml)" -ne 1 ] \
+    || [ "$(grep -c -- '- type: ' output/31-duplicate-type-entries.converted-30.yaml)" -ne 2 ] \
+    || [ "$(grep -c 'type: integer$' output/31-duplicate-type-entries.converted-30.yaml)" -ne 1 ] \
+    || [ "$(grep -c 'type: string$' output/31-duplicate-type-entries.converted-30.yaml)" -ne 3 ] \
+    || [ "$(grep -c 'nullable: true$' output/31-duplicate-type-entries.converted-30.yaml)" -ne 1 ]; then
+    echo 'Expected repeated types in type arrays to be kept once'
+    exit_code=1
+fi
+
+convert_and_validate 31-nullable-enum 3.0 --polyfill-nullable-enum
+
+if [ "$(grep -c -- '- null$' output/31-nullable-enum.converted-30.yaml)" -ne 2 ]; then
+    echo 'Expected null in the enum of every nullable schema'
+    exit_code=1
+fi
+
+convert_and_validate 31-integer-or-number-types 3.0
+docker run --rm -i openapi-spec-converter:latest -t 3.0 \
+    < specs/31-integer-or-number-types.yaml \
+    > /dev/null 2> output/31-integer-or-number-types.warnings.txt
+
+if grep -q 'integer' output/31-integer-or-number-types.converted-30.yaml \
+    || ! grep -q 'Measurement/properties/value: folded integer into number' output/31-integer-or-number-types.warnings.txt \
+    || [ "$(grep -c 'folded integer into number' output/31-integer-or-number-types.warnings.txt)" -ne 3 ]; then
+    echo 'Expected {type: [integer, number]} to collapse to {type: number} with a warning'
+    exit_code=1
+fi
+
+convert_and_validate 30-paths-without-leading-slash 3.1 --fix-paths
+convert_and_validate 30-paths-without-leading-slash swagger --fix-paths
+convert_and_validate 30-vendor-extensions 3.1 --strip-ext x-internal-
+
+if grep -q 'x-internal-rate-limit\|x-internal-table' output/30-vendor-extensions.converted-31.yaml \
+    || ! grep -q 'x-public-stability' output/30-vendor-extensions.converted-31.yaml \
+    || ! grep -q 'x-internal-id:' output/30-vendor-extensions.converted-31.yaml; then
+    echo 'Expected only x-internal- extensions to be removed'
+    exit_code=1
+fi
+
+echo 'Converting 30-path-and-operation-servers to 3.1 with --output-openapi-version 3.1.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.1 -f yaml --output-openapi-version 3.1.0 \
+    < specs/30-path-and-operation-servers.yaml \
+    > output/30-path-and-operation-servers.openapi-310.yaml
+
+echo 'Validating 30-path-and-operation-servers converted with --output-openapi-version 3.1.0'
+if ! node_modules/.bin/redocly lint output/30-path-and-operation-servers.openapi-310.yaml 2>&1; then
+    exit_code=1
+fi
+
+if ! grep -q '^openapi: 3.1.0$' output/30-path-and-operation-servers.openapi-310.yaml; then
+    echo 'Expected --output-openapi-version to set the openapi version to 3.1.0'
+    exit_code=1
+fi
+
+# Serve specs/remote over HTTP so remote $refs can be downloaded.
+echo 'Converting 30-remote-refs to 3.1 with --allow-remote-refs'
+python3 -m http.server 8089 --bind 127.0.0.1 --directory specs/remote > /dev/null 2>&1 &
+remote_server_pid=$!
+sleep 1
+
+if docker run --rm -i openapi-spec-converter:latest -t 3.1 -f yaml \
+    < specs/30-remote-refs.yaml \
+    > /dev/null 2>&1; then
+    echo 'Expected remote references to be rejected without --allow-remote-refs'
+    exit_code=1
+fi
+
+docker run --rm -i --network host openapi-spec-converter:latest -t 3.1 -f yaml --allow-remote-refs \
+    < specs/30-remote-refs.yaml \
+    > output/30-remote-refs.converted-31.yaml
+
+kill "$remote_server_pid"
+
+echo 'Validating 30-remote-refs converted to 3.1'
+if ! node_modules/.bin/redocly lint output/30-remote-refs.converted-31.yaml 2>&1; then
+    exit_code=1
+fi
+
+if grep -q 'http://\|nullable' output/30-remote-refs.converted-31.yaml; then
+    echo 'Expected the remote schema to be inlined and converted to 3.1'
+    exit_code=1
+fi
+
+# specs/base/common.yaml supplies the servers and Error schema, but the input's empty security is kept.
+echo 'Converting 30-without-servers to 3.1 with --merge-base'
+docker run --rm -i -v "$PWD/specs/base:/base:ro" openapi-spec-converter:latest -t 3.1 -f yaml \
+    --merge-base /base/common.yaml \
+    < specs/30-without-servers.yaml \
+    > output/30-without-servers.converted-31.yaml
+
+echo 'Validating 30-without-servers converted to 3.1'
+if ! node_modules/.bin/redocly lint output/30-without-servers.converted-31.yaml 2>&1; then
+    exit_code=1
+fi
+
+if ! grep -q 'url: https://api.example.com/v1' output/30-without-servers.converted-31.yaml \
+    || ! grep -q 'title: Pets without servers' output/30-without-servers.converted-31.yaml \
+    || ! grep -q 'description: The pets.' output/30-without-servers.converted-31.yaml \
+    || ! grep -q '^    Error:' output/30-without-servers.converted-31.yaml \
+    || grep -q -- '- apiKey: \[\]' output/30-without-servers.converted-31.yaml; then
+    echo 'Expected --merge-base to add the base servers and components, keeping the input values'
+    exit_code=1
+fi
+
+# libopenapi accepts the undeclared path parameter, but kin-openapi rejects it.
+echo 'Converting 30-missing-path-parameter with --validate-strict'
+if docker run --rm -i openapi-spec-converter:latest -t 3.0 --validate-strict \
+    < specs/30-missing-path-parameter.yaml \
+    > /dev/null \
+    2> output/30-missing-path-parameter.validate.txt \
+    || ! grep -q '^kin-openapi: .*missing: \[petId\]' output/30-missing-path-parameter.validate.txt \
+    || grep -q '^libopenapi:' output/30-missing-path-parameter.validate.txt; then
+    echo 'Expected --validate-strict to report the kin-openapi error'
+    exit_code=1
+fi
+
+convert_and_validate 30-path-level-parameters 3.0 --validate-strict
+convert_and_validate 30-tree-node 3.1
+
+# Recursive schemas are valid, so the cycle is a warning unless asked to fail.
+docker run --rm -i openapi-spec-converter:latest -t 3.1 -f yaml \
+    < specs/30-tree-node.yaml \
+    > /dev/null \
+    2> output/30-tree-node.warnings.txt
+
+if ! grep -q 'components/schemas/TreeNode: schema reference cycle TreeNode -> TreeNode' output/30-tree-node.warnings.txt; then
+    echo 'Expected a warning for the schema reference cycle'
+    exit_code=1
+fi
+
+echo 'Converting 30-tree-node with --fail-on-ref-cycle'
+if docker run --rm -i openapi-spec-converter:latest -t swagger --fail-on-ref-cycle \
+    < specs/30-tree-node.yaml \
+    > /dev/null \
+    2> output/30-tree-node.fail.txt \
+    || ! grep -q 'Schema reference cycles: TreeNode -> TreeNode' output/30-tree-node.fail.txt; then
+    echo 'Expected --fail-on-ref-cycle to fail with the cycle path'
+    exit_code=1
+fi
+
+for name in 30-canonical-order-a 30-canonical-order-b; do
+    echo "Canonicalizing $name"
+    docker run --rm -i openapi-spec-converter:latest --canonicalize -f yaml \
+        < "specs/$name.yaml" \
+        > "output/$name.canonical.yaml"
+done
+
+# The fixtures only differ by key order, quoting, duplicate required entries and empty sections.
+if ! cmp -s output/30-canonical-order-a.canonical.yaml output/30-canonical-order-b.canonical.yaml; then
+    echo 'Expected equal documents to have identical canonical forms'
+    diff output/30-canonical-order-a.canonical.yaml output/30-canonical-order-b.canonical.yaml
+    exit_code=1
+fi
+
+echo 'Validating canonical 30-canonical-order-a'
+if ! node_modules/.bin/swagger-cli validate output/30-canonical-order-a.canonical.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 31-webhooks to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < specs/31-webhooks.yaml \
+    > output/31-webhooks.converted-30.yaml \
+    2> output/31-webhooks.warnings.txt
+
+if grep -q '^webhooks:' output/31-webhooks.converted-30.yaml \
+    || ! grep -q 'dropped 2 webhooks with 3 operations, OpenAPI 3.0 has no webhooks: newPet (1 operation), petSold (2 operations)$' output/31-webhooks.warnings.txt; then
+    echo 'Expected webhooks to be dropped with a warning listing them'
+    exit_code=1
+fi
+
+echo 'Converting 30-templated-servers from an environment variable'
+docker run --rm -e OPENAPI_SPEC="$(cat specs/30-templated-servers.yaml)" openapi-spec-converter:latest \
+    --input-env OPENAPI_SPEC -t 3.1 -f yaml \
+    > output/30-templated-servers.from-env.yaml
+
+if ! cmp -s output/30-templated-servers.from-env.yaml output/30-templated-servers.converted-31.yaml; then
+    echo 'Expected --input-env to convert the spec in the environment variable like stdin'
+    exit_code=1
+fi
+
+echo 'Converting 30-without-version without --assume-version'
+if docker run --rm -i openapi-spec-converter:latest -t 3.1 \
+    < specs/30-without-version.yaml \
+    > /dev/null 2>&1; then
+    echo 'Expected a document without a version to fail without --assume-version'
+    exit_code=1
+fi
+
+convert_and_validate 30-without-version 3.1 --assume-version 3.0
+
+# Forcing JSON on YAML input should fail, and forcing YAML on JSON input should
+# still parse, as JSON is valid YAML.
+echo 'Converting YAML input with --input-format json'
+if docker run --rm -i openapi-spec-converter:latest -t 3.0 --input-format json \
+    < specs/swagger-base-path-without-host.yaml \
+    > /dev/null 2>&1; then
+    echo 'Expected forcing JSON on YAML input to fail'
+    exit_code=1
+fi
+
+echo 'Converting JSON input with --input-format yaml'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f json \
+    < specs/swagger-base-path-without-host.yaml \
+    > output/swagger-base-path-without-host.swagger.json
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml --input-format yaml \
+    < output/swagger-base-path-without-host.swagger.json \
+    > output/swagger-base-path-without-host.input-format-yaml.yaml
+
+echo 'Validating JSON input converted with --input-format yaml'
+if ! node_modules/.bin/swagger-cli validate output/swagger-base-path-without-host.input-format-yaml.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 30-missing-operation-id with --require-operation-id'
+if docker run --rm -i openapi-spec-converter:latest -t 3.1 --require-operation-id \
+    < specs/30-missing-operation-id.yaml \
+    > /dev/null 2> output/30-missing-operation-id.errors.txt; then
+    echo 'Expected --require-operation-id to fail for operations without an operationId'
+    exit_code=1
+elif ! grep -q 'POST /pets, DELETE /pets/{id}' output/30-missing-operation-id.errors.txt; then
+    echo 'Expected the error to list the operations without an operationId'
+    exit_code=1
+fi
+
+# --drop-unsupported removes everything 3.0 can't describe, so the
+# kitchen sink must pass the same keyword check --fail-unknown-keywords runs.
+convert_and_validate 31-kitchen-sink 3.0 --drop-unsupported
+
+if grep -q 'patternProperties\|propertyNames\|dependentRequired\|unevaluatedProperties\|const:\|mutualTLS\|mtls\|identifier:\|\$comment' \
+    output/31-kitchen-sink.converted-30.yaml; then
+    echo 'Expected --drop-unsupported to remove 3.1 only keywords and features'
+    exit_code=1
+fi
+
+echo 'Checking 31-kitchen-sink converted with --drop-unsupported for unknown keywords'
+if ! docker run --rm -i openapi-spec-converter:latest -t 3.0 --fail-unknown-keywords \
+    < output/31-kitchen-sink.converted-30.yaml \
+    > /dev/null; then
+    echo 'Expected no unknown keywords after --drop-unsupported'
+    exit_code=1
+fi
+
+convert_and_validate 31-mutual-tls-only 3.0 --drop-unsupported
+docker run --rm -i openapi-spec-converter:latest -t 3.0 --drop-unsupported \
+    < specs/31-mutual-tls-only.yaml \
+    > /dev/null 2> output/31-mutual-tls-only.warnings.txt
+
+# An operation whose only requirement used mutualTLS inherits the root
+# security instead of becoming public with `security: []`.
+if [ "$(grep -c '^ *security:' output/31-mutual-tls-only.converted-30.yaml)" -ne 2 ] \
+    || [ "$(grep -c 'security: \[\]$' output/31-mutual-tls-only.converted-30.yaml)" -ne 1 ] \
+    || ! grep -q '#/paths/~1pets/get/security: dropped every security requirement' output/31-mutual-tls-only.warnings.txt; then
+    echo 'Expected operations that only required mutualTLS to inherit the root security'
+    exit_code=1
+fi
+
+# patternProperties has no 3.0 equivalent and is passed through, which
+# --fail-unknown-keywords must catch.
+echo 'Converting 31-pattern-properties with --fail-unknown-keywords'
+if docker run --rm -i openapi-spec-converter:latest -t 3.0 --fail-unknown-keywords \
+    < specs/31-pattern-properties.yaml \
+    > /dev/null 2> output/31-pattern-properties.errors.txt; then
+    echo 'Expected --fail-unknown-keywords to fail for patternProperties in 3.0'
+    exit_code=1
+elif ! grep -q '#/components/schemas/Labels/patternProperties' output/31-pattern-properties.errors.txt; then
+    echo 'Expected the error to list the patternProperties keyword'
+    exit_code=1
+fi
+
+# Operations with neither parameters nor responses aren't valid in every
+# version, so only check that they convert without crashing.
+for target in swagger 3.0 3.1; do
+    echo "Converting 30-operation-without-parameters-or-responses to $target"
+    if ! docker run --rm -i openapi-spec-converter:latest -t "$target" -f yaml \
+        < specs/30-operation-without-parameters-or-responses.yaml \
+        > /dev/null; then
+        echo "Expected operations without parameters or responses to convert to $target"
+        exit_code=1
+    fi
+done
+
+# Normalizing YAML without changing the version must keep comments.
+echo 'Normalizing 30-yaml-comments'
+docker run --rm -i openapi-spec-converter:latest --normalize -f yaml \
+    < specs/30-yaml-comments.yaml \
+    > output/30-yaml-comments.normalized.yaml
+
+if [ "$(head -n 1 output/30-yaml-comments.normalized.yaml)" != '# Pet store API, maintained by the pets team.' ] \
+    || ! grep -q 'operationId: listPets # used as the client method name' output/30-yaml-comments.normalized.yaml; then
+    echo 'Expected comments to be kept by --normalize'
+    exit_code=1
+fi
+
+echo 'Validating normalized 30-yaml-comments'
+if ! node_modules/.bin/swagger-cli validate output/30-yaml-comments.normalized.yaml; then
+    exit_code=1
+fi
+
+echo 'Writing warnings with --warnings-file'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml --warnings-file /dev/stderr \
+    < specs/30-path-and-operation-servers.yaml \
+    > /dev/null \
+    2> output/30-path-and-operation-servers.warnings.json
+
+if ! grep -q '"path": "#/paths/~1pets/servers"' output/30-path-and-operation-servers.warnings.json \
+    || ! grep -q '"path": "#/paths/~1pets/post/servers"' output/30-path-and-operation-servers.warnings.json; then
+    echo 'Expected the warnings file to contain the dropped servers warnings'
+    exit_code=1
+fi
+
+echo 'Printing warnings and timings with --log-format json'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml --verbose --log-format json \
+    < specs/30-path-and-operation-servers.yaml \
+    > /dev/null \
+    2> output/30-path-and-operation-servers.log.jsonl
+
+# Every line has to parse as JSON for log aggregators to read it.
+while IFS= read -r line; do
+    if ! node -e 'JSON.parse(process.argv[1])' "$line"; then
+        echo "Expected a JSON log line, got: $line"
+        exit_code=1
+    fi
+done < output/30-path-and-operation-servers.log.jsonl
+
+if ! grep -q '"level":"warning"' output/30-path-and-operation-servers.log.jsonl \
+    || ! grep -q '"level":"info"' output/30-path-and-operation-servers.log.jsonl; then
+    echo 'Expected JSON log lines for both warnings and timings'
+    exit_code=1
+fi
+
+echo 'Printing timings with --verbose and --timings-json'
+docker run --rm -i openapi-spec-converter:latest -t 3.1 --verbose \
+    < specs/swagger-base-path-without-host.yaml \
+    > /dev/null \
+    2> output/swagger-base-path-without-host.timings.txt
+docker run --rm -i openapi-spec-converter:latest -t 3.1 --timings-json \
+    < specs/swagger-base-path-without-host.yaml \
+    > /dev/null \
+    2> output/swagger-base-path-without-host.timings.json
+
+# Each stage is printed with its conversion and duration, e.g. "swagger -> 3.0 load: 1.2ms".
+if ! grep -qE '^swagger -> 3\.0 load: [0-9.]+(ns|µs|ms|s)$' output/swagger-base-path-without-host.timings.txt \
+    || ! grep -qE '^3\.0 -> 3\.1 [a-z ]+: [0-9.]+(ns|µs|ms|s)$' output/swagger-base-path-without-host.timings.txt; then
+    echo 'Expected --verbose to print the duration of each stage'
+    exit_code=1
+fi
+
+if ! node -e '
+    // Warnings may be logged before the timings, which are on the last line.
+    const lines = require("fs").readFileSync(process.argv[1], "utf8").trim().split("\n");
+    const timings = JSON.parse(lines[lines.length - 1]);
+    const valid = timings.length > 0 && timings.every((timing) =>
+        typeof timing.conversion === "string" && typeof timing.stage === "string"
+        && Number.isInteger(timing.duration) && timing.duration >= 0);
+    const conversions = new Set(timings.map((timing) => timing.conversion));
+    process.exit(valid && conversions.has("swagger -> 3.0") && conversions.has("3.0 -> 3.1") ? 0 : 1);
+' output/swagger-base-path-without-host.timings.json; then
+    echo 'Expected --timings-json to print a JSON array of stage names and durations'
+    exit_code=1
+fi
+
+echo 'Printing timings with --timings-json and --log-format json'
+docker run --rm -i openapi-spec-converter:latest -t 3.1 --timings-json --log-format json \
+    < specs/swagger-base-path-without-host.yaml \
+    > /dev/null \
+    2> output/swagger-base-path-without-host.timings.jsonl
+
+# Every line is a log entry, so the timings are one entry per stage instead of an array.
+if ! node -e '
+    const lines = require("fs").readFileSync(process.argv[1], "utf8").trim().split("\n");
+    const entries = lines.map((line) => JSON.parse(line));
+    const timings = entries.filter((entry) => entry.level === "info" && entry.stage);
+    process.exit(entries.every((entry) => !Array.isArray(entry)) && timings.length > 1
+        && timings.every((timing) => Number.isInteger(timing.duration)) ? 0 : 1);
+' output/swagger-base-path-without-host.timings.jsonl; then
+    echo 'Expected --timings-json with --log-format json to print one JSON line per stage'
+    exit_code=1
+fi
+
+echo 'Converting 30-postman-collection to a Postman collection'
+docker run --rm -i openapi-spec-converter:latest -t postman \
+    < specs/30-postman-collection.yaml \
+    > output/30-postman-collection.postman.json
+
+# The fixture has six operations, and each becomes one request.
+if [ "$(grep -c '"request":' output/30-postman-collection.postman.json)" -ne 6 ]; then
+    echo 'Expected one Postman request per operation'
+    exit_code=1
+fi
+
+echo 'Writing a conversion report with --report'
+docker run --rm -i openapi-spec-converter:latest -t 3.1 --report /dev/stderr \
+    < specs/swagger-base-path-without-host.yaml \
+    > /dev/null \
+    2> output/swagger-base-path-without-host.report.json
+
+if ! grep -q '"inputVersion": "2.0"' output/swagger-base-path-without-host.report.json \
+    || ! grep -q '"swagger -> 3.0"' output/swagger-base-path-without-host.report.json; then
+    echo 'Expected the report to contain the input version and the applied steps'
+    exit_code=1
+fi
+
+echo 'Refusing to overwrite an existing output file with --no-clobber'
+# /dev/null always exists, so the converter must refuse to write to it.
+if docker run --rm -i openapi-spec-converter:latest -t 3.1 --no-clobber -o /dev/null \
+    < specs/swagger-base-path-without-host.yaml \
+    2> output/swagger-base-path-without-host.no-clobber.txt \
+    || ! grep -q 'already exists' output/swagger-base-path-without-host.no-clobber.txt; then
+    echo 'Expected --no-clobber to refuse to overwrite an existing file'
+    exit_code=1
+fi
+
+echo 'Setting the output file permissions with --chmod'
+# Without --chmod an existing file keeps its permissions, with it they are replaced.
+install -m 0600 /dev/null output/swagger-base-path-without-host.chmod.yaml
+
+for mode in '' 0640; do
+    docker run --rm -i --user "$(id -u):$(id -g)" -v "$PWD/output:/output" \
+        openapi-spec-converter:latest -t 3.1 ${mode:+--chmod "$mode"} \
+        -o /output/swagger-base-path-without-host.chmod.yaml \
+        < specs/swagger-base-path-without-host.yaml
+
+    if [ "0$(stat -c '%a' output/swagger-base-path-without-host.chmod.yaml)" != "${mode:-0600}" ]; then
+        echo "Expected the output file permissions to be ${mode:-0600}"
+        exit_code=1
+    fi
+done
+
+echo 'Reading the input from a named pipe'
+# A named pipe has no size, the converter has to read until the writer closes it.
+rm -f output/swagger-base-path-without-host.fifo
+mkfifo output/swagger-base-path-without-host.fifo
+cat specs/swagger-base-path-without-host.yaml > output/swagger-base-path-without-host.fifo &
+
+if ! docker run --rm -i --user "$(id -u):$(id -g)" -v "$PWD/output:/output" \
+    openapi-spec-converter:latest -t 3.1 -f yaml \
+    /output/swagger-base-path-without-host.fifo \
+    > output/swagger-base-path-without-host.fifo-converted-31.yaml \
+    || ! cmp -s output/swagger-base-path-without-host.fifo-converted-31.yaml \
+        output/swagger-base-path-without-host.converted-31.yaml; then
+    echo 'Expected reading the input from a named pipe to match reading it from stdin'
+    exit_code=1
+fi
+
+wait
+rm -f output/swagger-base-path-without-host.fifo
+
+echo 'Converting multiple specs with --jsonl'
+docker run --rm -i openapi-spec-converter:latest -t 3.1 --jsonl \
+    < specs/multiple-specs.jsonl \
+    > output/multiple-specs.converted-31.jsonl \
+    2> output/multiple-specs.progress.txt
+
+if [ "$(wc -l < output/multiple-specs.converted-31.jsonl)" -ne 2 ]; then
+    echo 'Expected one converted spec per input line'
+    exit_code=1
+fi
+
+if [ "$(grep -c '^\[[0-9]/2\] line [0-9] converted$' output/multiple-specs.progress.txt)" -ne 2 ]; then
+    echo 'Expected one progress line per converted spec'
+    exit_code=1
+fi
+
+line_number=0
+
+while IFS= read -r line; do
+    line_number=$((line_number + 1))
+    echo "$line" > "output/multiple-specs.converted-31.$line_number.json"
+
+    echo "Validating --jsonl line $line_number converted to 3.1"
+    if ! node_modules/.bin/redocly lint "output/multiple-specs.converted-31.$line_number.json" 2>&1; then
+        exit_code=1
+    fi
+done < output/multiple-specs.converted-31.jsonl
+
+echo 'Converting multiple specs with a failing line with --jsonl'
+if docker run --rm -i openapi-spec-converter:latest -t 3.1 --jsonl \
+    < specs/multiple-specs-with-error.jsonl \
+    > output/multiple-specs-with-error.converted-31.jsonl \
+    2> output/multiple-specs-with-error.progress.txt; then
+    echo 'Expected --jsonl to fail when a line can not be converted'
+    exit_code=1
+fi
+
+# The failing line is reported, and the lines around it are still converted.
+if [ "$(wc -l < output/multiple-specs-with-error.converted-31.jsonl)" -ne 2 ] \
+    || ! grep -q '^\[2/3\] line 2 failed$' output/multiple-specs-with-error.progress.txt \
+    || ! grep -q '^\[3/3\] line 3 converted$' output/multiple-specs-with-error.progress.txt \
+    || ! grep -q 'Error converting document on line 2: ' output/multiple-specs-with-error.progress.txt; then
+    echo 'Expected the failing --jsonl line to be reported without stopping the other lines'
+    exit_code=1
+fi
+
+convert_and_validate 30-numeric-formats 3.1
+convert_and_validate 31-numeric-format-type-arrays 3.0
+
+# Only string formats are content fields in 3.1, numeric formats must be kept.
+echo 'Converting 30-numeric-formats back from 3.1 to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < output/30-numeric-formats.converted-31.yaml \
+    > output/30-numeric-formats.back-to-30.yaml
+
+for output in output/30-numeric-formats.converted-31.yaml output/30-numeric-formats.back-to-30.yaml; do
+    if [ "$(grep -c 'format: int32' "$output")" -ne 2 ] \
+        || ! grep -q 'format: int64' "$output" \
+        || ! grep -q 'format: double' "$output" \
+        || ! grep -q 'format: float' "$output"; then
+        echo "Expected numeric formats to be kept in $output"
+        exit_code=1
+    fi
+done
+
+# Splitting type arrays into oneOf moves the format to the branch it applies to.
+if ! grep -A3 -- '- type: integer' output/31-numeric-format-type-arrays.converted-30.yaml | grep -q 'format: int64' \
+    || ! grep -A3 -- '- type: number' output/31-numeric-format-type-arrays.converted-30.yaml | grep -q 'format: double' \
+    || ! grep -q 'format: float' output/31-numeric-format-type-arrays.converted-30.yaml; then
+    echo 'Expected numeric formats to be kept when splitting type arrays into oneOf'
+    exit_code=1
+fi
+
+convert_and_validate 30-schema-defaults 3.1 --examples-from-default
+
+# Defaults become examples, but existing examples and null defaults are kept.
+if ! grep -A2 'default: 20' output/30-schema-defaults.converted-31.yaml | grep -q -- '- 20' \
+    || ! grep -A2 'default: dog' output/30-schema-defaults.converted-31.yaml | grep -q -- '- dog' \
+    || grep -q -- '- Rex' output/30-schema-defaults.converted-31.yaml \
+    || [ "$(grep -c 'examples:' output/30-schema-defaults.converted-31.yaml)" -ne 3 ]; then
+    echo 'Expected --examples-from-default to add examples from defaults'
+    exit_code=1
+fi
+
+convert_and_validate 30-component-callbacks 3.1
+convert_and_validate 31-component-path-items 3.0
+
+# Schemas in component callbacks and path items must be converted too.
+if grep -q 'nullable' output/30-component-callbacks.converted-31.yaml \
+    || ! grep -q -- '- "null"' output/30-component-callbacks.converted-31.yaml; then
+    echo 'Expected schemas in components.callbacks to be converted'
+    exit_code=1
+fi
+
+if ! grep -q 'nullable: true' output/31-component-path-items.converted-30.yaml; then
+    echo 'Expected schemas in components.pathItems to be converted'
+    exit_code=1
+fi
+
+convert_and_validate 30-hoisted-schema-names 3.1 --dedupe-schemas --components-prefix Billing --schema-name-case snake_case
+
+# Hoisted schemas are named in the chosen case, and referenced by that name.
+if ! grep -q '^    billing_generated1:' output/30-hoisted-schema-names.converted-31.yaml \
+    || [ "$(grep -c "'#/components/schemas/billing_generated1'" output/30-hoisted-schema-names.converted-31.yaml)" -ne 2 ]; then
+    echo 'Expected --schema-name-case to name hoisted schemas in snake_case'
+    exit_code=1
+fi
+
+# Repeated lines are converted once and the cached result is used again.
+echo 'Converting repeated specs with --jsonl'
+cat specs/multiple-specs.jsonl specs/multiple-specs.jsonl \
+    | docker run --rm -i openapi-spec-converter:latest -t 3.1 --jsonl \
+    > output/multiple-specs.repeated-31.jsonl \
+    2> output/multiple-specs.repeated-progress.txt
+
+if ! diff <(cat output/multiple-specs.converted-31.jsonl output/multiple-specs.converted-31.jsonl) \
+    output/multiple-specs.repeated-31.jsonl \
+    || [ "$(grep -c 'converted (cached)$' output/multiple-specs.repeated-progress.txt)" -ne 2 ]; then
+    echo 'Expected repeated --jsonl lines to be converted once and then taken from the cache'
+    exit_code=1
+fi
+
+echo 'Converting with the serve subcommand'
+container=$(docker run --rm -d -p 127.0.0.1:18080:8080 openapi-spec-converter:latest serve)
+
+for _ in $(seq 10); do
+    if curl -sf http://127.0.0.1:18080/healthz > /dev/null; then
+        break
+    fi
+
+    sleep 1
+done
+
+if ! curl -sf --data-binary @specs/swagger-base-path-without-host.yaml \
+    'http://127.0.0.1:18080/convert?target=3.1&format=yaml' \
+    > output/swagger-base-path-without-host.served-31.yaml; then
+    echo 'Expected POST /convert to convert the document'
+    exit_code=1
+elif ! node_modules/.bin/redocly lint output/swagger-base-path-without-host.served-31.yaml 2>&1; then
+    exit_code=1
+fi
+
+# The same document was just converted, so the result comes from the cache.
+if ! curl -sf -D - -o /dev/null --data-binary @specs/swagger-base-path-without-host.yaml \
+    'http://127.0.0.1:18080/convert?target=3.1&format=yaml' \
+    | grep -qi '^X-Conversion-Cache: hit'; then
+    echo 'Expected a repeated POST /convert to be served from the cache'
+    exit_code=1
+fi
+
+if [ "$(curl -s -o /dev/null -w '%{http_code}' --data-binary 'not a spec' http://127.0.0.1:18080/convert)" -ne 422 ]; then
+    echo 'Expected POST /convert to reject documents which cannot be converted'
+    exit_code=1
+fi
+
+docker stop "$container" > /dev/null
+
 exit $exit_code